@@ -3,9 +3,9 @@ import json
 
 
 def parse_full(filename):
+    # game dumps are streamed as NDJSON: one game loop per line
     with open(filename) as json_file:
-        parsed_json = json.load(json_file)
-        iters = parsed_json["iteration"]
+        iters = [json.loads(line) for line in json_file if line.strip()]
         # print(iters[0].keys())
         parse_iteration_data(iters[0]["round"])
 
@@ -47,5 +47,5 @@ def parse_bike_data(bike_data):
     return agent_dirs
 
 
-# parse_full("gameDumps/mutable/00cd13d1-4d35-4667-aa9d-87c32f2d3eaf.json")
-parse_full("gameDumps/debug/76e34653-faaf-4805-b16c-6c8b8eb362ad.json")
+# parse_full("gameDumps/mutable/00cd13d1-4d35-4667-aa9d-87c32f2d3eaf.ndjson")
+parse_full("gameDumps/debug/76e34653-faaf-4805-b16c-6c8b8eb362ad.ndjson")
@@ -174,7 +174,7 @@ class Visualiser:
                 filepath = filedialog.askopenfilename(
                     initialdir=JSONPATH,
                     title="Select JSON file",
-                    filetypes=(("JSON files", "*.json"), ("all files", "*.*"))
+                    filetypes=(("Game dumps", "*.ndjson"), ("JSON files", "*.json"), ("all files", "*.*"))
                 )
                 root.destroy()
                 if filepath != "":
@@ -213,7 +213,11 @@ class Visualiser:
         Reads the simulated JSON file and stores the data
         """
         with open(filepath, "r", encoding="utf-8") as f:
-            data = json.load(f)
+            if filepath.endswith(".ndjson"):
+                # full game dumps are streamed with one line (the list of game states) per round
+                data = [json.loads(line) for line in f if line.strip()]
+            else:
+                data = json.load(f)
         self.jsondata = data
         self.gameScreenManager.set_json(data)
         self.UIElements["game_screen"] = self.gameScreenManager.init_ui(self.manager, self.UIscreen, self.consoleContainer)
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/replay"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+var configFile = flag.String("config", "", "path to the YAML/JSON scenario file to record (defaults are used for anything it omits)")
+var seed = flag.Int64("seed", 0, "seed of the recorded run (unset: the scenario's, or one picked from the clock)")
+var recordFile = flag.String("record", "", "path to write the recording of the scenario to")
+var verifyFile = flag.String("verify", "", "path of a recording to replay and compare against")
+
+// errDiverged is returned once a divergence has been reported
+var errDiverged = errors.New("the replay diverged from the recording")
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// with -verify a recording is replayed, with -record the scenario is recorded, and with neither the
+// scenario is recorded twice over and the two runs are compared
+func run() error {
+	if *verifyFile != "" {
+		recording, err := readRecording(*verifyFile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Replaying %s (seed %d)\n", *verifyFile, *recording.Config.Simulation.Seed)
+		divergence, err := replay.Verify(recording)
+		if err != nil {
+			return err
+		}
+		return report(divergence)
+	}
+
+	cfg, err := config.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if seedIsSet() {
+		cfg.Simulation.Seed = config.Seed(*seed)
+	}
+	recording, err := replay.Record(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *recordFile != "" {
+		if err := writeRecording(*recordFile, recording); err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %d snapshots (seed %d) to %s\n", len(recording.Snapshots), recording.Config.Simulation.Seed, *recordFile)
+		return nil
+	}
+
+	fmt.Printf("Replaying the run (seed %d)\n", *recording.Config.Simulation.Seed)
+	divergence, err := replay.Verify(recording)
+	if err != nil {
+		return err
+	}
+	return report(divergence)
+}
+
+// whether -seed was given explicitly (as any seed, 0 included, can be replayed)
+func seedIsSet() bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			set = true
+		}
+	})
+	return set
+}
+
+func report(divergence *replay.Divergence) error {
+	if divergence != nil {
+		fmt.Printf("Diverged at %s\n", divergence)
+		return errDiverged
+	}
+	fmt.Println("The replay matched the recording")
+	return nil
+}
+
+func readRecording(path string) (*replay.Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening recording: %w", err)
+	}
+	defer file.Close()
+	return replay.ReadRecording(file)
+}
+
+func writeRecording(path string, recording *replay.Recording) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating recording: %w", err)
+	}
+	if err := replay.WriteRecording(file, recording); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/sweep"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+)
+
+var configFile = flag.String("config", "", "path to the base YAML/JSON scenario file (defaults are used for anything it omits)")
+var agents = flag.String("agents", "", "comma separated agent counts to sweep over")
+var lootRatios = flag.String("loot", "", "comma separated lootbox to agent ratios to sweep over")
+var ruleCounts = flag.String("rules", "", "comma separated global rule counts to sweep over")
+var stratifyRules = flag.String("s", "", "comma separated rule stratification settings (true/false) to sweep over")
+var governanceMixes = flag.String("governance", "", "semicolon separated founding governance mixes to sweep over (e.g. \"agents;democracy:1;democracy:1,dictatorship:1\")")
+var repetitions = flag.Int("reps", 10, "number of runs of each configuration")
+var workers = flag.Int("workers", runtime.NumCPU(), "number of simulations run at once")
+var seed = flag.Int64("seed", 0, "seed of the first run of each configuration (unset: the scenario's, or one picked from the clock)")
+var csvFile = flag.String("csv", "sweep.csv", "path of the aggregated CSV results (empty to skip)")
+var jsonFile = flag.String("json", "sweep.json", "path of the aggregated JSON results (empty to skip)")
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "error running sweep: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	base, err := config.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if seedIsSet() {
+		base.Simulation.Seed = config.Seed(*seed)
+	}
+	if base.Simulation.Seed == nil {
+		base.Simulation.Seed = config.Seed(time.Now().UnixNano())
+	}
+
+	grid := sweep.Grid{}
+	if grid.Agents, err = sweep.ParseInts(*agents); err != nil {
+		return fmt.Errorf("-agents: %w", err)
+	}
+	if grid.LootRatios, err = sweep.ParseFloats(*lootRatios); err != nil {
+		return fmt.Errorf("-loot: %w", err)
+	}
+	if grid.RuleCounts, err = sweep.ParseInts(*ruleCounts); err != nil {
+		return fmt.Errorf("-rules: %w", err)
+	}
+	if grid.StratifyRules, err = sweep.ParseBools(*stratifyRules); err != nil {
+		return fmt.Errorf("-s: %w", err)
+	}
+	if grid.GovernanceMixes, err = sweep.ParseGovernanceMixes(*governanceMixes); err != nil {
+		return fmt.Errorf("-governance: %w", err)
+	}
+
+	configurations := grid.Configurations(base)
+	fmt.Printf("Sweeping %d configurations x %d repetitions on %d workers (seed %d)\n", len(configurations), *repetitions, *workers, *base.Simulation.Seed)
+	start := time.Now()
+	results, err := sweep.Run(configurations, *repetitions, *workers)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Sweep completed in %s\n", time.Since(start).Round(time.Millisecond))
+
+	if err := writeFile(*csvFile, results, sweep.WriteCSV); err != nil {
+		return err
+	}
+	return writeFile(*jsonFile, results, sweep.WriteJSON)
+}
+
+// whether -seed was given explicitly (as any seed, 0 included, can be reproduced)
+func seedIsSet() bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			set = true
+		}
+	})
+	return set
+}
+
+func writeFile(path string, results []sweep.Result, write func(io.Writer, []sweep.Result) error) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating results file: %w", err)
+	}
+	if err := write(file, results); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -3,8 +3,14 @@ package teamSOSA
 import (
 	"SOMAS2023/internal/clients/teamSOSA/agent"
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/registry"
 )
 
+// spawned with -mix=sosa:<count>
+func init() {
+	registry.Register("sosa", GetBiker)
+}
+
 // this function is going to be called by the server to instantiate bikers in the MVP
 func GetBiker(baseBiker *objects.BaseBiker) objects.IBaseBiker {
 	baseBiker.GroupID = 2
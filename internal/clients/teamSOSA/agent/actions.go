@@ -7,7 +7,6 @@ import (
 	"SOMAS2023/internal/common/voting"
 	"maps"
 	"math"
-	"math/rand"
 	"runtime"
 
 	"github.com/google/uuid"
@@ -69,7 +68,7 @@ func (a *AgentSOSA) DecideWeights(action utils.Action) map[uuid.UUID]float64 {
 	// We set the weight for an Agent to be equal to its Social Capital.
 	weights := make(map[uuid.UUID]float64)
 	agents := a.GetFellowBikers()
-	compRoll := rand.Float64()
+	compRoll := a.GetRandomSource().Float64()
 	willComply := compRoll < a.Modules.AgentParameters.Trustworthiness
 	for _, agent := range agents {
 		// if agent Id is not in the a.Modules.SocialCapital.SocialCapital map, set the weight to 0.5 (neither trust or distrust)
@@ -128,7 +127,7 @@ func (a *AgentSOSA) DecideGovernance() utils.Governance {
 	// Need to decide weights for each type of Governance
 	// Can add an invalid weighting so that it is not 50/50
 
-	randomNumber := rand.Float64()
+	randomNumber := a.GetRandomSource().Float64()
 	if randomNumber < democracyWeight {
 		return utils.Democracy
 	} else if randomNumber < democracyWeight+leadershipWeight {
@@ -166,8 +165,8 @@ func (a *AgentSOSA) DecideDictatorAllocation() voting.IdVoteMap {
 
 	// Calculate the total social capital
 	totalSocialCapital := 0.0
-	for _, sc := range socialCapital {
-		totalSocialCapital += sc
+	for _, agentID := range utils.SortedIDs(socialCapital) {
+		totalSocialCapital += socialCapital[agentID]
 	}
 
 	// Distribute the allocation based on each agent's share of the total social capital
@@ -262,7 +261,8 @@ func (a *AgentSOSA) FinalDirectionVote(proposals map[uuid.UUID]uuid.UUID) voting
 	// Assume we set our own social capital to 1.0, thus need to account for it
 	weight := 1.0 / (a.Modules.AgentParameters.GetSumOfTrust() + 1)
 
-	for proposerID, proposal := range proposals {
+	for _, proposerID := range utils.SortedIDs(proposals) {
+		proposal := proposals[proposerID]
 		scWeight := 0.0
 		if proposerID == a.GetID() {
 			// If the proposal is our own, we vote for it with full weight
@@ -315,7 +315,7 @@ func (a *AgentSOSA) DecideForce(direction uuid.UUID) {
 		return
 		// lootboxId := a.Modules.Environment.GetHighestGainLootbox()
 		// lootboxPos := a.Modules.Environment.GetLootboxPos(lootboxId)
-		// a.SetForces(a.Modules.Utils.GetForcesToTarget(a.GetLocation(), lootboxPos))
+		// a.SetForces(a.Modules.Utils.GetForcesToTarget(a.GetGameState().GetConfig().Physics.BikerMaxForce, a.GetLocation(), lootboxPos))
 		// return
 	}
 
@@ -325,20 +325,20 @@ func (a *AgentSOSA) DecideForce(direction uuid.UUID) {
 		// fmt.Printf("[DecideForce] Agent %s is near Awdi\n", a.GetID())
 		// Move in opposite direction to Awdi in full force
 		bikePos, awdiPos := a.Modules.Environment.GetBike().GetPosition(), a.Modules.Environment.GetAwdi().GetPosition()
-		force := a.Modules.Utils.GetForcesToTargetWithDirectionOffset(utils.BikerMaxForce, 1.0-a.Modules.Environment.GetBikeOrientation(), bikePos, awdiPos)
+		force := a.Modules.Utils.GetForcesToTargetWithDirectionOffset(a.GetGameState().GetConfig().Physics.BikerMaxForce, 1.0-a.Modules.Environment.GetBikeOrientation(), bikePos, awdiPos)
 		a.SetForces(force)
 		return
 	}
 	// Use the average social capital to decide whether to pedal in the voted direciton or not
 	probabilityOfConformity := a.Modules.AgentParameters.GetAverageTrust()
-	randomNumber := rand.Float64()
+	randomNumber := a.GetRandomSource().Float64()
 	agentPosition := a.GetLocation()
 	lootboxID := direction
 	if randomNumber > probabilityOfConformity {
 		lootboxID = a.Modules.Environment.GetHighestGainLootbox()
 	}
 	lootboxPosition := a.Modules.Environment.GetLootboxPos(lootboxID)
-	force := a.Modules.Utils.GetForcesToTargetWithDirectionOffset(utils.BikerMaxForce, -a.Modules.Environment.GetBikeOrientation(), agentPosition, lootboxPosition)
+	force := a.Modules.Utils.GetForcesToTargetWithDirectionOffset(a.GetGameState().GetConfig().Physics.BikerMaxForce, -a.Modules.Environment.GetBikeOrientation(), agentPosition, lootboxPosition)
 	a.SetForces(force)
 }
 
@@ -2,23 +2,28 @@ package agent
 
 import (
 	"SOMAS2023/internal/clients/teamSOSA/modules"
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/utils"
+	"math/rand"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
+// random source for the agents built by the tests
+var testRng = rand.New(rand.NewSource(0))
+
 func TestNewBaseTeam2Biker(t *testing.T) {
-	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), &MockGameState{}))
+	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), &MockGameState{}, testRng))
 	assert.NotNil(t, agent)
 	assert.Equal(t, 0, agent.BaseBiker.GetPoints())
 	assert.Equal(t, 1.0, agent.BaseBiker.GetEnergyLevel())
 }
 
 func TestClippingSocialCapital(t *testing.T) {
-	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), &MockGameState{}))
+	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), &MockGameState{}, testRng))
 	testAgentID := uuid.New()
 
 	// Set up predefined values for trust, institution, and network
@@ -60,17 +65,17 @@ func TestCosineSimilarity(t *testing.T) {
 func generateMockupBike() (*AgentSOSA, objects.IMegaBike) {
 
 	mgs := &MockGameState{bikes: make(map[uuid.UUID]objects.IMegaBike)}
-	bike := objects.GetMegaBike(mgs)
+	bike := objects.GetMegaBike(mgs, config.DefaultConfig(), testRng)
 
 	mgs.SetTestingBike(bike)
 
-	simLeader := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), mgs))
+	simLeader := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), mgs, testRng))
 	bike.AddAgent(simLeader)
 	simLeader.SetBike(bike.GetID())
 	simLeader.ToggleOnBike()
 
 	for i := 0; i < 7; i++ {
-		fakeAgent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), mgs))
+		fakeAgent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), mgs, testRng))
 		bike.AddAgent(fakeAgent)
 		fakeAgent.SetBike(bike.GetID())
 		fakeAgent.ToggleOnBike()
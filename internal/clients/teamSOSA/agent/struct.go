@@ -34,8 +34,8 @@ func NewAgentSOSA(baseBiker *objects.BaseBiker) *AgentSOSA {
 	return &AgentSOSA{
 		BaseBiker: baseBiker,
 		Modules: AgentModules{
-			Environment:     modules.GetEnvironmentModule(baseBiker.GetID(), baseBiker.GetGameState(), baseBiker.GetBike()),
-			AgentParameters: modules.NewAgentParameters(),
+			Environment:     modules.GetEnvironmentModule(baseBiker.GetID(), baseBiker.GetGameState(), baseBiker.GetBike(), baseBiker.GetRandomSource()),
+			AgentParameters: modules.NewAgentParameters(baseBiker.GetRandomSource()),
 			Decision:        modules.NewDecisionModule(),
 			Utils:           modules.NewUtilsModule(),
 			VotedDirection:  uuid.Nil,
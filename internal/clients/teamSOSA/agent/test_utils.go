@@ -1,7 +1,11 @@
 package agent
 
 import (
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/spatial"
+	"SOMAS2023/internal/common/utils"
+	"math/rand"
 
 	"github.com/google/uuid"
 )
@@ -27,7 +31,15 @@ func (mgs *MockGameState) GetAgentMap() map[uuid.UUID]objects.IBaseBiker {
 }
 
 func (mgs *MockGameState) GetAwdi() objects.IAwdi {
-	return objects.GetIAwdi()
+	return objects.GetIAwdi(config.DefaultConfig(), rand.New(rand.NewSource(0)))
+}
+
+func (mgs *MockGameState) GetAwdis() []objects.IAwdi {
+	return []objects.IAwdi{mgs.GetAwdi()}
+}
+
+func (mgs *MockGameState) GetConfig() config.Config {
+	return *config.DefaultConfig()
 }
 
 func (mgs *MockGameState) ViewGlobalRuleCache() map[uuid.UUID]*objects.Rule {
@@ -35,3 +47,32 @@ func (mgs *MockGameState) ViewGlobalRuleCache() map[uuid.UUID]*objects.Rule {
 }
 
 func (mgs *MockGameState) AddToGlobalRuleCache(*objects.Rule) {}
+
+func (mgs *MockGameState) GetNearestLootBox(utils.Coordinates, func(objects.ILootBox) bool) objects.ILootBox {
+	return nil
+}
+
+func (mgs *MockGameState) GetLootBoxesInRadius(utils.Coordinates, float64) map[uuid.UUID]objects.ILootBox {
+	return make(map[uuid.UUID]objects.ILootBox)
+}
+
+func (mgs *MockGameState) GetNearestMegaBike(position utils.Coordinates, accept func(objects.IMegaBike) bool) objects.IMegaBike {
+	grid := spatial.NewGrid(config.DefaultConfig().Environment.SpatialCellSize)
+	for id, bike := range mgs.bikes {
+		grid.Insert(id, bike.GetPosition())
+	}
+	id, _ := grid.Nearest(position, func(id uuid.UUID) bool {
+		return accept == nil || accept(mgs.bikes[id])
+	})
+	return mgs.bikes[id]
+}
+
+func (mgs *MockGameState) GetMegaBikesInRadius(position utils.Coordinates, radius float64) map[uuid.UUID]objects.IMegaBike {
+	bikes := make(map[uuid.UUID]objects.IMegaBike)
+	for id, bike := range mgs.bikes {
+		if spatial.Distance(position, bike.GetPosition()) <= radius {
+			bikes[id] = bike
+		}
+	}
+	return bikes
+}
@@ -20,7 +20,7 @@ func TestRuleAdherenceValue_SameDirection(t *testing.T) {
 	actualAction := utils.Forces{Pedal: 0.2, Turning: turningDecision}
 
 	// Create an instance of AgentSOSA
-	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), &MockGameState{}))
+	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), &MockGameState{}, testRng))
 
 	// Call the function
 	result := agent.Modules.Utils.RuleAdherenceValue(agentID, expectedAction, actualAction)
@@ -47,7 +47,7 @@ func TestRuleAdherenceValue_OppositeDirection(t *testing.T) {
 	actualAction := utils.Forces{Pedal: 0.2, Turning: ActualTurningDecision}
 
 	// Create an instance of AgentSOSA
-	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), &MockGameState{}))
+	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), &MockGameState{}, testRng))
 
 	// Call the function
 	result := agent.Modules.Utils.RuleAdherenceValue(agentID, expectedAction, actualAction)
@@ -74,7 +74,7 @@ func TestRuleAdherenceValue_OrthogonalDirection(t *testing.T) {
 	actualAction := utils.Forces{Pedal: 0.2, Turning: ActualTurningDecision}
 
 	// Create an instance of AgentSOSA
-	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), &MockGameState{}))
+	agent := NewAgentSOSA(objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), &MockGameState{}, testRng))
 
 	// Call the function
 	result := agent.Modules.Utils.RuleAdherenceValue(agentID, expectedAction, actualAction)
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"SOMAS2023/internal/common/utils"
 	"math/rand"
 
 	"github.com/google/uuid"
@@ -13,8 +14,8 @@ type AgentParameters struct {
 
 func (ap *AgentParameters) GetSumOfTrust() float64 {
 	var sum = 0.0
-	for _, value := range ap.TrustNetwork {
-		sum += value
+	for _, agentId := range utils.SortedIDs(ap.TrustNetwork) {
+		sum += ap.TrustNetwork[agentId]
 	}
 	return sum
 }
@@ -34,7 +35,8 @@ func (ap *AgentParameters) GetMinimumTrust() IDTrustPair {
 	minTrust := 2.0
 	minAgentId := uuid.Nil
 
-	for agentId, trust := range ap.TrustNetwork {
+	for _, agentId := range utils.SortedIDs(ap.TrustNetwork) {
+		trust := ap.TrustNetwork[agentId]
 		if trust < minTrust {
 			minTrust = trust
 			minAgentId = agentId
@@ -47,7 +49,8 @@ func (ap *AgentParameters) GetMaximumTrust() IDTrustPair {
 	maxTrust := -2.0
 	maxAgentId := uuid.Nil
 
-	for agentId, value := range ap.TrustNetwork {
+	for _, agentId := range utils.SortedIDs(ap.TrustNetwork) {
+		value := ap.TrustNetwork[agentId]
 		if value > maxTrust {
 			maxTrust = value
 			maxAgentId = agentId
@@ -103,9 +106,9 @@ func (ap *AgentParameters) UpdateTrustValue(agentID uuid.UUID, eventValue, event
 // 	// fmt.Printf("[UpdateSocialCapital] Social Capital After: %v\n", sc.SocialCapital)
 // }
 
-func NewAgentParameters() *AgentParameters {
+func NewAgentParameters(rng *rand.Rand) *AgentParameters {
 	return &AgentParameters{
-		Trustworthiness: rand.Float64(),
+		Trustworthiness: rng.Float64(),
 		TrustNetwork:    make(map[uuid.UUID]float64),
 	}
 }
@@ -2,6 +2,7 @@ package modules
 
 import (
 	objects "SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"math"
 	"math/rand"
@@ -14,9 +15,10 @@ const (
 )
 
 type EnvironmentModule struct {
-	AgentId   uuid.UUID
-	GameState objects.IGameState
-	BikeId    uuid.UUID
+	AgentId      uuid.UUID
+	GameState    objects.IGameState
+	BikeId       uuid.UUID
+	RandomSource *rand.Rand // the agent's random source
 }
 
 ///
@@ -36,8 +38,8 @@ func (e *EnvironmentModule) GetLootboxPos(lootboxId uuid.UUID) utils.Coordinates
 }
 
 func (e *EnvironmentModule) GetRandomLootbox() uuid.UUID {
-	for _, lootbox := range e.GetLootBoxes() {
-		return lootbox.GetID()
+	if lootboxId := utils.PickRandomID(e.RandomSource, e.GetLootBoxes()); lootboxId != uuid.Nil {
+		return lootboxId
 	}
 	panic("No lootboxes found.")
 }
@@ -54,19 +56,9 @@ func (e *EnvironmentModule) GetLootBoxesByColor(color utils.Colour) map[uuid.UUI
 }
 
 func (e *EnvironmentModule) GetNearestLootbox(agentId uuid.UUID) uuid.UUID {
-	nearestLootbox := uuid.Nil
-	minDist := math.MaxFloat64
-	for _, lootbox := range e.GetLootBoxes() {
-		if e.IsLootboxNearAwdi(lootbox.GetID()) {
-			// fmt.Printf("[GetNearestLootbox] Lootbox %v is near awdi\n", lootbox.GetID())
-			continue
-		}
-		dist := e.GetDistanceToLootbox(lootbox.GetID())
-		if dist < minDist {
-			minDist = dist
-			nearestLootbox = lootbox.GetID()
-		}
-	}
+	nearestLootbox := e.nearestLootboxNotNearAwdi(func(objects.ILootBox) bool {
+		return true
+	})
 
 	if nearestLootbox == uuid.Nil {
 		nearestLootbox = e.GetRandomLootbox()
@@ -92,17 +84,10 @@ func (e *EnvironmentModule) GetNearestLootboxFromSubset(agentId uuid.UUID, subse
 
 func (e *EnvironmentModule) GetNearestLootboxByColor(agentId uuid.UUID, color utils.Colour) uuid.UUID {
 	nearestLootbox := e.GetNearestLootbox(agentId) // Defaults to nearest lootbox
-	minDist := math.MaxFloat64
-	for _, lootbox := range e.GetLootBoxesByColor(color) {
-		if e.IsLootboxNearAwdi(lootbox.GetID()) {
-			// fmt.Printf("[GetNearestLootboxByColor] Lootbox %v is near awdi\n", lootbox.GetID())
-			continue
-		}
-		dist := e.GetDistanceToLootbox(lootbox.GetID())
-		if dist < minDist {
-			minDist = dist
-			nearestLootbox = lootbox.GetID()
-		}
+	if nearestByColor := e.nearestLootboxNotNearAwdi(func(lootbox objects.ILootBox) bool {
+		return lootbox.GetColour() == color
+	}); nearestByColor != uuid.Nil {
+		nearestLootbox = nearestByColor
 	}
 
 	if nearestLootbox == uuid.Nil {
@@ -134,6 +119,18 @@ func (e *EnvironmentModule) GetNearestLootboxByColorFromSubset(agentId uuid.UUID
 	return nearestLootbox
 }
 
+// Gets the nearest of the lootboxes accepted that aren't near the awdi (uuid.Nil if there's none).
+func (e *EnvironmentModule) nearestLootboxNotNearAwdi(accept func(objects.ILootBox) bool) uuid.UUID {
+	bikePos, awdiPos := e.GetBikeById(e.BikeId).GetPosition(), e.GetAwdi().GetPosition()
+	nearest := e.GameState.GetNearestLootBox(bikePos, func(lootbox objects.ILootBox) bool {
+		return e.GetDistance(lootbox.GetPosition(), awdiPos) > AwdiRange && accept(lootbox)
+	})
+	if nearest == nil {
+		return uuid.Nil
+	}
+	return nearest.GetID()
+}
+
 func (e *EnvironmentModule) GetDistanceToLootbox(lootboxId uuid.UUID) float64 {
 	bikePos, agntPos := e.GetBikeById(e.BikeId).GetPosition(), e.GetLootBoxById(lootboxId).GetPosition()
 
@@ -178,16 +175,10 @@ func (e *EnvironmentModule) GetNearestLootboxAwayFromAwdi() uuid.UUID {
 	awayPos := utils.Coordinates{X: awayX, Y: awayY}
 
 	// Find nearest lootbox away from awdi.
-	minLoot := uuid.Nil
-	minDist := math.MaxFloat64
-	for id, lootbox := range e.GetLootBoxes() {
-		dist := e.GetDistance(awayPos, lootbox.GetPosition())
-		if dist < minDist {
-			minDist = dist
-			minLoot = id
-		}
+	if minLoot := e.GameState.GetNearestLootBox(awayPos, nil); minLoot != nil {
+		return minLoot.GetID()
 	}
-	return minLoot
+	return uuid.Nil
 }
 
 ///
@@ -218,7 +209,8 @@ func (e *EnvironmentModule) GetBikerWithMaxSocialCapital(ap *AgentParameters) ID
 	fellowBikers := e.GetBikerAgents()
 	maxSCAgentId := uuid.Nil
 	maxSC := -2.0
-	for _, fellowBiker := range fellowBikers {
+	for _, fellowBikerId := range utils.SortedIDs(fellowBikers) {
+		fellowBiker := fellowBikers[fellowBikerId]
 		if sc, ok := ap.TrustNetwork[e.AgentId]; ok {
 			if sc >= maxSC {
 				maxSCAgentId = fellowBiker.GetID()
@@ -233,7 +225,8 @@ func (e *EnvironmentModule) GetBikerWithMinSocialCapital(ap *AgentParameters) ID
 	fellowBikers := e.GetBikerAgents()
 	minSCAgentId := uuid.Nil
 	minSC := math.MaxFloat64
-	for _, fellowBiker := range fellowBikers {
+	for _, fellowBikerId := range utils.SortedIDs(fellowBikers) {
+		fellowBiker := fellowBikers[fellowBikerId]
 		if sc, ok := ap.TrustNetwork[e.AgentId]; ok {
 			if sc < minSC {
 				minSCAgentId = fellowBiker.GetID()
@@ -248,13 +241,7 @@ func (e *EnvironmentModule) GetBikerWithMinSocialCapital(ap *AgentParameters) ID
 	}
 	// Otherwise, return a random agent.
 	if len(fellowBikers) > 1 {
-		i, targetI := 0, rand.Intn(len(fellowBikers))
-		for id := range fellowBikers {
-			if i == targetI {
-				return IDTrustPair{ID: id, Trust: minSC}
-			}
-			i++
-		}
+		return IDTrustPair{ID: utils.PickRandomID(e.RandomSource, fellowBikers), Trust: minSC}
 	}
 	panic("No agents found to kick off.")
 	// return IDTrustPair{ID: uuid.Nil, Trust: math.NaN()}
@@ -266,7 +253,8 @@ func (e *EnvironmentModule) GetBikeWithMaximumSocialCapital(ap *AgentParameters)
 	maxBikeId := uuid.Nil
 
 	bikes := e.GetBikes()
-	for bikeId, bike := range bikes {
+	for _, bikeId := range utils.SortedIDs(bikes) {
+		bike := bikes[bikeId]
 		totalSocialCapital := float64(0)
 		agentCount := float64(len(bike.GetAgents()))
 
@@ -292,12 +280,8 @@ func (e *EnvironmentModule) GetBikeWithMaximumSocialCapital(ap *AgentParameters)
 	}
 
 	// Otherwise, change to a random bike.
-	i, targetI := 0, rand.Intn(len(bikes))
-	for id := range bikes {
-		if i == targetI {
-			return id
-		}
-		i++
+	if bikeId := utils.PickRandomID(e.RandomSource, bikes); bikeId != uuid.Nil {
+		return bikeId
 	}
 	panic("No bikes found to change to.")
 
@@ -340,13 +324,14 @@ func (e *EnvironmentModule) GetBikerAgents() map[uuid.UUID]objects.IBaseBiker {
 
 func (e *EnvironmentModule) GetDistance(pos1, pos2 utils.Coordinates) float64 {
 
-	return math.Sqrt(math.Pow(pos1.X-pos2.X, 2) + math.Pow(pos1.Y-pos2.Y, 2))
+	return math.Sqrt(physics.ComputeDistance(pos1, pos2, e.GameState.GetConfig().Environment))
 }
 
-func GetEnvironmentModule(agentId uuid.UUID, gameState objects.IGameState, bikeId uuid.UUID) *EnvironmentModule {
+func GetEnvironmentModule(agentId uuid.UUID, gameState objects.IGameState, bikeId uuid.UUID, rng *rand.Rand) *EnvironmentModule {
 	return &EnvironmentModule{
-		AgentId:   agentId,
-		GameState: gameState,
-		BikeId:    bikeId,
+		AgentId:      agentId,
+		GameState:    gameState,
+		BikeId:       bikeId,
+		RandomSource: rng,
 	}
 }
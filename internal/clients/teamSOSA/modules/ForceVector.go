@@ -25,9 +25,9 @@ func (fv *ForceVector) CosineSimilarity(vec ForceVector) float64 {
 	return fv.Dot(vec) / (fv.Magnitude() * vec.Magnitude())
 }
 
-func (fv *ForceVector) ConvertToForce() utils.Forces {
+func (fv *ForceVector) ConvertToForce(maxForce float64) utils.Forces {
 	return utils.Forces{
-		Pedal: math.Min(math.Sqrt(math.Pow(fv.X, 2)+math.Pow(fv.Y, 2)), utils.BikerMaxForce),
+		Pedal: math.Min(math.Sqrt(math.Pow(fv.X, 2)+math.Pow(fv.Y, 2)), maxForce),
 		Turning: utils.TurningDecision{
 			SteeringForce: math.Atan2(fv.Y, fv.X) / math.Pi,
 		},
@@ -27,7 +27,7 @@ func (um *UtilsModule) ProjectForce(actual, expected utils.Forces) float64 {
 }
 
 // Get the forces to the target coordinated
-func (um *UtilsModule) GetForcesToTarget(agentPosition, targetPosition utils.Coordinates) utils.Forces {
+func (um *UtilsModule) GetForcesToTarget(force float64, agentPosition, targetPosition utils.Coordinates) utils.Forces {
 
 	deltaX := targetPosition.X - agentPosition.X
 	deltaY := targetPosition.Y - agentPosition.Y
@@ -38,7 +38,7 @@ func (um *UtilsModule) GetForcesToTarget(agentPosition, targetPosition utils.Coo
 		SteeringForce: normalisedAngle,
 	}
 	return utils.Forces{
-		Pedal:   utils.BikerMaxForce,
+		Pedal:   force,
 		Brake:   0.0,
 		Turning: turningDecision,
 	}
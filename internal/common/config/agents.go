@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TeamCount is the number of agents a registered team (see the registry package) has in a run
+type TeamCount struct {
+	Team  string
+	Count int
+}
+
+// AgentMix gives the number of agents of each team in a run, the teams being spawned in order.
+// When it's set, the agent count is the total of the mix; otherwise the agents are split evenly
+// between the teams the server was given.
+type AgentMix []TeamCount
+
+func (m AgentMix) IsSet() bool {
+	return len(m) > 0
+}
+
+func (m AgentMix) Total() int {
+	total := 0
+	for _, team := range m {
+		total += team.Count
+	}
+	return total
+}
+
+// String formats the mix in the form accepted by ParseAgentMix
+func (m AgentMix) String() string {
+	parts := make([]string, len(m))
+	for i, team := range m {
+		parts[i] = team.Team + ":" + strconv.Itoa(team.Count)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m AgentMix) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *AgentMix) UnmarshalText(text []byte) error {
+	mix, err := ParseAgentMix(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mix
+	return nil
+}
+
+// ParseAgentMix reads a mix written as comma separated team:count pairs (e.g. "sosa:40,base:20").
+// An empty string leaves the mix unset.
+func ParseAgentMix(text string) (AgentMix, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	mix := make(AgentMix, 0)
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(text, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || name == "" {
+			return nil, fmt.Errorf("agent mix entry %q is not of the form team:count", pair)
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("agent mix count for %s: %w", name, err)
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("agent mix count for %s must not be negative", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("team %s appears more than once in the agent mix", name)
+		}
+		seen[name] = true
+		mix = append(mix, TeamCount{Team: name, Count: count})
+	}
+	return mix, nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+/*
+Awdi Targeting
+*/
+type AwdiTargeting int
+
+const (
+	SlowestTargeting   AwdiTargeting = iota // the slowest bike, the nearest one breaking ties
+	NearestTargeting                        // the nearest bike
+	RichestTargeting                        // the bike with the most energy in its pool, the nearest one breaking ties
+	PopulousTargeting                       // the bike with the most riders, the nearest one breaking ties
+	InterceptTargeting                      // the bike the awdi can catch up with the soonest, given where it's heading
+	PatrolTargeting                         // no bike: the awdi patrols between random waypoints
+	NumAwdiTargetings                       // add a sentinel for counting the number of awdi targetings
+)
+
+func (t AwdiTargeting) String() string {
+	switch t {
+	case SlowestTargeting:
+		return "slowest"
+	case NearestTargeting:
+		return "nearest"
+	case RichestTargeting:
+		return "richest"
+	case PopulousTargeting:
+		return "most_populous"
+	case InterceptTargeting:
+		return "intercept"
+	case PatrolTargeting:
+		return "patrol"
+	default:
+		return "unknown"
+	}
+}
+
+// allows awdi targetings to be written by name in scenario files
+func (t AwdiTargeting) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *AwdiTargeting) UnmarshalText(text []byte) error {
+	for targeting := SlowestTargeting; targeting < NumAwdiTargetings; targeting++ {
+		if targeting.String() == string(text) {
+			*t = targeting
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown awdi targeting %q", string(text))
+}
+
+/*
+Awdi Pursuit
+*/
+type AwdiPursuit int
+
+const (
+	DirectPursuit    AwdiPursuit = iota // steering straight at where the target is
+	InterceptPursuit                    // steering at where the target will be met, given its velocity
+	NumAwdiPursuits                     // add a sentinel for counting the number of awdi pursuits
+)
+
+func (p AwdiPursuit) String() string {
+	switch p {
+	case DirectPursuit:
+		return "direct"
+	case InterceptPursuit:
+		return "intercept"
+	default:
+		return "unknown"
+	}
+}
+
+// allows awdi pursuits to be written by name in scenario files
+func (p AwdiPursuit) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *AwdiPursuit) UnmarshalText(text []byte) error {
+	for pursuit := DirectPursuit; pursuit < NumAwdiPursuits; pursuit++ {
+		if pursuit.String() == string(text) {
+			*p = pursuit
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown awdi pursuit %q", string(text))
+}
+
+// AwdiSpec describes one of the awdis of a run
+type AwdiSpec struct {
+	Strategy AwdiTargeting      `json:"strategy" yaml:"strategy"`                     // which bike the awdi goes after
+	Mass     float64            `json:"mass" yaml:"mass"`                             // 0 for physics.mass_awdi
+	Position *utils.Coordinates `json:"position,omitempty" yaml:"position,omitempty"` // where the awdi spawns (a random position if not given)
+}
+
+// AwdiFleet gives the awdis of a run, which are spawned in order
+type AwdiFleet []AwdiSpec
+
+func (f AwdiFleet) validate() error {
+	if len(f) == 0 {
+		return errors.New("awdi.fleet must have at least one awdi")
+	}
+	for i, spec := range f {
+		switch {
+		case spec.Strategy < 0 || spec.Strategy >= NumAwdiTargetings:
+			return fmt.Errorf("awdi.fleet[%d].strategy %d is not a known awdi targeting", i, spec.Strategy)
+		case spec.Mass < 0:
+			return fmt.Errorf("awdi.fleet[%d].mass must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// clone copies the fleet along with the spawn positions its awdis point to
+func (f AwdiFleet) clone() AwdiFleet {
+	cloned := slices.Clone(f)
+	for i, spec := range cloned {
+		if spec.Position != nil {
+			position := *spec.Position
+			cloned[i].Position = &position
+		}
+	}
+	return cloned
+}
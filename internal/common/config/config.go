@@ -0,0 +1,346 @@
+package config
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Config gathers every tunable parameter of a simulation run. A scenario file only needs
+// to specify the values it changes: anything left out keeps the value from DefaultConfig.
+type Config struct {
+	Simulation  SimulationConfig  `json:"simulation" yaml:"simulation"`
+	Environment EnvironmentConfig `json:"environment" yaml:"environment"`
+	Physics     PhysicsConfig     `json:"physics" yaml:"physics"`
+	Resources   ResourcesConfig   `json:"resources" yaml:"resources"`
+	Awdi        AwdiConfig        `json:"awdi" yaml:"awdi"`
+	Voting      VotingConfig      `json:"voting" yaml:"voting"`
+	Governance  GovernanceConfig  `json:"governance" yaml:"governance"`
+	Output      OutputConfig      `json:"output" yaml:"output"`
+}
+
+/*
+Simulation Parameters
+*/
+type SimulationConfig struct {
+	Iterations      int      `json:"iterations" yaml:"iterations"`               // number of game loops (rounds) in a run
+	RoundIterations int      `json:"round_iterations" yaml:"round_iterations"`   // number of iterations in each round
+	BikerAgentCount int      `json:"agents" yaml:"agents"`                       // number of agents in the simulator
+	LootBoxRatio    float64  `json:"loot_ratio" yaml:"loot_ratio"`               // ratio of lootboxes to agents
+	GlobalRuleCount int      `json:"global_rule_count" yaml:"global_rule_count"` // number of initial rules in the global rule cache
+	StratifyRules   bool     `json:"stratify_rules" yaml:"stratify_rules"`       // stratify rules by action
+	Seed            *int64   `json:"seed,omitempty" yaml:"seed,omitempty"`       // seed of the run's random source (unset: one is picked from the clock)
+	AgentMix        AgentMix `json:"agent_mix" yaml:"agent_mix"`                 // number of agents of each registered team (unset: the agents are split evenly between the server's teams)
+	AgentWorkers    int      `json:"agent_workers" yaml:"agent_workers"`         // number of agents making their decisions at once (0: one per CPU)
+	Sequential      bool     `json:"sequential" yaml:"sequential"`               // ask agents for their decisions one at a time, on the server's goroutine (for debugging)
+}
+
+/*
+Environment Parameters
+*/
+type EnvironmentConfig struct {
+	GridHeight                float64      `json:"grid_height" yaml:"grid_height"`
+	GridWidth                 float64      `json:"grid_width" yaml:"grid_width"`
+	CollisionThreshold        float64      `json:"collision_threshold" yaml:"collision_threshold"`
+	BikersOnBike              int          `json:"bikers_on_bike" yaml:"bikers_on_bike"`
+	ReplenishEnergyEveryRound bool         `json:"replenish_energy_every_round" yaml:"replenish_energy_every_round"`
+	ResetPointsEveryRound     bool         `json:"reset_points_every_round" yaml:"reset_points_every_round"`
+	RespawnEveryRound         bool         `json:"respawn_every_round" yaml:"respawn_every_round"`
+	ReplenishLootBoxes        bool         `json:"replenish_loot_boxes" yaml:"replenish_loot_boxes"`
+	ReplenishMegaBikes        bool         `json:"replenish_mega_bikes" yaml:"replenish_mega_bikes"`
+	SpatialCellSize           float64      `json:"spatial_cell_size" yaml:"spatial_cell_size"`               // size of the cells of the spatial index the server keeps of lootboxes and bikes
+	Boundary                  BoundaryMode `json:"boundary" yaml:"boundary"`                                 // what happens to objects reaching the edges of the grid
+	FirstArrivalTakesLoot     bool         `json:"first_arrival_takes_loot" yaml:"first_arrival_takes_loot"` // a lootbox reached by several bikes in an iteration goes to the first to get there (otherwise it's split between them)
+}
+
+/*
+Physics Parameters
+*/
+type PhysicsConfig struct {
+	MassBike        float64      `json:"mass_bike" yaml:"mass_bike"`
+	MassBiker       float64      `json:"mass_biker" yaml:"mass_biker"`
+	MassAwdi        float64      `json:"mass_awdi" yaml:"mass_awdi"`
+	BikerMaxForce   float64      `json:"biker_max_force" yaml:"biker_max_force"`   // the max force a biker can pedal
+	AwdiMaxForce    float64      `json:"awdi_max_force" yaml:"awdi_max_force"`     // the awdi's force is equivalent to that of one biker agent going at maximum speed
+	DragCoefficient float64      `json:"drag_coefficient" yaml:"drag_coefficient"` // drag coefficient can be optimised in experimentation
+	MaxSpeed        float64      `json:"max_speed" yaml:"max_speed"`               // the speed no bike (or awdi) can exceed
+	Model           PhysicsModel `json:"model" yaml:"model"`                       // how objects move: simple (scalar velocity) or vector (2D velocity with momentum)
+	TurnRate        float64      `json:"turn_rate" yaml:"turn_rate"`               // vector model: the most an object of unit mass turns in an iteration (1 is 180°), heavier objects turning slower
+	SubSteps        int          `json:"sub_steps" yaml:"sub_steps"`               // vector model: number of steps each iteration is integrated in
+	BikeCollisions  bool         `json:"bike_collisions" yaml:"bike_collisions"`   // bikes bump into each other (otherwise they pass through each other)
+	Restitution     float64      `json:"restitution" yaml:"restitution"`           // share of the speed two bikes close at that they bounce off each other with (1 is a perfectly elastic bump)
+	DraftingRange   float64      `json:"drafting_range" yaml:"drafting_range"`     // a bike this close behind another one drafts behind it (0: no drafting)
+	DraftingDrag    float64      `json:"drafting_drag" yaml:"drafting_drag"`       // share of the drag a drafting bike still feels
+}
+
+/*
+Resources - Points and Energy
+*/
+type ResourcesConfig struct {
+	MovingDepletion               float64 `json:"moving_depletion" yaml:"moving_depletion"`                             // proportionality of energy loss
+	LimboEnergyPenalty            float64 `json:"limbo_energy_penalty" yaml:"limbo_energy_penalty"`                     // amount of energy lost per round when off a bike
+	DeliberativeDemocracyPenalty  float64 `json:"deliberative_democracy_penalty" yaml:"deliberative_democracy_penalty"` // amount of energy lost per vote in a deliberative democracy
+	LeadershipDemocracyPenalty    float64 `json:"leadership_democracy_penalty" yaml:"leadership_democracy_penalty"`     // amount of energy lost per vote in a leadership democracy
+	CouncilPenalty                float64 `json:"council_penalty" yaml:"council_penalty"`                               // amount of energy lost by each council member per vote in a council
+	SortitionPenalty              float64 `json:"sortition_penalty" yaml:"sortition_penalty"`                           // amount of energy lost by a ruler drawn by lot per direction it decides on
+	RotationPenalty               float64 `json:"rotation_penalty" yaml:"rotation_penalty"`                             // amount of energy lost per vote under a rotating leadership
+	PointsFromSameColouredLootBox int     `json:"points_from_same_coloured_loot_box" yaml:"points_from_same_coloured_loot_box"`
+	OffencePenalty                float64 `json:"offence_penalty" yaml:"offence_penalty"`             // amount of energy lost by an agent whose callback panics or returns an invalid output
+	WallEnergyPenalty             float64 `json:"wall_energy_penalty" yaml:"wall_energy_penalty"`     // amount of energy lost by each rider of a bike stopped by an absorbing wall
+	BikeCollisionDamage           float64 `json:"bike_collision_damage" yaml:"bike_collision_damage"` // amount of energy lost by each rider of a bike bumping into another, per unit of speed they closed at
+}
+
+/*
+Awdi Behavior
+*/
+type AwdiConfig struct {
+	TargetsEmptyMegaBike          bool        `json:"targets_empty_mega_bike" yaml:"targets_empty_mega_bike"`
+	OnlyTargetsStationaryMegaBike bool        `json:"only_targets_stationary_mega_bike" yaml:"only_targets_stationary_mega_bike"` // only bikes standing still are targeted
+	RemovesMegaBike               bool        `json:"removes_mega_bike" yaml:"removes_mega_bike"`
+	Fleet                         AwdiFleet   `json:"fleet" yaml:"fleet"`                         // the awdis roaming the map, each going after bikes its own way
+	Pursuit                       AwdiPursuit `json:"pursuit" yaml:"pursuit"`                     // how the awdis steer towards their targets: direct or intercept
+	LookaheadHorizon              float64     `json:"lookahead_horizon" yaml:"lookahead_horizon"` // intercept pursuit: the furthest ahead (in iterations) the awdis predict where their targets will be
+}
+
+/*
+Voting Method Choice
+*/
+type VotingConfig struct {
+	Method utils.VoteMethod `json:"method" yaml:"method"`
+}
+
+/*
+Governance Parameters
+*/
+type GovernanceConfig struct {
+	FoundingMix            GovernanceMix `json:"founding_mix" yaml:"founding_mix"`                       // imposed proportions of founding governance choices (unset: agents choose)
+	AmendmentInterval      int           `json:"amendment_interval" yaml:"amendment_interval"`           // every how many iterations riders may vote to change the governance of their bike (0: never)
+	AmendmentSupermajority float64       `json:"amendment_supermajority" yaml:"amendment_supermajority"` // the share of the riders that must vote for a change of governance for it to pass
+	TermLength             int           `json:"term_length" yaml:"term_length"`                         // how many iterations a ruler serves before being up for re-election (0: until it dies or leaves)
+	TermLimit              int           `json:"term_limit" yaml:"term_limit"`                           // how many terms in a row a ruler may serve (0: no limit)
+	NoConfidenceMajority   float64       `json:"no_confidence_majority" yaml:"no_confidence_majority"`   // the share of the other riders that must lose confidence in their ruler to remove it (0: rulers can't be removed that way)
+	CouncilSize            int           `json:"council_size" yaml:"council_size"`                       // how many riders are elected to the council of a bike under Council governance
+	SortitionPeriod        int           `json:"sortition_period" yaml:"sortition_period"`               // every how many iterations a new ruler is drawn by lot under Sortition (0: only when the ruler leaves)
+	RotationPeriod         int           `json:"rotation_period" yaml:"rotation_period"`                 // how many iterations each rider leads for under Rotation (0: until it leaves)
+	Nominations            bool          `json:"nominations" yaml:"nominations"`                         // riders stand for ruler before elections, and only the candidates can be voted for
+	ExpulsionThreshold     float64       `json:"expulsion_threshold" yaml:"expulsion_threshold"`         // the share of the other riders of a democracy that must vote to expel a rider for it to be kicked off
+	ExpulsionSeconding     bool          `json:"expulsion_seconding" yaml:"expulsion_seconding"`         // a motion to expel a rider of a democracy only goes to the vote if another rider seconds it
+	RejoinCooldown         int           `json:"rejoin_cooldown" yaml:"rejoin_cooldown"`                 // how many iterations a rider kicked off a bike must wait before it may rejoin it (0: none)
+}
+
+/*
+Output Parameters
+*/
+type OutputConfig struct {
+	Directory  string           `json:"directory" yaml:"directory"`   // directory the game dumps are written to, created if missing (empty: no game dump)
+	DumpLevel  DumpLevel        `json:"dump_level" yaml:"dump_level"` // how much of the game state is dumped
+	Statistics StatisticsConfig `json:"statistics" yaml:"statistics"` // the statistics report written at the end of a run
+	EventLog   bool             `json:"event_log" yaml:"event_log"`   // log every institutional decision (elections, kick offs, votes, allocations...)
+}
+
+// DefaultConfig returns the parameters the simulation has historically been run with
+func DefaultConfig() *Config {
+	return &Config{
+		Simulation: SimulationConfig{
+			Iterations:      100,
+			RoundIterations: 100,
+			BikerAgentCount: 80,
+			LootBoxRatio:    2.5,
+			GlobalRuleCount: 0,
+			StratifyRules:   true,
+		},
+		Environment: EnvironmentConfig{
+			GridHeight:                250.0,
+			GridWidth:                 250.0,
+			CollisionThreshold:        7.0,
+			BikersOnBike:              8,
+			ReplenishEnergyEveryRound: true,
+			ResetPointsEveryRound:     true,
+			RespawnEveryRound:         true,
+			ReplenishLootBoxes:        true,
+			ReplenishMegaBikes:        true,
+			SpatialCellSize:           25.0,
+			Boundary:                  NoBoundary,
+			FirstArrivalTakesLoot:     false,
+		},
+		Physics: PhysicsConfig{
+			MassBike:        1.0,
+			MassBiker:       1.0,
+			MassAwdi:        7.0,
+			BikerMaxForce:   0.8,
+			AwdiMaxForce:    1.0,
+			DragCoefficient: 0.5,
+			MaxSpeed:        5.0,
+			Model:           SimplePhysics,
+			TurnRate:        1.0,
+			SubSteps:        4,
+			BikeCollisions:  false,
+			Restitution:     0.8,
+			DraftingRange:   0.0,
+			DraftingDrag:    0.7,
+		},
+		Resources: ResourcesConfig{
+			MovingDepletion:               0.01,
+			LimboEnergyPenalty:            -0.05,
+			DeliberativeDemocracyPenalty:  0.05,
+			LeadershipDemocracyPenalty:    0.025,
+			CouncilPenalty:                0.05,
+			SortitionPenalty:              0.05,
+			RotationPenalty:               0.025,
+			PointsFromSameColouredLootBox: 5,
+			OffencePenalty:                0.0,
+			WallEnergyPenalty:             0.1,
+			BikeCollisionDamage:           0.0,
+		},
+		Awdi: AwdiConfig{
+			TargetsEmptyMegaBike:          false,
+			OnlyTargetsStationaryMegaBike: false,
+			RemovesMegaBike:               false,
+			Fleet:                         AwdiFleet{{Strategy: SlowestTargeting}},
+			Pursuit:                       DirectPursuit,
+			LookaheadHorizon:              10.0,
+		},
+		Voting: VotingConfig{
+			Method: utils.PLURALITY,
+		},
+		Governance: GovernanceConfig{
+			FoundingMix:            GovernanceMix{},
+			AmendmentInterval:      0,
+			AmendmentSupermajority: 2.0 / 3.0,
+			TermLength:             0,
+			TermLimit:              0,
+			NoConfidenceMajority:   0.0,
+			CouncilSize:            3,
+			SortitionPeriod:        5,
+			RotationPeriod:         5,
+			Nominations:            false,
+			ExpulsionThreshold:     0.5,
+			ExpulsionSeconding:     false,
+			RejoinCooldown:         0,
+		},
+		Output: OutputConfig{
+			Directory: "gameDumps/debug",
+			DumpLevel: SimplifiedDump,
+			Statistics: StatisticsConfig{
+				JSON:       true,
+				XLSX:       true,
+				Groups:     true,
+				Governance: true,
+				Bikes:      true,
+				LootBoxes:  true,
+			},
+		},
+	}
+}
+
+// Clone returns a copy of the config that shares nothing with it (the seed, the agent mix and
+// the awdi fleet being the only parts that aren't plain values)
+func (c *Config) Clone() *Config {
+	cloned := *c
+	if c.Simulation.Seed != nil {
+		cloned.Simulation.Seed = Seed(*c.Simulation.Seed)
+	}
+	cloned.Simulation.AgentMix = slices.Clone(c.Simulation.AgentMix)
+	cloned.Awdi.Fleet = c.Awdi.Fleet.clone()
+	return &cloned
+}
+
+// Seed returns a seed to set in a scenario (a scenario's seed being unset when it should be picked from the clock)
+func Seed(seed int64) *int64 {
+	return &seed
+}
+
+// LootBoxCount is the number of lootboxes the server keeps on the map
+func (c *Config) LootBoxCount() int {
+	return int(float64(c.Simulation.BikerAgentCount) * c.Simulation.LootBoxRatio)
+}
+
+// MegaBikeCount is the number of megabikes needed to seat every agent
+func (c *Config) MegaBikeCount() int {
+	return int(math.Ceil(float64(c.Simulation.BikerAgentCount) / float64(c.Environment.BikersOnBike)))
+}
+
+// Validate reports the first parameter that would make the simulation meaningless or crash
+func (c *Config) Validate() error {
+	switch {
+	case c.Simulation.Iterations < 0:
+		return errors.New("simulation.iterations must not be negative")
+	case c.Simulation.RoundIterations < 0:
+		return errors.New("simulation.round_iterations must not be negative")
+	case c.Simulation.BikerAgentCount < 0:
+		return errors.New("simulation.agents must not be negative")
+	case c.Simulation.LootBoxRatio < 0:
+		return errors.New("simulation.loot_ratio must not be negative")
+	case c.Simulation.AgentMix.IsSet() && c.Simulation.AgentMix.Total() != c.Simulation.BikerAgentCount:
+		return fmt.Errorf("simulation.agent_mix has %d agents, but simulation.agents is %d", c.Simulation.AgentMix.Total(), c.Simulation.BikerAgentCount)
+	case c.Simulation.GlobalRuleCount < 0:
+		return errors.New("simulation.global_rule_count must not be negative")
+	case c.Simulation.AgentWorkers < 0:
+		return errors.New("simulation.agent_workers must not be negative")
+	case c.Environment.GridHeight <= 0 || c.Environment.GridWidth <= 0:
+		return errors.New("environment grid dimensions must be positive")
+	case c.Environment.BikersOnBike <= 0:
+		return errors.New("environment.bikers_on_bike must be positive")
+	case !(c.Environment.SpatialCellSize > 0):
+		return errors.New("environment.spatial_cell_size must be positive")
+	case c.Environment.Boundary < 0 || c.Environment.Boundary >= NumBoundaryModes:
+		return fmt.Errorf("environment.boundary %d is not a known boundary mode", c.Environment.Boundary)
+	case c.Physics.MassBike <= 0 || c.Physics.MassAwdi <= 0:
+		return errors.New("bike and awdi masses must be positive")
+	case c.Physics.MassBiker < 0:
+		return errors.New("physics.mass_biker must not be negative")
+	case c.Resources.OffencePenalty < 0:
+		return errors.New("resources.offence_penalty must not be negative")
+	case c.Resources.WallEnergyPenalty < 0:
+		return errors.New("resources.wall_energy_penalty must not be negative")
+	case c.Resources.BikeCollisionDamage < 0:
+		return errors.New("resources.bike_collision_damage must not be negative")
+	case c.Physics.DragCoefficient < 0:
+		return errors.New("physics.drag_coefficient must not be negative")
+	case !(c.Physics.MaxSpeed > 0):
+		return errors.New("physics.max_speed must be positive")
+	case c.Physics.Model < 0 || c.Physics.Model >= NumPhysicsModels:
+		return fmt.Errorf("physics.model %d is not a known physics model", c.Physics.Model)
+	case !(c.Physics.TurnRate > 0):
+		return errors.New("physics.turn_rate must be positive")
+	case c.Physics.SubSteps <= 0:
+		return errors.New("physics.sub_steps must be positive")
+	case c.Physics.Restitution < 0 || c.Physics.Restitution > 1:
+		return errors.New("physics.restitution must be between 0 and 1")
+	case c.Physics.DraftingRange < 0:
+		return errors.New("physics.drafting_range must not be negative")
+	case c.Physics.DraftingDrag < 0 || c.Physics.DraftingDrag > 1:
+		return errors.New("physics.drafting_drag must be between 0 and 1")
+	case c.Awdi.Pursuit < 0 || c.Awdi.Pursuit >= NumAwdiPursuits:
+		return fmt.Errorf("awdi.pursuit %d is not a known awdi pursuit", c.Awdi.Pursuit)
+	case c.Awdi.LookaheadHorizon < 0:
+		return errors.New("awdi.lookahead_horizon must not be negative")
+	case c.Voting.Method < 0 || c.Voting.Method >= utils.NumVoteMethods:
+		return fmt.Errorf("voting.method %d is not a known voting method", c.Voting.Method)
+	case !c.Governance.FoundingMix.IsValid():
+		return errors.New("governance.founding_mix proportions must not be negative")
+	case c.Governance.AmendmentInterval < 0:
+		return errors.New("governance.amendment_interval must not be negative")
+	case !(c.Governance.AmendmentSupermajority > 0.5) || c.Governance.AmendmentSupermajority > 1:
+		return errors.New("governance.amendment_supermajority must be more than 0.5 and at most 1")
+	case c.Governance.TermLength < 0:
+		return errors.New("governance.term_length must not be negative")
+	case c.Governance.TermLimit < 0:
+		return errors.New("governance.term_limit must not be negative")
+	case c.Governance.NoConfidenceMajority < 0 || c.Governance.NoConfidenceMajority > 1:
+		return errors.New("governance.no_confidence_majority must be between 0 and 1")
+	case c.Governance.CouncilSize <= 0:
+		return errors.New("governance.council_size must be positive")
+	case c.Governance.SortitionPeriod < 0 || c.Governance.RotationPeriod < 0:
+		return errors.New("governance.sortition_period and governance.rotation_period must not be negative")
+	case !(c.Governance.ExpulsionThreshold > 0) || c.Governance.ExpulsionThreshold > 1:
+		return errors.New("governance.expulsion_threshold must be more than 0 and at most 1")
+	case c.Governance.RejoinCooldown < 0:
+		return errors.New("governance.rejoin_cooldown must not be negative")
+	case c.Output.DumpLevel < 0 || c.Output.DumpLevel >= NumDumpLevels:
+		return fmt.Errorf("output.dump_level %d is not a known dump level", c.Output.DumpLevel)
+	}
+	return c.Awdi.Fleet.validate()
+}
@@ -0,0 +1,46 @@
+package config
+
+import "fmt"
+
+/*
+World Boundary
+*/
+type BoundaryMode int
+
+const (
+	NoBoundary       BoundaryMode = iota // the map is unbounded: objects may drift away from the spawn area forever
+	WrapBoundary                         // the map is a torus: objects leaving on one side come back on the other
+	ReflectBoundary                      // objects bounce off the edges of the map
+	AbsorbBoundary                       // objects are stopped at the edges of the map, and the riders of a bike lose energy
+	NumBoundaryModes                     // add a sentinel for counting the number of boundary modes
+)
+
+func (b BoundaryMode) String() string {
+	switch b {
+	case NoBoundary:
+		return "none"
+	case WrapBoundary:
+		return "wrap"
+	case ReflectBoundary:
+		return "reflect"
+	case AbsorbBoundary:
+		return "absorb"
+	default:
+		return "unknown"
+	}
+}
+
+// allows boundary modes to be written by name in scenario files (and on the command line)
+func (b BoundaryMode) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *BoundaryMode) UnmarshalText(text []byte) error {
+	for mode := NoBoundary; mode < NumBoundaryModes; mode++ {
+		if mode.String() == string(text) {
+			*b = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown boundary mode %q", string(text))
+}
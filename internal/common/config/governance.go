@@ -0,0 +1,133 @@
+package config
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GovernanceMix gives the relative proportions of agents founding each kind of bike. When it's
+// set, the server deals the founding choices out in these proportions instead of asking the
+// agents (which is what happens when every proportion is zero).
+type GovernanceMix struct {
+	Democracy    float64 `json:"democracy" yaml:"democracy"`
+	Leadership   float64 `json:"leadership" yaml:"leadership"`
+	Dictatorship float64 `json:"dictatorship" yaml:"dictatorship"`
+	Council      float64 `json:"council" yaml:"council"`
+	Sortition    float64 `json:"sortition" yaml:"sortition"`
+	Rotation     float64 `json:"rotation" yaml:"rotation"`
+}
+
+// the weight of each governance in the mix
+func (m GovernanceMix) weights() map[utils.Governance]float64 {
+	return map[utils.Governance]float64{
+		utils.Democracy:    m.Democracy,
+		utils.Leadership:   m.Leadership,
+		utils.Dictatorship: m.Dictatorship,
+		utils.Council:      m.Council,
+		utils.Sortition:    m.Sortition,
+		utils.Rotation:     m.Rotation,
+	}
+}
+
+// the weight of a governance in the mix, to be set
+func (m *GovernanceMix) weight(governance utils.Governance) *float64 {
+	switch governance {
+	case utils.Leadership:
+		return &m.Leadership
+	case utils.Dictatorship:
+		return &m.Dictatorship
+	case utils.Council:
+		return &m.Council
+	case utils.Sortition:
+		return &m.Sortition
+	case utils.Rotation:
+		return &m.Rotation
+	default:
+		return &m.Democracy
+	}
+}
+
+// IsSet reports whether the mix imposes any founding choices
+func (m GovernanceMix) IsSet() bool {
+	for _, weight := range m.weights() {
+		if weight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m GovernanceMix) IsValid() bool {
+	for _, weight := range m.weights() {
+		if weight < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Proportions returns the share of the agents founding each governance (summing to 1), or nil if the mix isn't set
+func (m GovernanceMix) Proportions() map[utils.Governance]float64 {
+	if !m.IsSet() {
+		return nil
+	}
+	weights := m.weights()
+	total := 0.0
+	for governance := utils.Democracy; governance < utils.Invalid; governance++ {
+		total += weights[governance]
+	}
+	proportions := make(map[utils.Governance]float64, len(weights))
+	for governance, weight := range weights {
+		proportions[governance] = weight / total
+	}
+	return proportions
+}
+
+// String formats the mix in the form accepted by ParseGovernanceMix ("agents" when it isn't set)
+func (m GovernanceMix) String() string {
+	if !m.IsSet() {
+		return "agents"
+	}
+	values := m.weights()
+	parts := make([]string, 0, len(values))
+	for governance := utils.Democracy; governance < utils.Invalid; governance++ {
+		if values[governance] > 0 {
+			parts = append(parts, governance.String()+":"+strconv.FormatFloat(values[governance], 'g', -1, 64))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseGovernanceMix reads a mix written as comma separated governance:weight pairs
+// (e.g. "democracy:0.5,dictatorship:0.5"). "agents" (or an empty string) leaves the choice to the agents.
+func ParseGovernanceMix(text string) (GovernanceMix, error) {
+	mix := GovernanceMix{}
+	text = strings.TrimSpace(text)
+	if text == "" || text == "agents" {
+		return mix, nil
+	}
+	for _, pair := range strings.Split(text, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			return mix, fmt.Errorf("governance mix entry %q is not of the form governance:weight", pair)
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return mix, fmt.Errorf("governance mix weight for %s: %w", name, err)
+		}
+		governance := utils.Democracy
+		for governance < utils.Invalid && governance.String() != name {
+			governance++
+		}
+		if governance == utils.Invalid {
+			return mix, fmt.Errorf("%q is not a known governance", name)
+		}
+		*mix.weight(governance) = weight
+	}
+	if !mix.IsValid() {
+		return mix, fmt.Errorf("governance mix %q has negative weights", text)
+	}
+	return mix, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig reads a YAML or JSON scenario file (chosen by extension) on top of the default
+// parameters. An empty path returns the defaults unchanged.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading scenario file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported scenario file extension %q", ext)
+	}
+	// an empty scenario file simply keeps the defaults
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing scenario file %s: %w", path, err)
+	}
+
+	// the agent count of a scenario with an agent mix is that of the mix
+	if cfg.Simulation.AgentMix.IsSet() {
+		cfg.Simulation.BikerAgentCount = cfg.Simulation.AgentMix.Total()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveConfig writes the scenario to a YAML or JSON file (chosen by extension), which LoadConfig reads back as it was
+func SaveConfig(path string, cfg *Config) error {
+	var data []byte
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		data, err = json.MarshalIndent(cfg, "", "  ")
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(cfg)
+	default:
+		return fmt.Errorf("unsupported scenario file extension %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("encoding scenario: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating scenario directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing scenario file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Game Dump Level
+*/
+type DumpLevel int
+
+const (
+	NoDump         DumpLevel = iota // only the statistics report is written
+	SimplifiedDump                  // bike and agent directions, loot gained and kick offs for each game loop
+	FullDump                        // the whole game state (see server.GameStateDump) after every iteration
+	NumDumpLevels                   // add a sentinel for counting the number of dump levels
+)
+
+func (d DumpLevel) String() string {
+	switch d {
+	case NoDump:
+		return "none"
+	case SimplifiedDump:
+		return "simplified"
+	case FullDump:
+		return "full"
+	default:
+		return "unknown"
+	}
+}
+
+// allows dump levels to be written by name in scenario files (and on the command line)
+func (d DumpLevel) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *DumpLevel) UnmarshalText(text []byte) error {
+	for level := NoDump; level < NumDumpLevels; level++ {
+		if level.String() == string(text) {
+			*d = level
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown dump level %q", string(text))
+}
+
+/*
+Statistics Report
+*/
+type StatisticsConfig struct {
+	JSON       bool `json:"json" yaml:"json"`             // write the report as JSON
+	XLSX       bool `json:"xlsx" yaml:"xlsx"`             // write the report as a spreadsheet
+	Groups     bool `json:"groups" yaml:"groups"`         // include the statistics of each group of agents
+	Governance bool `json:"governance" yaml:"governance"` // include the statistics of the riders of each governance
+	Bikes      bool `json:"bikes" yaml:"bikes"`           // include bike survival
+	LootBoxes  bool `json:"loot_boxes" yaml:"loot_boxes"` // include lootbox throughput
+}
+
+// IsSet reports whether a statistics report is written at all
+func (c StatisticsConfig) IsSet() bool {
+	return c.JSON || c.XLSX
+}
+
+// SetFormats reads the report formats from a comma separated list (e.g. "json,xlsx"), "none" writing no report
+func (c *StatisticsConfig) SetFormats(text string) error {
+	c.JSON, c.XLSX = false, false
+	return parseSelection(text, map[string]*bool{"json": &c.JSON, "xlsx": &c.XLSX})
+}
+
+// SetAggregates reads the aggregates included in the report from a comma separated list
+// (e.g. "groups,loot_boxes"), "all" including all of them and "none" none of them
+func (c *StatisticsConfig) SetAggregates(text string) error {
+	selection := map[string]*bool{"groups": &c.Groups, "governance": &c.Governance, "bikes": &c.Bikes, "loot_boxes": &c.LootBoxes}
+	for _, selected := range selection {
+		*selected = strings.TrimSpace(text) == "all"
+	}
+	if strings.TrimSpace(text) == "all" {
+		return nil
+	}
+	return parseSelection(text, selection)
+}
+
+func parseSelection(text string, selection map[string]*bool) error {
+	text = strings.TrimSpace(text)
+	if text == "" || text == "none" {
+		return nil
+	}
+	for _, name := range strings.Split(text, ",") {
+		selected, ok := selection[strings.TrimSpace(name)]
+		if !ok {
+			return fmt.Errorf("%q is not one of the options", strings.TrimSpace(name))
+		}
+		*selected = true
+	}
+	return nil
+}
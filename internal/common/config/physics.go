@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+/*
+Physics Model
+*/
+type PhysicsModel int
+
+const (
+	SimplePhysics    PhysicsModel = iota // a scalar velocity along the orientation, which objects take up at once
+	VectorPhysics                        // a 2D velocity with momentum, objects turning at a rate limited by their mass
+	NumPhysicsModels                     // add a sentinel for counting the number of physics models
+)
+
+func (m PhysicsModel) String() string {
+	switch m {
+	case SimplePhysics:
+		return "simple"
+	case VectorPhysics:
+		return "vector"
+	default:
+		return "unknown"
+	}
+}
+
+// allows physics models to be written by name in scenario files
+func (m PhysicsModel) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *PhysicsModel) UnmarshalText(text []byte) error {
+	for model := SimplePhysics; model < NumPhysicsModels; model++ {
+		if model.String() == string(text) {
+			*m = model
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown physics model %q", string(text))
+}
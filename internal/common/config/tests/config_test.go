@@ -0,0 +1,238 @@
+package config_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/utils"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeScenario(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEmptyPathGivesDefaults(t *testing.T) {
+	cfg, err := config.LoadConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, config.DefaultConfig(), cfg)
+}
+
+func TestDefaultDerivedCounts(t *testing.T) {
+	cfg := config.DefaultConfig()
+	assert.Equal(t, 200, cfg.LootBoxCount())
+	assert.Equal(t, 10, cfg.MegaBikeCount())
+}
+
+func TestLoadYAMLScenario(t *testing.T) {
+	path := writeScenario(t, "scenario.yaml", `
+simulation:
+  agents: 24
+physics:
+  drag_coefficient: 0.25
+voting:
+  method: borda_count
+`)
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 24, cfg.Simulation.BikerAgentCount)
+	assert.Equal(t, 0.25, cfg.Physics.DragCoefficient)
+	assert.Equal(t, utils.BORDACOUNT, cfg.Voting.Method)
+	// values not in the file keep their defaults
+	assert.Equal(t, config.DefaultConfig().Environment, cfg.Environment)
+	assert.Equal(t, 3, cfg.MegaBikeCount())
+}
+
+func TestLoadJSONScenario(t *testing.T) {
+	path := writeScenario(t, "scenario.json", `{"environment": {"bikers_on_bike": 4}, "awdi": {"removes_mega_bike": true}}`)
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, cfg.Environment.BikersOnBike)
+	assert.True(t, cfg.Awdi.RemovesMegaBike)
+	assert.Equal(t, 20, cfg.MegaBikeCount())
+}
+
+func TestRejectsBadScenarios(t *testing.T) {
+	scenarios := map[string]string{
+		"unknown_field.yaml": "physics:\n  drag: 1\n",
+		"bad_method.yaml":    "voting:\n  method: dice\n",
+		"invalid.json":       `{"environment": {"bikers_on_bike": 0}}`,
+		"workers.yaml":       "simulation:\n  agent_workers: -1\n",
+		"cell_size.yaml":     "environment:\n  spatial_cell_size: 0\n",
+		"boundary.yaml":      "environment:\n  boundary: sphere\n",
+		"sub_steps.yaml":     "physics:\n  model: vector\n  sub_steps: 0\n",
+		"restitution.yaml":   "physics:\n  restitution: 1.5\n",
+		"fleet.yaml":         "awdi:\n  fleet: []\n",
+		"lookahead.yaml":     "awdi:\n  pursuit: intercept\n  lookahead_horizon: -1\n",
+		"supermajority.yaml": "governance:\n  amendment_interval: 5\n  amendment_supermajority: 0.5\n",
+		"term.yaml":          "governance:\n  term_length: 10\n  term_limit: -1\n",
+		"council.yaml":       "governance:\n  council_size: 0\n",
+		"expulsion.yaml":     "governance:\n  expulsion_threshold: 0\n  rejoin_cooldown: 2\n",
+		"scenario.toml":      "",
+	}
+	for name, contents := range scenarios {
+		_, err := config.LoadConfig(writeScenario(t, name, contents))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestDefaultScenarioFileMatchesDefaults(t *testing.T) {
+	cfg, err := config.LoadConfig(filepath.Join("..", "..", "..", "..", "scenarios", "default.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, config.DefaultConfig(), cfg)
+}
+
+func TestGovernanceMixRoundTrip(t *testing.T) {
+	mix, err := config.ParseGovernanceMix("democracy:1, dictatorship:3")
+	assert.NoError(t, err)
+	assert.Equal(t, config.GovernanceMix{Democracy: 1, Dictatorship: 3}, mix)
+	assert.Equal(t, 0.75, mix.Proportions()[utils.Dictatorship])
+
+	again, err := config.ParseGovernanceMix(mix.String())
+	assert.NoError(t, err)
+	assert.Equal(t, mix, again)
+
+	unset, err := config.ParseGovernanceMix("agents")
+	assert.NoError(t, err)
+	assert.False(t, unset.IsSet())
+	assert.Equal(t, "agents", unset.String())
+
+	_, err = config.ParseGovernanceMix("monarchy:1")
+	assert.Error(t, err)
+	_, err = config.ParseGovernanceMix("democracy:-1")
+	assert.Error(t, err)
+}
+
+func TestLoadFoundingMix(t *testing.T) {
+	path := writeScenario(t, "scenario.yaml", `
+governance:
+  founding_mix:
+    leadership: 2
+    dictatorship: 1
+`)
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, config.GovernanceMix{Leadership: 2, Dictatorship: 1}, cfg.Governance.FoundingMix)
+}
+
+func TestLoadDumpLevel(t *testing.T) {
+	path := writeScenario(t, "scenario.yaml", `
+output:
+  dump_level: full
+`)
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, config.FullDump, cfg.Output.DumpLevel)
+
+	path = writeScenario(t, "scenario.yaml", `
+output:
+  dump_level: everything
+`)
+	_, err = config.LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestStatisticsSelection(t *testing.T) {
+	path := writeScenario(t, "scenario.yaml", `
+output:
+  statistics:
+    xlsx: false
+    bikes: false
+`)
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+	// anything the scenario omits keeps its default
+	assert.Equal(t, config.StatisticsConfig{JSON: true, Groups: true, Governance: true, LootBoxes: true}, cfg.Output.Statistics)
+
+	statistics := config.StatisticsConfig{}
+	assert.NoError(t, statistics.SetFormats("xlsx"))
+	assert.NoError(t, statistics.SetAggregates("groups, loot_boxes"))
+	assert.Equal(t, config.StatisticsConfig{XLSX: true, Groups: true, LootBoxes: true}, statistics)
+
+	assert.NoError(t, statistics.SetFormats("none"))
+	assert.False(t, statistics.IsSet())
+	assert.NoError(t, statistics.SetAggregates("all"))
+	assert.True(t, statistics.Governance && statistics.Bikes)
+	assert.Error(t, statistics.SetAggregates("groups,weather"))
+}
+
+func TestAgentMix(t *testing.T) {
+	mix, err := config.ParseAgentMix("sosa:40, base:20")
+	assert.NoError(t, err)
+	assert.Equal(t, config.AgentMix{{Team: "sosa", Count: 40}, {Team: "base", Count: 20}}, mix)
+	assert.Equal(t, "sosa:40,base:20", mix.String())
+
+	for _, bad := range []string{"sosa", "sosa:-1", "sosa:1,sosa:2", ":3"} {
+		_, err := config.ParseAgentMix(bad)
+		assert.Error(t, err, bad)
+	}
+
+	// the agent count of a scenario is that of its mix
+	path := writeScenario(t, "scenario.yaml", `
+simulation:
+  agent_mix: "sosa:10,base:6"
+`)
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 16, cfg.Simulation.BikerAgentCount)
+	assert.Equal(t, config.AgentMix{{Team: "sosa", Count: 10}, {Team: "base", Count: 6}}, cfg.Simulation.AgentMix)
+
+	cfg.Simulation.BikerAgentCount = 20
+	assert.Error(t, cfg.Validate())
+}
+
+func TestCloneSharesNothing(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.Seed = config.Seed(7)
+	cfg.Simulation.AgentMix = config.AgentMix{{Team: "sosa", Count: 10}, {Team: "base", Count: 6}}
+	cfg.Awdi.Fleet = config.AwdiFleet{{Strategy: config.SlowestTargeting, Position: &utils.Coordinates{X: 1, Y: 2}}}
+	cloned := cfg.Clone()
+	assert.Equal(t, cfg, cloned)
+
+	// changing the clone leaves the original as it was
+	*cloned.Simulation.Seed = 8
+	cloned.Simulation.AgentMix[0].Count = 4
+	cloned.Awdi.Fleet[0].Strategy = config.NearestTargeting
+	cloned.Awdi.Fleet[0].Position.X = 3
+	assert.Equal(t, 10, cfg.Simulation.AgentMix[0].Count)
+	assert.Equal(t, config.SlowestTargeting, cfg.Awdi.Fleet[0].Strategy)
+	assert.Equal(t, 1.0, cfg.Awdi.Fleet[0].Position.X)
+	assert.Equal(t, int64(7), *cfg.Simulation.Seed)
+}
+
+func TestSeedZeroIsASeed(t *testing.T) {
+	// a scenario without a seed leaves it to the clock, whereas a seed of 0 is kept (and can be replayed)
+	cfg, err := config.LoadConfig(writeScenario(t, "unseeded.yaml", "simulation:\n  agents: 24\n"))
+	assert.NoError(t, err)
+	assert.Nil(t, cfg.Simulation.Seed)
+
+	cfg, err = config.LoadConfig(writeScenario(t, "seeded.yaml", "simulation:\n  seed: 0\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, config.Seed(0), cfg.Simulation.Seed)
+}
+
+func TestSavedScenarioIsLoadedBack(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.Seed = config.Seed(0)
+	cfg.Simulation.AgentMix = config.AgentMix{{Team: "sosa", Count: 10}, {Team: "base", Count: 6}}
+	cfg.Simulation.BikerAgentCount = 16
+	cfg.Environment.Boundary = config.WrapBoundary
+	cfg.Awdi.Fleet = config.AwdiFleet{{Strategy: config.SlowestTargeting, Position: &utils.Coordinates{X: 1, Y: 2}}}
+	cfg.Governance.FoundingMix = config.GovernanceMix{Leadership: 2, Dictatorship: 1}
+	cfg.Output.DumpLevel = config.FullDump
+
+	for _, name := range []string{"scenario.yaml", "scenario.json"} {
+		path := filepath.Join(t.TempDir(), "nested", name)
+		assert.NoError(t, config.SaveConfig(path, cfg), name)
+		loaded, err := config.LoadConfig(path)
+		assert.NoError(t, err, name)
+		assert.Equal(t, cfg, loaded, name)
+	}
+	assert.Error(t, config.SaveConfig(filepath.Join(t.TempDir(), "scenario.toml"), cfg))
+}
@@ -1,15 +1,126 @@
 package globals
 
-import "flag"
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/registry"
+	"flag"
+	"fmt"
+	"strings"
+)
 
-// const LootBoxCount = BikerAgentCount * 2.5 // 2.5 lootboxes available per Agent
-// const MegaBikeCount = 11                   // Megabikes should have 8 riders
-// const BikerAgentCount = 56                 // 56 agents in total
+// Flags holds the command line flags of a simulation run. They're registered on a flag set
+// rather than held in package variables, so that nothing is shared between servers.
+type Flags struct {
+	flagSet    *flag.FlagSet
+	ConfigFile *string
 
-var BikerAgentCount = flag.Int("agents", 80, "number of agents in simulator")
-var LootBoxRatio = flag.Float64("loot", 2.5, "ratio of lootboxes to agents")
-var GlobalRuleCount = flag.Int("rules", 0, "number of initial rules in global rule cache")
-var StratifyRules = flag.Bool("s", true, "stratify rules by action")
+	// the flags below override the scenario file when they are explicitly set
+	BikerAgentCount *int
+	LootBoxRatio    *float64
+	GlobalRuleCount *int
+	StratifyRules   *bool
+	Seed            *int64
+	AgentMix        config.AgentMix
+	AgentWorkers    *int
+	Sequential      *bool
+	Boundary        config.BoundaryMode
+	OutputDirectory *string
+	DumpLevel       config.DumpLevel
+	Statistics      *string
+	Aggregates      *string
+	EventLog        *bool
+}
 
-var LootBoxCount int = 140
-var MegaBikeCount int = 10
+// RegisterFlags defines the simulation's flags on the given flag set (flag.CommandLine for a main package)
+func RegisterFlags(flagSet *flag.FlagSet) *Flags {
+	f := &Flags{
+		flagSet:         flagSet,
+		ConfigFile:      flagSet.String("config", "", "path to a YAML/JSON scenario file (defaults are used for anything it omits)"),
+		BikerAgentCount: flagSet.Int("agents", 80, "number of agents in simulator"),
+		LootBoxRatio:    flagSet.Float64("loot", 2.5, "ratio of lootboxes to agents"),
+		GlobalRuleCount: flagSet.Int("rules", 0, "number of initial rules in global rule cache"),
+		StratifyRules:   flagSet.Bool("s", true, "stratify rules by action"),
+		Seed:            flagSet.Int64("seed", 0, "seed of the simulation's random source (unset: the scenario's, or one picked from the clock)"),
+		AgentWorkers:    flagSet.Int("workers", 0, "number of agents making their decisions at once (0: one per CPU)"),
+		Sequential:      flagSet.Bool("sequential", false, "ask agents for their decisions one at a time (for debugging)"),
+		OutputDirectory: flagSet.String("out", "gameDumps/debug", "directory the game dump and statistics report are written to (empty to skip them)"),
+		Statistics:      flagSet.String("stats", "json,xlsx", "formats of the statistics report (json and/or xlsx, or none)"),
+		EventLog:        flagSet.Bool("events", false, "log every institutional decision of the run next to its game dump"),
+		Aggregates:      flagSet.String("aggregates", "all", "aggregates included in the statistics report (groups, governance, bikes and/or loot_boxes, all or none)"),
+	}
+	flagSet.TextVar(&f.AgentMix, "mix", config.AgentMix(nil), fmt.Sprintf("number of agents of each team, e.g. sosa:40,base:20 (registered teams: %s)", strings.Join(registry.Names(), ", ")))
+	flagSet.TextVar(&f.Boundary, "boundary", config.NoBoundary, "what happens at the edges of the grid (none, wrap, reflect or absorb)")
+	flagSet.TextVar(&f.DumpLevel, "dump", config.SimplifiedDump, "how much of the game state is dumped (none, simplified or full)")
+	return f
+}
+
+// LoadConfig builds the simulation parameters from the scenario file given by -config,
+// then applies any of the command line flags that were explicitly set (the flag set must have been parsed)
+func (f *Flags) LoadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig(*f.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var flagErr error
+	f.flagSet.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "agents":
+			cfg.Simulation.BikerAgentCount = *f.BikerAgentCount
+		case "loot":
+			cfg.Simulation.LootBoxRatio = *f.LootBoxRatio
+		case "rules":
+			cfg.Simulation.GlobalRuleCount = *f.GlobalRuleCount
+		case "s":
+			cfg.Simulation.StratifyRules = *f.StratifyRules
+		case "seed":
+			cfg.Simulation.Seed = config.Seed(*f.Seed)
+		case "mix":
+			cfg.Simulation.AgentMix = f.AgentMix
+		case "workers":
+			cfg.Simulation.AgentWorkers = *f.AgentWorkers
+		case "sequential":
+			cfg.Simulation.Sequential = *f.Sequential
+		case "boundary":
+			cfg.Environment.Boundary = f.Boundary
+		case "out":
+			cfg.Output.Directory = *f.OutputDirectory
+		case "dump":
+			cfg.Output.DumpLevel = f.DumpLevel
+		case "events":
+			cfg.Output.EventLog = *f.EventLog
+		case "stats":
+			if err := cfg.Output.Statistics.SetFormats(*f.Statistics); err != nil {
+				flagErr = fmt.Errorf("invalid -stats: %w", err)
+			}
+		case "aggregates":
+			if err := cfg.Output.Statistics.SetAggregates(*f.Aggregates); err != nil {
+				flagErr = fmt.Errorf("invalid -aggregates: %w", err)
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+	// an agent mix gives the agent count, unless -agents contradicts it
+	if cfg.Simulation.AgentMix.IsSet() && !f.isSet("agents") {
+		cfg.Simulation.BikerAgentCount = cfg.Simulation.AgentMix.Total()
+	}
+	if err := registry.ValidateMix(cfg.Simulation.AgentMix); err != nil {
+		return nil, fmt.Errorf("invalid agent mix: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// reports whether a flag was explicitly set
+func (f *Flags) isSet(name string) bool {
+	set := false
+	f.flagSet.Visit(func(fl *flag.Flag) {
+		set = set || fl.Name == name
+	})
+	return set
+}
@@ -1,9 +1,10 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/config"
 	phy "SOMAS2023/internal/common/physics"
-	"SOMAS2023/internal/common/utils"
-	"math"
+	utils "SOMAS2023/internal/common/utils"
+	"math/rand"
 
 	"github.com/google/uuid"
 )
@@ -12,24 +13,30 @@ type IAwdi interface {
 	IPhysicsObject
 	InjectGameState(gameState IGameState)
 	GetTargetID() uuid.UUID
+	GetStrategyName() string // the name of the strategy the awdi chooses its target by
 }
 
 type Awdi struct {
 	*PhysicsObject
 	target    IMegaBike
+	strategy  AwdiStrategy
 	gameState IGameState
 }
 
 // GetAwdi is a constructor for Awdi that initializes it with a new UUID and default position.
-func GetAwdi() *Awdi {
-	return &Awdi{
-		PhysicsObject: GetPhysicsObject(utils.MassAwdi),
-	}
+func GetAwdi(cfg *config.Config, rng *rand.Rand) *Awdi {
+	return GetAwdiWithStrategy(cfg.Physics.MassAwdi, slowestBike(cfg), cfg, rng)
+}
+
+func GetIAwdi(cfg *config.Config, rng *rand.Rand) IAwdi {
+	return GetAwdi(cfg, rng)
 }
 
-func GetIAwdi() IAwdi {
+// GetAwdiWithStrategy is a constructor for an Awdi of the given mass, going after bikes by the given strategy
+func GetAwdiWithStrategy(mass float64, strategy AwdiStrategy, cfg *config.Config, rng *rand.Rand) *Awdi {
 	return &Awdi{
-		PhysicsObject: GetPhysicsObject(utils.MassAwdi),
+		PhysicsObject: GetPhysicsObject(mass, cfg, rng),
+		strategy:      strategy,
 	}
 }
 
@@ -42,59 +49,45 @@ func (awdi *Awdi) UpdateForce() {
 	// Compute the target Megabike, which will update awdi.target
 	awdi.ComputeTarget()
 
-	if awdi.target == nil { // no target, awdi will not apply a force and eventually come to a stop
+	if _, ok := awdi.destination(); !ok { // nowhere to go, awdi will not apply a force and eventually come to a stop
 		awdi.force = 0.0
 	} else {
-		awdi.force = utils.AwdiMaxForce // Otherwise apply max force to get to target MegaBike
+		awdi.force = awdi.cfg.Physics.AwdiMaxForce // Otherwise apply max force to get to target MegaBike (or waypoint)
 	}
 }
 
 // Calculates and returns the desired orientation of the awdi based on the current gamestate
 func (awdi *Awdi) UpdateOrientation() {
-	// If no target, awdi will not change orientation
+	// If nowhere to go, awdi will not change orientation
 	// Otherwise, new orientation is calculated based on positioning of target
-	if awdi.target != nil {
-		awdi.orientation = phy.ComputeOrientation(awdi.coordinates, awdi.target.GetPosition())
+	if destination, ok := awdi.destination(); ok {
+		awdi.orientation = phy.ComputeOrientation(awdi.coordinates, destination, awdi.cfg.Environment)
 	}
 }
 
-// Computes the target Megabike based on current gameState
-func (awdi *Awdi) ComputeTarget() {
-	// search for target
-	minDistance := math.Inf(1)
-	minVelocity := math.Inf(1)
-	awdi.target = nil
-	for _, bike := range awdi.gameState.GetMegaBikes() {
-		if utils.AwdiOnlyTargetsStationaryMegaBike {
-			if bike.GetVelocity() != 0.0 {
-				continue
-			}
-		}
-
-		if !utils.AwdiTargetsEmptyMegaBike {
-			agentsOnBike := bike.GetAgents()
-			if len(agentsOnBike) == 0 {
-				continue
-			}
+// where the awdi heads for: its target (or where it will meet it, under intercept pursuit), or otherwise its
+// strategy's waypoint
+func (awdi *Awdi) destination() (utils.Coordinates, bool) {
+	if awdi.target != nil {
+		if awdi.cfg.Awdi.Pursuit == config.InterceptPursuit {
+			targetVelocity := phy.VelocityOf(awdi.target.GetPhysicalState(), awdi.target.GetOrientation())
+			return phy.InterceptPoint(awdi.coordinates, pursuitSpeed(awdi, awdi.cfg), awdi.target.GetPosition(), targetVelocity, awdi.cfg.Awdi.LookaheadHorizon, awdi.cfg.Environment), true
 		}
+		return awdi.target.GetPosition(), true
+	}
+	return awdi.strategy.Waypoint()
+}
 
-		// ignore faster bike
-		if bike.GetVelocity() > minVelocity {
-			continue
-		}
+// Computes the target Megabike based on current gameState, by the awdi's strategy
+func (awdi *Awdi) ComputeTarget() {
+	awdi.target = awdi.strategy.ChooseTarget(awdi, awdi.gameState, func(bike IMegaBike) bool {
+		return (awdi.cfg.Awdi.TargetsEmptyMegaBike || len(bike.GetAgents()) != 0) &&
+			(!awdi.cfg.Awdi.OnlyTargetsStationaryMegaBike || bike.GetVelocity() == 0.0)
+	})
+}
 
-		distance := phy.ComputeDistance(awdi.coordinates, bike.GetPosition())
-		// minimize the velocity first
-		if bike.GetVelocity() < minVelocity {
-			awdi.target = bike
-		} else if distance < minDistance { // if same velocity, then minimize distance
-			awdi.target = bike
-		} else {
-			continue
-		}
-		minVelocity = awdi.target.GetVelocity()
-		minDistance = distance
-	}
+func (awdi *Awdi) GetStrategyName() string {
+	return awdi.strategy.Name()
 }
 
 func (awdi *Awdi) GetTargetID() uuid.UUID {
@@ -0,0 +1,134 @@
+package objects
+
+import (
+	"SOMAS2023/internal/common/config"
+	phy "SOMAS2023/internal/common/physics"
+	utils "SOMAS2023/internal/common/utils"
+	"math"
+	"math/rand"
+)
+
+// AwdiStrategy decides what an Awdi goes after. The Awdi only hands it the bikes it may target (awdi config).
+type AwdiStrategy interface {
+	// the name the strategy is reported by in game dumps
+	Name() string
+	// ChooseTarget picks the bike the Awdi goes after among those accepted (nil for none), once per move
+	ChooseTarget(awdi IAwdi, gameState IGameState, accept func(IMegaBike) bool) IMegaBike
+	// Waypoint is where an Awdi without a target heads for, if anywhere (otherwise it comes to a stop)
+	Waypoint() (utils.Coordinates, bool)
+}
+
+// NewAwdiStrategy builds the strategy of a scenario's awdi (only patrolling draws from the random source)
+func NewAwdiStrategy(targeting config.AwdiTargeting, cfg *config.Config, rng *rand.Rand) AwdiStrategy {
+	switch targeting {
+	case config.NearestTargeting:
+		return nearestBike{}
+	case config.RichestTargeting:
+		return rankedBikes{name: targeting.String(), cfg: cfg, score: func(awdi IAwdi, bike IMegaBike) (float64, bool) {
+			return bike.GetCurrentPool(), true
+		}}
+	case config.PopulousTargeting:
+		return rankedBikes{name: targeting.String(), cfg: cfg, score: func(awdi IAwdi, bike IMegaBike) (float64, bool) {
+			return float64(len(bike.GetAgents())), true
+		}}
+	case config.InterceptTargeting:
+		return rankedBikes{name: targeting.String(), cfg: cfg, score: func(awdi IAwdi, bike IMegaBike) (float64, bool) {
+			interceptTime, ok := phy.InterceptTime(awdi.GetPosition(), pursuitSpeed(awdi, cfg), bike.GetPosition(), phy.VelocityOf(bike.GetPhysicalState(), bike.GetOrientation()), cfg.Environment)
+			return -interceptTime, ok
+		}}
+	case config.PatrolTargeting:
+		return &patrol{cfg: cfg, rng: rand.New(rand.NewSource(rng.Int63()))}
+	default:
+		return slowestBike(cfg)
+	}
+}
+
+// an awdi catches up with bikes at the speed it's going, or the one it can keep up if that's faster
+func pursuitSpeed(awdi IAwdi, cfg *config.Config) float64 {
+	return math.Max(awdi.GetVelocity(), phy.TopSpeed(cfg.Physics.AwdiMaxForce, cfg.Physics))
+}
+
+// the nearest bike is found through the spatial index, without visiting every bike
+type nearestBike struct{}
+
+func (nearestBike) Name() string {
+	return config.NearestTargeting.String()
+}
+
+func (nearestBike) ChooseTarget(awdi IAwdi, gameState IGameState, accept func(IMegaBike) bool) IMegaBike {
+	return gameState.GetNearestMegaBike(awdi.GetPosition(), accept)
+}
+
+func (nearestBike) Waypoint() (utils.Coordinates, bool) {
+	return utils.Coordinates{}, false
+}
+
+// rankedBikes goes after the bike with the highest score (bikes without one aren't candidates), the nearest one
+// breaking ties and bikes being visited in ID order so that ties are always broken the same way
+type rankedBikes struct {
+	name  string
+	cfg   *config.Config
+	score func(awdi IAwdi, bike IMegaBike) (float64, bool)
+}
+
+func slowestBike(cfg *config.Config) rankedBikes {
+	return rankedBikes{name: config.SlowestTargeting.String(), cfg: cfg, score: func(awdi IAwdi, bike IMegaBike) (float64, bool) {
+		return -bike.GetVelocity(), true
+	}}
+}
+
+func (r rankedBikes) Name() string {
+	return r.name
+}
+
+func (r rankedBikes) ChooseTarget(awdi IAwdi, gameState IGameState, accept func(IMegaBike) bool) IMegaBike {
+	var target IMegaBike
+	maxScore, minDistance := math.Inf(-1), math.Inf(1)
+	megaBikes := gameState.GetMegaBikes()
+	for _, bikeID := range utils.SortedIDs(megaBikes) {
+		bike := megaBikes[bikeID]
+		if !accept(bike) {
+			continue
+		}
+		score, ok := r.score(awdi, bike)
+		if !ok || score < maxScore {
+			continue
+		}
+		distance := phy.ComputeDistance(awdi.GetPosition(), bike.GetPosition(), r.cfg.Environment)
+		// maximise the score first, then minimise the distance
+		if score > maxScore || distance < minDistance {
+			target, maxScore, minDistance = bike, score, distance
+		}
+	}
+	return target
+}
+
+func (r rankedBikes) Waypoint() (utils.Coordinates, bool) {
+	return utils.Coordinates{}, false
+}
+
+// patrol goes after no bike, heading for random waypoints on the map instead (a new one once it reaches one)
+type patrol struct {
+	cfg      *config.Config
+	rng      *rand.Rand
+	waypoint *utils.Coordinates
+}
+
+func (p *patrol) Name() string {
+	return config.PatrolTargeting.String()
+}
+
+func (p *patrol) ChooseTarget(awdi IAwdi, gameState IGameState, accept func(IMegaBike) bool) IMegaBike {
+	if p.waypoint == nil || math.Sqrt(phy.ComputeDistance(awdi.GetPosition(), *p.waypoint, p.cfg.Environment)) <= p.cfg.Environment.CollisionThreshold {
+		waypoint := utils.GenerateRandomCoordinates(p.rng, p.cfg.Environment.GridWidth, p.cfg.Environment.GridHeight)
+		p.waypoint = &waypoint
+	}
+	return nil
+}
+
+func (p *patrol) Waypoint() (utils.Coordinates, bool) {
+	if p.waypoint == nil {
+		return utils.Coordinates{}, false
+	}
+	return *p.waypoint, true
+}
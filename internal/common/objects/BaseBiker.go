@@ -1,9 +1,9 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 	voting "SOMAS2023/internal/common/voting"
-	"math"
 
 	"math/rand"
 
@@ -28,6 +28,10 @@ type IBaseBiker interface {
 	VoteForKickout() map[uuid.UUID]int
 	VoteDictator() voting.IdVoteMap
 	VoteLeader() voting.IdVoteMap
+	VoteAmendment(proposed utils.Governance) bool // ** vote on the motion to switch the bike to the proposed governance
+	VoteNoConfidence() bool                       // ** whether the agent has lost confidence in the ruler of its bike (and wants it removed)
+	DecideCandidacy() *Manifesto                  // ** whether to stand for ruler of the bike (nil if not), with what the agent promises to do if elected
+	VoteExpulsion(accused uuid.UUID) bool         // ** vote on the motion to expel the accused rider from the (democratic) bike
 
 	// dictator functions
 	DictateDirection() uuid.UUID                // ** called only when the agent is the dictator
@@ -71,6 +75,7 @@ type IBaseBiker interface {
 	HandleVoteRulerMessage(msg VoteRulerMessage)
 	HandleVoteKickoutMessage(msg VoteKickoutMessage)
 	HandleVoteAllocationMessage(msg VoteAllocationMessage)
+	HandleManifestoMessage(msg ManifestoMessage)
 
 	GetAllMessages([]IBaseBiker) []messaging.IMessage[IBaseBiker]
 }
@@ -83,7 +88,8 @@ const (
 )
 
 type BaseBiker struct {
-	*baseAgent.BaseAgent[IBaseBiker]              // BaseBiker inherits functions from BaseAgent such as GetID(), GetAllMessages() and UpdateAgentInternalState()
+	*baseAgent.BaseAgent[IBaseBiker] // BaseBiker inherits functions from BaseAgent such as GetAllMessages() and UpdateAgentInternalState()
+	id                               uuid.UUID
 	soughtColour                     utils.Colour // the colour of the lootbox that the agent is currently seeking
 	onBike                           bool
 	energyLevel                      float64 // float between 0 and 1
@@ -92,9 +98,16 @@ type BaseBiker struct {
 	megaBikeId                       uuid.UUID             // if they are not on a bike it will be 0
 	gameState                        IGameState            // updated by the server at every round
 	reputation                       map[uuid.UUID]float64 // record reputation for other agents in float
+	rng                              *rand.Rand            // the agent's own random source, seeded by the server
 	GroupID                          int
 }
 
+// the ID given by BaseAgent comes from the global uuid source, so it's replaced by one drawn from
+// the agent's own random source (seeded runs then reproduce agent IDs too)
+func (bb *BaseBiker) GetID() uuid.UUID {
+	return bb.id
+}
+
 func (bb *BaseBiker) GetEnergyLevel() float64 {
 	return bb.energyLevel
 }
@@ -148,19 +161,11 @@ func (bb *BaseBiker) GetLocation() utils.Coordinates {
 // in the MVP this is used to determine the pedalling forces as all agent will be
 // aiming to get to the closest lootbox by default
 func (bb *BaseBiker) nearestLoot() uuid.UUID {
-	currLocation := bb.GetLocation()
-	shortestDist := math.MaxFloat64
-	var nearestBox uuid.UUID
-	var currDist float64
-	for _, loot := range bb.gameState.GetLootBoxes() {
-		x, y := loot.GetPosition().X, loot.GetPosition().Y
-		currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
-		if currDist < shortestDist {
-			nearestBox = loot.GetID()
-			shortestDist = currDist
-		}
+	nearestBox := bb.gameState.GetNearestLootBox(bb.GetLocation(), nil)
+	if nearestBox == nil {
+		return uuid.Nil
 	}
-	return nearestBox
+	return nearestBox.GetID()
 }
 
 // in the MVP the biker's action defaults to pedaling (as it won't be able to change bikes)
@@ -187,10 +192,8 @@ func (bb *BaseBiker) DecideForce(direction uuid.UUID) {
 	if len(currentLootBoxes) > 0 {
 		targetPos := currentLootBoxes[direction].GetPosition()
 
-		deltaX := targetPos.X - currLocation.X
-		deltaY := targetPos.Y - currLocation.Y
-		angle := math.Atan2(deltaY, deltaX)
-		normalisedAngle := angle / math.Pi
+		// steer along the shortest path to the lootbox (which may cross the edges of a toroidal grid)
+		normalisedAngle := physics.ComputeOrientation(currLocation, targetPos, bb.gameState.GetConfig().Environment)
 
 		// Default BaseBiker will always
 		turningDecision := utils.TurningDecision{
@@ -199,7 +202,7 @@ func (bb *BaseBiker) DecideForce(direction uuid.UUID) {
 		}
 
 		nearestBoxForces := utils.Forces{
-			Pedal:   utils.BikerMaxForce,
+			Pedal:   bb.gameState.GetConfig().Physics.BikerMaxForce,
 			Brake:   0.0,
 			Turning: turningDecision,
 		}
@@ -207,12 +210,8 @@ func (bb *BaseBiker) DecideForce(direction uuid.UUID) {
 	} else { // otherwise move away from awdi
 		awdiPos := bb.GetGameState().GetAwdi().GetPosition()
 
-		deltaX := awdiPos.X - currLocation.X
-		deltaY := awdiPos.Y - currLocation.Y
-
 		// Steer in opposite direction to awdi
-		angle := math.Atan2(deltaY, deltaX)
-		normalisedAngle := angle / math.Pi
+		normalisedAngle := physics.ComputeOrientation(currLocation, awdiPos, bb.gameState.GetConfig().Environment)
 
 		// Steer in opposite direction to awdi
 		var flipAngle float64
@@ -229,7 +228,7 @@ func (bb *BaseBiker) DecideForce(direction uuid.UUID) {
 		}
 
 		escapeAwdiForces := utils.Forces{
-			Pedal:   utils.BikerMaxForce,
+			Pedal:   bb.gameState.GetConfig().Physics.BikerMaxForce,
 			Brake:   0.0,
 			Turning: turningDecision,
 		}
@@ -239,16 +238,11 @@ func (bb *BaseBiker) DecideForce(direction uuid.UUID) {
 
 // decide which bike to go to. the base agent chooses a random bike
 func (bb *BaseBiker) ChangeBike() uuid.UUID {
-	megaBikes := bb.gameState.GetMegaBikes()
-	i, targetI := 0, rand.Intn(len(megaBikes))
-	// Go doesn't have a sensible way to do this...
-	for id := range megaBikes {
-		if i == targetI {
-			return id
-		}
-		i++
+	bikeID := utils.PickRandomID(bb.rng, bb.gameState.GetMegaBikes())
+	if bikeID == uuid.Nil {
+		panic("no bikes")
 	}
-	panic("no bikes")
+	return bikeID
 }
 
 func (bb *BaseBiker) SetBike(bikeId uuid.UUID) {
@@ -265,7 +259,7 @@ func (bb *BaseBiker) GetGroupID() int {
 
 // this is called when a lootbox of the desidered colour has been looted in order to update the sought colour
 func (bb *BaseBiker) UpdateColour(totColours utils.Colour) {
-	bb.soughtColour = utils.Colour(rand.Intn(int(totColours)))
+	bb.soughtColour = utils.Colour(bb.rng.Intn(int(totColours)))
 }
 
 func (bb *BaseBiker) SetDeterministicColour(col utils.Colour) {
@@ -310,6 +304,12 @@ func (bb *BaseBiker) GetGameState() IGameState {
 	return bb.gameState
 }
 
+// agents should draw any randomness from here (rather than the global math/rand functions)
+// so that a seeded run can be replayed exactly
+func (bb *BaseBiker) GetRandomSource() *rand.Rand {
+	return bb.rng
+}
+
 // Returns the other agents on your bike :)
 func (bb *BaseBiker) GetFellowBikers() []IBaseBiker {
 	bikes := bb.gameState.GetMegaBikes()
@@ -385,7 +385,7 @@ func (bb *BaseBiker) VoteForKickout() map[uuid.UUID]int {
 		agentID := agent.GetID()
 		if agentID != bb.GetID() {
 			// random votes to other agents
-			voteResults[agentID] = rand.Intn(2) // randomly assigns 0 or 1 vote
+			voteResults[agentID] = bb.rng.Intn(2) // randomly assigns 0 or 1 vote
 		}
 	}
 
@@ -411,6 +411,26 @@ func (bb *BaseBiker) DictateDirection() uuid.UUID {
 	return nearest
 }
 
+// defaults to only backing a switch to the governance the agent would found a bike with
+func (bb *BaseBiker) VoteAmendment(proposed utils.Governance) bool {
+	return proposed == bb.DecideGovernance()
+}
+
+// defaults to keeping confidence in the ruler
+func (bb *BaseBiker) VoteNoConfidence() bool {
+	return false
+}
+
+// defaults to backing the motions to expel the riders the agent wants kicked out
+func (bb *BaseBiker) VoteExpulsion(accused uuid.UUID) bool {
+	return bb.VoteForKickout()[accused] > 0
+}
+
+// defaults to standing for ruler, promising nothing
+func (bb *BaseBiker) DecideCandidacy() *Manifesto {
+	return &Manifesto{}
+}
+
 // defaults to voting for first agent in the list
 func (bb *BaseBiker) VoteLeader() voting.IdVoteMap {
 	votes := make(voting.IdVoteMap)
@@ -584,6 +604,16 @@ func (bb *BaseBiker) CreateVoteAllocationMessage() VoteAllocationMessage {
 	}
 }
 
+func (bb *BaseBiker) CreateManifestoMessage() ManifestoMessage {
+	// Currently this returns a default/meaningless message
+	// For team's agent, add your own logic to communicate with other agents
+	return ManifestoMessage{
+		BaseMessage: messaging.CreateMessage[IBaseBiker](bb, bb.GetFellowBikers()),
+		BikeId:      bb.GetBike(),
+		Manifesto:   Manifesto{},
+	}
+}
+
 func (bb *BaseBiker) HandleKickoutMessage(msg KickoutAgentMessage) {
 	// Team's agent should implement logic for handling other biker messages that were sent to them.
 
@@ -666,28 +696,40 @@ func (bb *BaseBiker) HandleVoteAllocationMessage(msg VoteAllocationMessage) {
 	// voteMap := msg.VoteMap
 }
 
+func (bb *BaseBiker) HandleManifestoMessage(msg ManifestoMessage) {
+	// Team's agent should implement logic for handling other biker messages that were sent to them.
+
+	// sender := msg.BaseMessage.GetSender()
+	// bikeId := msg.BikeId
+	// manifesto := msg.Manifesto
+}
+
 // this function is going to be called by the server to instantiate bikers in the MVP
-func GetIBaseBiker(totColours utils.Colour, bikeId uuid.UUID, gameState IGameState) IBaseBiker {
+func GetIBaseBiker(totColours utils.Colour, bikeId uuid.UUID, gameState IGameState, rng *rand.Rand) IBaseBiker {
 	return &BaseBiker{
 		BaseAgent:    baseAgent.NewBaseAgent[IBaseBiker](),
-		soughtColour: utils.GenerateRandomColour(),
+		id:           utils.GenerateRandomID(rng),
+		soughtColour: utils.GenerateRandomColour(rng),
 		onBike:       true,
 		energyLevel:  1.0,
 		points:       0,
 		GroupID:      0,
 		gameState:    gameState,
+		rng:          rng,
 	}
 }
 
 // this function will be used by GetTeamAgent to get the ref to the BaseBiker
-func GetBaseBiker(totColours utils.Colour, bikeId uuid.UUID, gameState IGameState) *BaseBiker {
+func GetBaseBiker(totColours utils.Colour, bikeId uuid.UUID, gameState IGameState, rng *rand.Rand) *BaseBiker {
 	return &BaseBiker{
 		BaseAgent:    baseAgent.NewBaseAgent[IBaseBiker](),
-		soughtColour: utils.GenerateRandomColour(),
+		id:           utils.GenerateRandomID(rng),
+		soughtColour: utils.GenerateRandomColour(rng),
 		onBike:       false,
 		energyLevel:  1.0,
 		points:       0,
 		GroupID:      0,
 		gameState:    gameState,
+		rng:          rng,
 	}
 }
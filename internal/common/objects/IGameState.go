@@ -1,6 +1,9 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/utils"
+
 	"github.com/google/uuid"
 )
 
@@ -15,13 +18,25 @@ type RuleCacheOperations interface {
 	AddToGlobalRuleCache(*Rule)
 }
 
+// SpatialQueries answer what's near a position through the spatial index the server keeps of lootboxes and bikes,
+// without visiting every one of them. A nil accept function accepts every object; ties are broken by ID.
+type SpatialQueries interface {
+	GetNearestLootBox(position utils.Coordinates, accept func(ILootBox) bool) ILootBox       // the nearest accepted lootbox (nil if there's none)
+	GetLootBoxesInRadius(position utils.Coordinates, radius float64) map[uuid.UUID]ILootBox  // the lootboxes at most radius away
+	GetNearestMegaBike(position utils.Coordinates, accept func(IMegaBike) bool) IMegaBike    // the nearest accepted bike (nil if there's none)
+	GetMegaBikesInRadius(position utils.Coordinates, radius float64) map[uuid.UUID]IMegaBike // the bikes at most radius away
+}
+
 // /*
 // IGameState is an interface for GameState that objects will use to get the current game state
 // */
 type IGameState interface {
 	RuleCacheOperations
+	SpatialQueries
 	GetLootBoxes() map[uuid.UUID]ILootBox
 	GetMegaBikes() map[uuid.UUID]IMegaBike
 	GetAgentMap() map[uuid.UUID]IBaseBiker
-	GetAwdi() IAwdi
+	GetAwdi() IAwdi           // the first awdi (see GetAwdis)
+	GetAwdis() []IAwdi        // every awdi, in the order they spawned
+	GetConfig() config.Config // the parameters of the current simulation (a copy, so it can't be altered)
 }
@@ -1,7 +1,9 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/config"
 	utils "SOMAS2023/internal/common/utils"
+	"math/rand"
 )
 
 type ILootBox interface {
@@ -17,11 +19,11 @@ type LootBox struct {
 }
 
 // GetLootBox is a constructor for LootBox that initializes it with a new UUID and default position.
-func GetLootBox() *LootBox {
+func GetLootBox(cfg *config.Config, rng *rand.Rand) *LootBox {
 	return &LootBox{
-		PhysicsObject: GetPhysicsObject(0),
-		colour:        utils.GenerateRandomColour(),    // Initialize to randomized colour
-		totalLoot:     utils.GenerateRandomFloat(2, 4), // Initialize to randomized totalLoot
+		PhysicsObject: GetPhysicsObject(0, cfg, rng),
+		colour:        utils.GenerateRandomColour(rng),      // Initialize to randomized colour
+		totalLoot:     utils.GenerateRandomFloat(rng, 2, 4), // Initialize to randomized totalLoot
 	}
 }
 
@@ -1,8 +1,10 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/config"
 	utils "SOMAS2023/internal/common/utils"
 	"math"
+	"math/rand"
 
 	"github.com/google/uuid"
 )
@@ -17,6 +19,7 @@ type IMegaBike interface {
 	GetGovernance() utils.Governance
 	GetRuler() uuid.UUID
 	GetKickedOutCount() int
+	UpdateKickedOutCount(kicked int)
 	ResetKickedOutCount()
 	GetCurrentPool() float64
 	UpdateCurrentPool(val float64)
@@ -45,9 +48,9 @@ type MegaBike struct {
 }
 
 // GetMegaBike is a constructor for MegaBike that initializes it with a new UUID and default position.
-func GetMegaBike(ruleCache RuleCacheOperations) *MegaBike {
+func GetMegaBike(ruleCache RuleCacheOperations, cfg *config.Config, rng *rand.Rand) *MegaBike {
 	return &MegaBike{
-		PhysicsObject:       GetPhysicsObject(utils.MassBike),
+		PhysicsObject:       GetPhysicsObject(cfg.Physics.MassBike, cfg, rng),
 		governance:          utils.Democracy,
 		ruler:               uuid.Nil,
 		globalRuleCacheView: ruleCache,
@@ -84,8 +87,8 @@ func (mb *MegaBike) GetAgents() []IBaseBiker {
 
 // Calculate the mass of the bike with all it's agents
 func (mb *MegaBike) UpdateMass() {
-	mass := utils.MassBike
-	mass += float64(len(mb.agents))
+	mass := mb.cfg.Physics.MassBike
+	mass += float64(len(mb.agents)) * mb.cfg.Physics.MassBiker
 	mb.mass = mass
 }
 
@@ -158,6 +161,11 @@ func (mb *MegaBike) GetKickedOutCount() int {
 	return mb.kickedOutCount
 }
 
+// counts agents kicked out other than through KickOutAgent
+func (mb *MegaBike) UpdateKickedOutCount(kicked int) {
+	mb.kickedOutCount += kicked
+}
+
 func (mb *MegaBike) ResetKickedOutCount() {
 	mb.kickedOutCount = 0
 }
@@ -180,8 +188,8 @@ func (mb *MegaBike) KickOutAgent(weights map[uuid.UUID]float64) []uuid.UUID {
 
 	// Find all agents with votes > half the number of agents
 	agentsToKickOut := make([]uuid.UUID, 0)
-	for agentID, votes := range voteCount {
-		if votes > float64(len(mb.agents))/2.0 {
+	for _, agentID := range utils.SortedIDs(voteCount) {
+		if voteCount[agentID] > float64(len(mb.agents))/2.0 {
 			agentsToKickOut = append(agentsToKickOut, agentID)
 		}
 	}
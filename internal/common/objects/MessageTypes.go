@@ -80,6 +80,21 @@ type VoteAllocationMessage struct {
 	VoteMap voting.IdVoteMap // the vote map that you voted for (if you are telling the truth)
 }
 
+// Manifesto is what a rider standing for ruler of its bike promises to do if elected (the promises left unset are
+// not made)
+type Manifesto struct {
+	Weights    map[uuid.UUID]float64 `json:"weights,omitempty"`    // the weights it will give the votes of the riders (as a leader)
+	Allocation voting.IdVoteMap      `json:"allocation,omitempty"` // how it will split the loot between the riders (as a dictator)
+	Colour     *utils.Colour         `json:"colour,omitempty"`     // the colour of the lootboxes it will steer the bike to (as a dictator)
+}
+
+// "I am standing for ruler of this bike, and this is what I will do if elected"
+type ManifestoMessage struct {
+	messaging.BaseMessage[IBaseBiker]
+	BikeId    uuid.UUID // the bike the agent is standing for ruler of
+	Manifesto Manifesto // what the agent promises to do if elected
+}
+
 func (msg ReputationOfAgentMessage) InvokeMessageHandler(agent IBaseBiker) {
 	agent.HandleReputationMessage(msg)
 }
@@ -123,3 +138,7 @@ func (msg VoteKickoutMessage) InvokeMessageHandler(agent IBaseBiker) {
 func (msg VoteAllocationMessage) InvokeMessageHandler(agent IBaseBiker) {
 	agent.HandleVoteAllocationMessage(msg)
 }
+
+func (msg ManifestoMessage) InvokeMessageHandler(agent IBaseBiker) {
+	agent.HandleManifestoMessage(msg)
+}
@@ -8,9 +8,12 @@ The Bikers/Agents will not need to implement this interface
 */
 
 import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 
 	"math"
+	"math/rand"
 
 	"github.com/google/uuid"
 )
@@ -27,6 +30,13 @@ type IPhysicsObject interface {
 
 	// Server must set these variables since it updates the gamestate
 	SetPhysicalState(state utils.PhysicalState)
+	// or turn the object around (e.g. when it bounces off the edge of the grid)
+	SetOrientation(orientation float64)
+	// MoveTo sets the state an object moved to in an iteration, remembering the position it set off from
+	// (whereas SetPhysicalState puts the object in a state, as if it had always been there)
+	MoveTo(state utils.PhysicalState)
+	// returns the position the object set off from in its last move
+	GetPreviousPosition() utils.Coordinates
 
 	// This method will update the force of the PhysicsObject based on the current GameState.
 	// I.e. for MegaBike, force will be cacluated from the bikers
@@ -36,16 +46,21 @@ type IPhysicsObject interface {
 	// based on the current GameState
 	UpdateOrientation()
 	CheckForCollision(otherObject IPhysicsObject) bool
+	// checks whether the object came within the collision threshold of another over their last moves (and when)
+	CheckForSweptCollision(otherObject IPhysicsObject) (float64, bool)
 }
 
 type PhysicsObject struct {
-	id           uuid.UUID
-	coordinates  utils.Coordinates
-	mass         float64
-	acceleration float64
-	velocity     float64
-	orientation  float64
-	force        float64
+	id             uuid.UUID
+	coordinates    utils.Coordinates
+	previous       utils.Coordinates // where the object set off from in its last move
+	mass           float64
+	acceleration   float64
+	velocity       float64
+	velocityVector utils.Coordinates
+	orientation    float64
+	force          float64
+	cfg            *config.Config // simulation parameters shared with the server
 }
 
 // returns the unique ID of the object
@@ -72,10 +87,11 @@ func (po *PhysicsObject) GetForce() float64 {
 
 func (po *PhysicsObject) GetPhysicalState() utils.PhysicalState {
 	return utils.PhysicalState{
-		Position:     po.coordinates,
-		Acceleration: po.acceleration,
-		Velocity:     po.velocity,
-		Mass:         po.mass,
+		Position:       po.coordinates,
+		Acceleration:   po.acceleration,
+		Velocity:       po.velocity,
+		VelocityVector: po.velocityVector,
+		Mass:           po.mass,
 	}
 }
 
@@ -84,30 +100,57 @@ func (po *PhysicsObject) SetPhysicalState(state utils.PhysicalState) {
 	po.coordinates = state.Position
 	po.acceleration = state.Acceleration
 	po.velocity = state.Velocity
+	po.velocityVector = state.VelocityVector
+	po.previous = state.Position
+}
+
+func (po *PhysicsObject) MoveTo(state utils.PhysicalState) {
+	from := po.coordinates
+	po.SetPhysicalState(state)
+	po.previous = from
+}
+
+func (po *PhysicsObject) GetPreviousPosition() utils.Coordinates {
+	return po.previous
+}
+
+func (po *PhysicsObject) SetOrientation(orientation float64) {
+	po.orientation = orientation
 }
 
 // this will be used to check if a MegaBike has looted a LootBok or if the Awdi has collided with a MegaBike
 func (po *PhysicsObject) CheckForCollision(otherObject IPhysicsObject) bool {
 	otherPos := otherObject.GetPosition()
-	distance := math.Sqrt(math.Pow(otherPos.X-po.coordinates.X, 2) + math.Pow(otherPos.Y-po.coordinates.Y, 2))
-	if distance < utils.CollisionThreshold {
+	distance := math.Sqrt(physics.ComputeDistance(po.coordinates, otherPos, po.cfg.Environment))
+	if distance < po.cfg.Environment.CollisionThreshold {
 		return true
 	} else {
 		return false
 	}
 }
 
+// this checks the paths of the objects over their last moves rather than where they ended up, so that objects moving
+// faster than the collision threshold can't pass through each other without colliding
+func (po *PhysicsObject) CheckForSweptCollision(otherObject IPhysicsObject) (float64, bool) {
+	return physics.SweptCollision(po.previous, po.coordinates, otherObject.GetPreviousPosition(), otherObject.GetPosition(), po.cfg.Environment.CollisionThreshold, po.cfg.Environment)
+}
+
 func (po *PhysicsObject) UpdateForce() {}
 
 func (po *PhysicsObject) UpdateOrientation() {}
 
-func GetPhysicsObject(mass float64) *PhysicsObject {
+// GetPhysicsObject creates an object of the given mass at a random position, drawing its ID and position from rng
+func GetPhysicsObject(mass float64, cfg *config.Config, rng *rand.Rand) *PhysicsObject {
+	id := utils.GenerateRandomID(rng)
+	coordinates := utils.GenerateRandomCoordinates(rng, cfg.Environment.GridWidth, cfg.Environment.GridHeight)
 	return &PhysicsObject{
-		id:           uuid.New(),
-		coordinates:  utils.GenerateRandomCoordinates(),
+		id:           id,
+		coordinates:  coordinates,
+		previous:     coordinates,
 		mass:         mass,
 		acceleration: 0.0,
 		velocity:     0.0,
 		orientation:  0.0,
+		cfg:          cfg,
 	}
 }
@@ -1,8 +1,10 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/physics"
 	"errors"
+	"slices"
 
 	"github.com/google/uuid"
 	"gonum.org/v1/gonum/mat"
@@ -66,11 +68,11 @@ func (r *Rule) EvaluateAgentInputs(agent IBaseBiker) []float64 {
 	return inputVector
 }
 
-func (r *Rule) EvaluateTestLootboxRuleInputs(bike IMegaBike, lootbox ILootBox) []float64 {
+func (r *Rule) EvaluateTestLootboxRuleInputs(bike IMegaBike, lootbox ILootBox, world config.EnvironmentConfig) []float64 {
 	bPos := bike.GetPosition()
 	lPos := lootbox.GetPosition()
 
-	dPos := physics.ComputeDistance(bPos, lPos)
+	dPos := physics.ComputeDistance(bPos, lPos, world)
 
 	return []float64{
 		dPos,
@@ -83,8 +85,8 @@ func (r *Rule) EvaluateAgentRule(agent IBaseBiker) bool {
 	return r.EvaluateRule(inputVector)
 }
 
-func (r *Rule) EvaluateLootboxRule(bike IMegaBike, lootbox ILootBox) bool {
-	inputVector := r.EvaluateTestLootboxRuleInputs(bike, lootbox)
+func (r *Rule) EvaluateLootboxRule(bike IMegaBike, lootbox ILootBox, world config.EnvironmentConfig) bool {
+	inputVector := r.EvaluateTestLootboxRuleInputs(bike, lootbox, world)
 	return r.EvaluateRule(inputVector)
 }
 
@@ -116,6 +118,23 @@ func GenerateRule(action Action, name string, ruleInputs RuleInputs, ruleMatrix
 	}
 }
 
+// Copy gives a deep copy of the rule (same ID), which can be altered without affecting the original
+func (r *Rule) Copy() *Rule {
+	ruleMatrix := make(RuleMatrix, len(r.ruleMatrix))
+	for i, row := range r.ruleMatrix {
+		ruleMatrix[i] = slices.Clone(row)
+	}
+	return &Rule{
+		ruleID:          r.ruleID,
+		ruleName:        r.ruleName,
+		isMutable:       r.isMutable,
+		action:          r.action,
+		ruleInputs:      slices.Clone(r.ruleInputs),
+		ruleMatrix:      ruleMatrix,
+		ruleComparators: slices.Clone(r.ruleComparators),
+	}
+}
+
 func GenerateNullPassingRule() *Rule {
 	ruleInps := RuleInputs{Location, Energy, Points, Colour}
 	ruleMatrix := [][]float64{{0, 0, 0, 0, 0}, {0, 0, 0, 0, 0}, {0, 0, 0, 0, 0}}
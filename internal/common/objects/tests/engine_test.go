@@ -13,7 +13,7 @@ import (
 func TestRuleEvaluatesAsTrue(t *testing.T) {
 	// generate default agent with colour == 1 and energy == 100
 	testServer := server.GenerateServer()
-	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer))
+	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer, testRng))
 	// make colour deterministic (red) and energy (1-0.25 = 0.75)
 	const COL = 1
 	testAgent.SetDeterministicColour(COL)
@@ -34,7 +34,7 @@ func TestRuleEvaluatesAsTrue(t *testing.T) {
 func TestRuleEvaluatesAsFalse(t *testing.T) {
 	// generate default agent with colour == 1 and energy == 100
 	testServer := server.GenerateServer()
-	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer))
+	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer, testRng))
 	// make colour deterministic (red) and energy (1-0.25 = 0.75)
 	const COL = 1
 	testAgent.SetDeterministicColour(COL)
@@ -81,7 +81,7 @@ func TestRuleImmutability(t *testing.T) {
 func TestRulePassesAfterMutation(t *testing.T) {
 	// generate default agent with colour == 1 and energy == 100
 	testServer := server.GenerateServer()
-	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer))
+	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer, testRng))
 	// make points deterministic
 	testAgent.UpdatePoints(20)
 	testAgent.UpdateEnergyLevel(-0.25)
@@ -109,7 +109,7 @@ func TestRulePassesAfterMutation(t *testing.T) {
 func TestDefaultRuleAlwaysPasses(t *testing.T) {
 	testServer := server.GenerateServer()
 	testServer.Initialize(5)
-	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer))
+	testAgent := agent.NewAgentSOSA(objects.GetBaseBiker(1, uuid.New(), testServer, testRng))
 	testServer.AddAgent(testAgent)
 	testServer.FoundingInstitutions()
 	rule := objects.GenerateNullPassingRule()
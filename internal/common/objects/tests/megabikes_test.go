@@ -1,14 +1,19 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/server"
+	"math/rand"
 	"testing"
 
 	"github.com/google/uuid"
 )
 
+// random source for the agents and objects built by the tests themselves
+var testRng = rand.New(rand.NewSource(0))
+
 type MockBiker struct {
 	*objects.BaseBiker
 	ID      uuid.UUID
@@ -19,7 +24,8 @@ type MockBiker struct {
 }
 
 func (mb *MockBiker) GetLocation() utils.Coordinates {
-	return utils.GenerateRandomCoordinates()
+	cfg := config.DefaultConfig()
+	return utils.GenerateRandomCoordinates(testRng, cfg.Environment.GridWidth, cfg.Environment.GridHeight)
 }
 
 type MegaBike struct {
@@ -36,7 +42,7 @@ func (mrc *MockRuleCache) ViewGlobalRuleCache() map[uuid.UUID]*objects.Rule {
 func (mrc *MockRuleCache) AddToGlobalRuleCache(*objects.Rule) {}
 
 func NewMockBiker(gameState objects.IGameState) *MockBiker {
-	baseBiker := objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), gameState)
+	baseBiker := objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), gameState, testRng)
 
 	return &MockBiker{
 		BaseBiker: baseBiker,
@@ -61,7 +67,7 @@ func (mb *MockBiker) GetID() uuid.UUID {
 }
 
 func TestGetMegaBike(t *testing.T) {
-	mb := objects.GetMegaBike(&MockRuleCache{})
+	mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 
 	if mb == nil {
 		t.Errorf("GetMegaBike returned nil")
@@ -81,7 +87,7 @@ func TestAddAgent(t *testing.T) {
 	s := server.GenerateServer()
 	s.Initialize(iterations)
 
-	mb := objects.GetMegaBike(&MockRuleCache{})
+	mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 	biker := NewMockBiker(s)
 
 	mb.AddAgent(biker)
@@ -100,7 +106,7 @@ func TestRemoveAgent(t *testing.T) {
 	s := server.GenerateServer()
 	s.Initialize(iterations)
 
-	mb := objects.GetMegaBike(&MockRuleCache{})
+	mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 	biker1 := NewMockBiker(s)
 	biker2 := NewMockBiker(s)
 
@@ -129,7 +135,7 @@ func TestUpdateMass(t *testing.T) {
 	s := server.GenerateServer()
 	s.Initialize(iterations)
 
-	mb := objects.GetMegaBike(&MockRuleCache{})
+	mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 	initialMass := mb.GetPhysicalState().Mass
 
 	mb.AddAgent(NewMockBiker(s))
@@ -152,7 +158,7 @@ func TestUpdateOrientation(t *testing.T) {
 		s := server.GenerateServer()
 		s.Initialize(iterations)
 
-		mb := objects.GetMegaBike(&MockRuleCache{})
+		mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 		biker1 := NewMockBiker(s)
 		biker2 := NewMockBiker(s)
 
@@ -162,7 +168,7 @@ func TestUpdateOrientation(t *testing.T) {
 		}
 
 		force := utils.Forces{
-			Pedal:   utils.BikerMaxForce,
+			Pedal:   config.DefaultConfig().Physics.BikerMaxForce,
 			Brake:   0.0,
 			Turning: turningDecision,
 		}
@@ -187,7 +193,7 @@ func TestUpdateOrientation(t *testing.T) {
 		s := server.GenerateServer()
 		s.Initialize(iterations)
 
-		mb := objects.GetMegaBike(&MockRuleCache{})
+		mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 		biker := NewMockBiker(s)
 
 		turningDecision := utils.TurningDecision{
@@ -196,7 +202,7 @@ func TestUpdateOrientation(t *testing.T) {
 		}
 
 		force := utils.Forces{
-			Pedal:   utils.BikerMaxForce,
+			Pedal:   config.DefaultConfig().Physics.BikerMaxForce,
 			Brake:   0.0,
 			Turning: turningDecision,
 		}
@@ -220,7 +226,7 @@ func TestUpdateOrientation(t *testing.T) {
 		s := server.GenerateServer()
 		s.Initialize(iterations)
 
-		mb := objects.GetMegaBike(&MockRuleCache{})
+		mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 		biker1 := NewMockBiker(s)
 		biker2 := NewMockBiker(s)
 
@@ -230,7 +236,7 @@ func TestUpdateOrientation(t *testing.T) {
 		}
 
 		force1 := utils.Forces{
-			Pedal:   utils.BikerMaxForce,
+			Pedal:   config.DefaultConfig().Physics.BikerMaxForce,
 			Brake:   0.0,
 			Turning: turningDecision1,
 		}
@@ -241,7 +247,7 @@ func TestUpdateOrientation(t *testing.T) {
 		}
 
 		force2 := utils.Forces{
-			Pedal:   utils.BikerMaxForce,
+			Pedal:   config.DefaultConfig().Physics.BikerMaxForce,
 			Brake:   0.0,
 			Turning: turningDecision2,
 		}
@@ -270,16 +276,16 @@ func TestUpdateOrientation(t *testing.T) {
 		s := server.GenerateServer()
 		s.Initialize(iterations)
 
-		mb := objects.GetMegaBike(&MockRuleCache{})
+		mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 		biker1 := NewMockBiker(s)
 		biker2 := NewMockBiker(s)
 		biker3 := NewMockBiker(s)
 
 		// Set unique forces for each biker
 		forces := []utils.Forces{
-			{Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.1}},
-			{Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -0.7}},
-			{Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.3}},
+			{Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.1}},
+			{Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -0.7}},
+			{Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.3}},
 		}
 
 		bikers := []*MockBiker{biker1, biker2, biker3}
@@ -306,16 +312,16 @@ func TestUpdateOrientation(t *testing.T) {
 		s := server.GenerateServer()
 		s.Initialize(iterations)
 
-		mb := objects.GetMegaBike(&MockRuleCache{})
+		mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 		biker1 := NewMockBiker(s)
 		biker2 := NewMockBiker(s)
 
 		// Set forces for each biker
 		force1 := utils.Forces{
-			Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -1},
+			Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -1},
 		}
 		force2 := utils.Forces{
-			Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 1},
+			Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 1},
 		}
 
 		biker1.SetForces(force1)
@@ -341,16 +347,16 @@ func TestUpdateOrientation(t *testing.T) {
 		s := server.GenerateServer()
 		s.Initialize(iterations)
 
-		mb := objects.GetMegaBike(&MockRuleCache{})
+		mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 		biker1 := NewMockBiker(s)
 		biker2 := NewMockBiker(s)
 
 		// Set forces for each biker
 		force1 := utils.Forces{
-			Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -0.6},
+			Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -0.6},
 		}
 		force2 := utils.Forces{
-			Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.7},
+			Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.7},
 		}
 
 		biker1.SetForces(force1)
@@ -376,16 +382,16 @@ func TestUpdateOrientation(t *testing.T) {
 		s := server.GenerateServer()
 		s.Initialize(iterations)
 
-		mb := objects.GetMegaBike(&MockRuleCache{})
+		mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 		biker1 := NewMockBiker(s)
 		biker2 := NewMockBiker(s)
 
 		// Set forces for each biker
 		force1 := utils.Forces{
-			Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -0.1},
+			Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: -0.1},
 		}
 		force2 := utils.Forces{
-			Pedal: utils.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.2},
+			Pedal: config.DefaultConfig().Physics.BikerMaxForce, Brake: 0.0, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.2},
 		}
 
 		biker1.SetForces(force1)
@@ -407,7 +413,7 @@ func TestUpdateOrientation(t *testing.T) {
 }
 
 func TestGetSetGovernanceAndRuler(t *testing.T) {
-	mb := objects.GetMegaBike(&MockRuleCache{})
+	mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 	originalGovernance := mb.GetGovernance()
 	originalRuler := mb.GetRuler()
 
@@ -435,7 +441,7 @@ func TestKickOutAgent(t *testing.T) {
 	s.Initialize(iterations)
 	s.FoundingInstitutions()
 
-	mb := objects.GetMegaBike(&MockRuleCache{})
+	mb := objects.GetMegaBike(&MockRuleCache{}, config.DefaultConfig(), testRng)
 
 	//biker1 := NewMockBiker(uuid.New(), map[uuid.UUID]int{ /* votes */ })
 	biker1 := NewMockBiker(s)
@@ -487,10 +493,12 @@ func TestKickOutAgent(t *testing.T) {
 }
 
 func TestPopulateBikeWithFullRuleset(t *testing.T) {
-	serv := server.GenerateServer()
+	cfg := config.DefaultConfig()
+	cfg.Simulation.GlobalRuleCount = 100
+	serv := server.GenerateServerWithConfig(cfg)
 	serv.Initialize(1)
 
-	mb := objects.GetMegaBike(serv)
+	mb := objects.GetMegaBike(serv, cfg, testRng)
 
 	if len(mb.ViewLocalRuleMap()) != 0 {
 		t.Error("Rulemap not initialised as empty")
@@ -508,7 +516,7 @@ func TestRulesExtractedAndPassEvent(t *testing.T) {
 	serv := server.GenerateServer()
 	serv.Initialize(1)
 
-	mb := objects.GetMegaBike(serv)
+	mb := objects.GetMegaBike(serv, config.DefaultConfig(), testRng)
 	for i := 0; i < 8; i++ {
 		mb.AddAgent(NewMockBiker(serv))
 	}
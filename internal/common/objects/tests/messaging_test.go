@@ -21,7 +21,7 @@ type ExtendedBaseBiker struct {
 // Produce new IExtendedBaseBiker
 func NewExtendedBaseBiker(agentId uuid.UUID, gameState obj.IGameState) *ExtendedBaseBiker {
 	return &ExtendedBaseBiker{
-		BaseBiker: obj.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), gameState),
+		BaseBiker: obj.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), gameState, testRng),
 	}
 }
 
@@ -1,6 +1,7 @@
 package objects
 
 import (
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/server"
 	"testing"
@@ -28,7 +29,9 @@ func TestRegisterRuleToCache(t *testing.T) {
 }
 
 func TestServerGeneratesRuleCache(t *testing.T) {
-	serv := server.GenerateServer()
+	cfg := config.DefaultConfig()
+	cfg.Simulation.GlobalRuleCount = 100
+	serv := server.GenerateServerWithConfig(cfg)
 	serv.Initialize(1)
 
 	if len(serv.ViewGlobalRuleCache()) != 100 {
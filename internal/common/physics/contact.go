@@ -0,0 +1,81 @@
+package physics
+
+import (
+	"SOMAS2023/internal/common/config"
+	utils "SOMAS2023/internal/common/utils"
+	"math"
+)
+
+/*
+Contact between moving objects: bikes bumping into each other, and bikes drafting behind one another
+*/
+
+// Bounce resolves a collision between two objects over a step, given their states at the end of it, the positions
+// they set off from and the time of impact (see SweptCollision). The objects are moved back to where they were at the
+// time of impact (and pushed apart to threshold if they were closer), exchange momentum along the line between them,
+// keeping restitution of the speed they were closing at (1 being a perfectly elastic bump), and move on with their new
+// velocities for the rest of the step. It gives their new states and the speed they were closing at.
+func Bounce(state utils.PhysicalState, from utils.Coordinates, otherState utils.PhysicalState, otherFrom utils.Coordinates, impactTime float64, threshold float64, restitution float64, world config.EnvironmentConfig) (utils.PhysicalState, utils.PhysicalState, float64) {
+	position := along(from, state.Position, impactTime, world)
+	otherPosition := along(otherFrom, otherState.Position, impactTime, world)
+
+	// the line between the objects at the time of impact
+	dx, dy := Displacement(position, otherPosition, world)
+	distance := math.Hypot(dx, dy)
+	normal := utils.Coordinates{X: 1.0, Y: 0.0}
+	if distance > 0 {
+		normal = utils.Coordinates{X: dx / distance, Y: dy / distance}
+	}
+	mass, otherMass := state.Mass, otherState.Mass
+
+	// objects that were already too close are pushed apart, the lighter one more so
+	if overlap := threshold - distance; overlap > 0 {
+		position = offset(position, normal, -overlap*otherMass/(mass+otherMass))
+		otherPosition = offset(otherPosition, normal, overlap*mass/(mass+otherMass))
+	}
+
+	velocity, otherVelocity := state.VelocityVector, otherState.VelocityVector
+	closingSpeed := (velocity.X-otherVelocity.X)*normal.X + (velocity.Y-otherVelocity.Y)*normal.Y
+	if closingSpeed > 0 {
+		impulse := (1 + restitution) * closingSpeed / (1/mass + 1/otherMass)
+		velocity = offset(velocity, normal, -impulse/mass)
+		otherVelocity = offset(otherVelocity, normal, impulse/otherMass)
+	} else {
+		closingSpeed = 0.0
+	}
+
+	bounced := func(initial utils.PhysicalState, position utils.Coordinates, velocity utils.Coordinates) utils.PhysicalState {
+		speed := length(velocity)
+		return utils.PhysicalState{
+			Position:       offset(position, velocity, 1.0-impactTime),
+			Acceleration:   initial.Acceleration + speed - initial.Velocity,
+			Velocity:       speed,
+			VelocityVector: velocity,
+			Mass:           initial.Mass,
+		}
+	}
+	return bounced(state, position, velocity), bounced(otherState, otherPosition, otherVelocity), closingSpeed
+}
+
+// IsDrafting reports whether an object heading along the given orientation is close behind a leader: no further
+// than draftingRange from it, and no more than 30° off the line to it
+func IsDrafting(position utils.Coordinates, orientation float64, leader utils.Coordinates, draftingRange float64, world config.EnvironmentConfig) bool {
+	dx, dy := Displacement(position, leader, world)
+	distance := math.Hypot(dx, dy)
+	if distance == 0 || distance > draftingRange {
+		return false
+	}
+	heading := polar(1.0, orientation)
+	return dx*heading.X+dy*heading.Y >= distance*math.Cos(math.Pi/6)
+}
+
+// the position a fraction of the way from one position to another (along the shortest path)
+func along(from utils.Coordinates, to utils.Coordinates, fraction float64, world config.EnvironmentConfig) utils.Coordinates {
+	dx, dy := Displacement(from, to, world)
+	return utils.Coordinates{X: from.X + fraction*dx, Y: from.Y + fraction*dy}
+}
+
+// the position moved by scale times the given vector
+func offset(position utils.Coordinates, vector utils.Coordinates, scale float64) utils.Coordinates {
+	return utils.Coordinates{X: position.X + scale*vector.X, Y: position.Y + scale*vector.Y}
+}
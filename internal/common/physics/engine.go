@@ -1,6 +1,7 @@
 package physics
 
 import (
+	"SOMAS2023/internal/common/config"
 	utils "SOMAS2023/internal/common/utils"
 	"math"
 )
@@ -9,18 +10,18 @@ import (
 The Engine is responsible for calculating physics for the environment
 */
 
-func CalcAcceleration(f float64, m float64, v float64) float64 {
+func CalcAcceleration(f float64, m float64, v float64, dragCoefficient float64) float64 {
 	if m == 0 {
 		panic("zero mass")
 	}
-	return (f - CalcDrag(v)) / m
+	return (f - CalcDrag(v, dragCoefficient)) / m
 }
 
-func CalcDrag(velocity float64) float64 {
-	return utils.DragCoefficient * math.Pow(velocity, 2)
+func CalcDrag(velocity float64, dragCoefficient float64) float64 {
+	return dragCoefficient * math.Pow(velocity, 2)
 }
 
-func CalcVelocity(acc float64, currVelocity float64) float64 {
+func CalcVelocity(acc float64, currVelocity float64, maxSpeed float64) float64 {
 	var newVelocity float64
 	// dt is equal to one
 	if (currVelocity + (acc * 1)) < 0 {
@@ -28,7 +29,7 @@ func CalcVelocity(acc float64, currVelocity float64) float64 {
 	} else {
 		newVelocity = (acc * 1) + currVelocity
 	}
-	return newVelocity
+	return math.Min(newVelocity, maxSpeed)
 }
 
 func GetNewPosition(coordinates utils.Coordinates, velocity float64, orientation float64) utils.Coordinates {
@@ -37,30 +38,115 @@ func GetNewPosition(coordinates utils.Coordinates, velocity float64, orientation
 	return coordinates
 }
 
-// ComputeOrientation is to compute the orientation from source coordinate to target coordinate
-func ComputeOrientation(src utils.Coordinates, target utils.Coordinates) float64 {
+// Displacement is the shortest vector from source to target: on a toroidal world (environment.boundary: wrap)
+// it may cross the edges of the grid
+func Displacement(src utils.Coordinates, target utils.Coordinates, world config.EnvironmentConfig) (float64, float64) {
 	xDiff := target.X - src.X
 	yDiff := target.Y - src.Y
+	if world.Boundary == config.WrapBoundary {
+		xDiff -= world.GridWidth * math.Round(xDiff/world.GridWidth)
+		yDiff -= world.GridHeight * math.Round(yDiff/world.GridHeight)
+	}
+	return xDiff, yDiff
+}
+
+// ComputeOrientation is to compute the orientation from source coordinate to target coordinate (along the shortest path)
+func ComputeOrientation(src utils.Coordinates, target utils.Coordinates, world config.EnvironmentConfig) float64 {
+	xDiff, yDiff := Displacement(src, target, world)
 	return math.Atan2(yDiff, xDiff) / math.Pi
 }
 
-// ComputeDistance is to compute the L2 distance from source to target
-func ComputeDistance(src utils.Coordinates, target utils.Coordinates) float64 {
-	return math.Pow(src.X-target.X, 2) + math.Pow(src.Y-target.Y, 2)
+// ComputeDistance is to compute the squared L2 distance from source to target (along the shortest path)
+func ComputeDistance(src utils.Coordinates, target utils.Coordinates, world config.EnvironmentConfig) float64 {
+	xDiff, yDiff := Displacement(src, target, world)
+	return math.Pow(xDiff, 2) + math.Pow(yDiff, 2)
+}
+
+// SweptCollision reports whether two objects, each moving in a straight line from where it was to where it is over a
+// step, come closer than threshold to each other during it, and if so the time of impact: the fraction of the step
+// gone when they first do (0 if they were already that close at its start)
+func SweptCollision(from utils.Coordinates, to utils.Coordinates, otherFrom utils.Coordinates, otherTo utils.Coordinates, threshold float64, world config.EnvironmentConfig) (float64, bool) {
+	// seen from the other object, the object moves along a segment and the other object is a circle around the origin
+	startX, startY := Displacement(otherFrom, from, world)
+	moveX, moveY := Displacement(from, to, world)
+	otherMoveX, otherMoveY := Displacement(otherFrom, otherTo, world)
+	dx, dy := moveX-otherMoveX, moveY-otherMoveY
+
+	// the time of impact is the first t in [0, 1] for which |start + t * d| = threshold
+	c := math.Pow(startX, 2) + math.Pow(startY, 2) - math.Pow(threshold, 2)
+	if c < 0 {
+		return 0.0, true
+	}
+	a := math.Pow(dx, 2) + math.Pow(dy, 2)
+	b := 2 * (startX*dx + startY*dy)
+	discriminant := math.Pow(b, 2) - 4*a*c
+	// the objects don't move relative to each other, or their paths only graze the circle (if they meet it at all)
+	if a == 0 || discriminant <= 0 {
+		return 0.0, false
+	}
+	t := (-b - math.Sqrt(discriminant)) / (2 * a)
+	if t < 0 || t > 1 {
+		return 0.0, false
+	}
+	return t, true
+}
+
+// WallContact describes what the edges of the grid did to an object in a move
+type WallContact struct {
+	Hit         bool    // the object ran into a reflecting or absorbing wall
+	Orientation float64 // the orientation the object leaves the move with (turned around by a reflecting wall)
 }
 
-// This function is to be called from the server only
-func GenerateNewState(initialState utils.PhysicalState, force float64, orientation float64) utils.PhysicalState {
-	acceleration := CalcAcceleration(force, initialState.Mass, initialState.Velocity)
-	velocity := CalcVelocity(acceleration, initialState.Velocity)
-	coordinates := GetNewPosition(initialState.Position, velocity, orientation)
+// ApplyBoundary brings a state that has just moved back within the grid, according to the boundary mode of the world
+func ApplyBoundary(state utils.PhysicalState, orientation float64, world config.EnvironmentConfig) (utils.PhysicalState, WallContact) {
+	contact := WallContact{Orientation: orientation}
+	position := &state.Position
+	switch world.Boundary {
+	case config.WrapBoundary:
+		position.X -= world.GridWidth * math.Floor(position.X/world.GridWidth)
+		position.Y -= world.GridHeight * math.Floor(position.Y/world.GridHeight)
+	case config.ReflectBoundary:
+		// the part of the move beyond a wall is mirrored back into the grid, and the object turns around
+		if position.X < 0 || position.X > world.GridWidth {
+			position.X = reflect(position.X, world.GridWidth)
+			state.VelocityVector.X = -state.VelocityVector.X
+			contact.Orientation = 1.0 - contact.Orientation
+			contact.Hit = true
+		}
+		if position.Y < 0 || position.Y > world.GridHeight {
+			position.Y = reflect(position.Y, world.GridHeight)
+			state.VelocityVector.Y = -state.VelocityVector.Y
+			contact.Orientation = -contact.Orientation
+			contact.Hit = true
+		}
+	case config.AbsorbBoundary:
+		// the object is stopped at the wall
+		if position.X < 0 || position.X > world.GridWidth || position.Y < 0 || position.Y > world.GridHeight {
+			position.X = math.Max(0, math.Min(position.X, world.GridWidth))
+			position.Y = math.Max(0, math.Min(position.Y, world.GridHeight))
+			state.Velocity = 0.0
+			state.VelocityVector = utils.Coordinates{}
+			state.Acceleration = 0.0
+			contact.Hit = true
+		}
+	}
+	return state, contact
+}
 
-	finalState := utils.PhysicalState{
-		Position:     coordinates,
-		Acceleration: acceleration,
-		Velocity:     velocity,
-		Mass:         initialState.Mass,
+// mirrors a coordinate that went past 0 or limit back into [0, limit]
+func reflect(value float64, limit float64) float64 {
+	if value < 0 {
+		value = -value
+	} else if value > limit {
+		value = 2*limit - value
 	}
+	// a move longer than the grid itself can't be mirrored back into it
+	return math.Max(0, math.Min(value, limit))
+}
 
-	return finalState
+// This function is to be called from the server only: it moves an object facing the given orientation, which turns
+// to the target orientation (as far as the model allows), through an iteration, and keeps it within the grid
+func GenerateNewState(model Model, initialState utils.PhysicalState, force float64, orientation float64, targetOrientation float64, params config.PhysicsConfig, world config.EnvironmentConfig) (utils.PhysicalState, WallContact) {
+	finalState, finalOrientation := model.Step(initialState, force, orientation, targetOrientation, params)
+	return ApplyBoundary(finalState, finalOrientation, world)
 }
@@ -0,0 +1,118 @@
+package physics
+
+import (
+	"SOMAS2023/internal/common/config"
+	utils "SOMAS2023/internal/common/utils"
+	"math"
+)
+
+// Model moves an object through an iteration, given the force applied to it, the orientation it faces and the
+// orientation it's steered to. It gives the new state of the object and the orientation it ends up facing.
+type Model interface {
+	Step(initialState utils.PhysicalState, force float64, orientation float64, targetOrientation float64, params config.PhysicsConfig) (utils.PhysicalState, float64)
+}
+
+// NewModel gives the model selected by physics.model
+func NewModel(model config.PhysicsModel) Model {
+	switch model {
+	case config.VectorPhysics:
+		return VectorModel{}
+	default:
+		return SimpleModel{}
+	}
+}
+
+// SimpleModel is the original model: objects turn to their target orientation at once, and their (scalar)
+// velocity follows the force along it in a single step
+type SimpleModel struct{}
+
+func (SimpleModel) Step(initialState utils.PhysicalState, force float64, orientation float64, targetOrientation float64, params config.PhysicsConfig) (utils.PhysicalState, float64) {
+	acceleration := CalcAcceleration(force, initialState.Mass, initialState.Velocity, params.DragCoefficient)
+	velocity := CalcVelocity(acceleration, initialState.Velocity, params.MaxSpeed)
+	coordinates := GetNewPosition(initialState.Position, velocity, targetOrientation)
+
+	finalState := utils.PhysicalState{
+		Position:       coordinates,
+		Acceleration:   acceleration,
+		Velocity:       velocity,
+		VelocityVector: polar(velocity, targetOrientation),
+		Mass:           initialState.Mass,
+	}
+
+	return finalState, targetOrientation
+}
+
+// VectorModel gives objects momentum: their velocity is a 2D vector, which pedalling pushes along the orientation
+// they face and drag slows down, so they carry on drifting as they turn. Objects turn towards their target orientation
+// by at most physics.turn_rate / mass in an iteration (heavier bikes turn slower), and the iteration is integrated in
+// physics.sub_steps steps.
+type VectorModel struct{}
+
+func (VectorModel) Step(initialState utils.PhysicalState, force float64, orientation float64, targetOrientation float64, params config.PhysicsConfig) (utils.PhysicalState, float64) {
+	mass := initialState.Mass
+	if mass == 0 {
+		panic("zero mass")
+	}
+	dt := 1.0 / float64(params.SubSteps)
+	maxTurn := params.TurnRate / mass * dt
+
+	velocity := VelocityOf(initialState, orientation)
+	position := initialState.Position
+	for step := 0; step < params.SubSteps; step++ {
+		turn := math.Remainder(targetOrientation-orientation, 2.0)
+		orientation += math.Max(-maxTurn, math.Min(turn, maxTurn))
+
+		// pedalling pushes the object along its orientation, and drag slows it down against its velocity
+		speed := length(velocity)
+		thrust := polar(math.Max(force, 0.0), orientation)
+		velocity.X += (thrust.X - params.DragCoefficient*speed*velocity.X) / mass * dt
+		velocity.Y += (thrust.Y - params.DragCoefficient*speed*velocity.Y) / mass * dt
+		// braking slows the object down, but never sends it backwards
+		if force < 0 {
+			velocity = scaleTo(velocity, math.Max(length(velocity)+force/mass*dt, 0.0))
+		}
+		if length(velocity) > params.MaxSpeed {
+			velocity = scaleTo(velocity, params.MaxSpeed)
+		}
+
+		position.X += velocity.X * dt
+		position.Y += velocity.Y * dt
+	}
+
+	speed := length(velocity)
+	finalState := utils.PhysicalState{
+		Position:       position,
+		Acceleration:   speed - initialState.Velocity,
+		Velocity:       speed,
+		VelocityVector: velocity,
+		Mass:           mass,
+	}
+	return finalState, orientation
+}
+
+// VelocityOf is the velocity of an object in a state, heading along orientation: a state only given a speed
+// (e.g. by a scenario or a test) is moving along its orientation
+func VelocityOf(state utils.PhysicalState, orientation float64) utils.Coordinates {
+	if state.VelocityVector == (utils.Coordinates{}) && state.Velocity > 0 {
+		return polar(state.Velocity, orientation)
+	}
+	return state.VelocityVector
+}
+
+// the vector of the given length along an orientation (between -1 and 1, i.e. -180° to 180°)
+func polar(magnitude float64, orientation float64) utils.Coordinates {
+	return utils.Coordinates{X: magnitude * math.Cos(math.Pi*orientation), Y: magnitude * math.Sin(math.Pi*orientation)}
+}
+
+func length(vector utils.Coordinates) float64 {
+	return math.Hypot(vector.X, vector.Y)
+}
+
+// the vector in the same direction, of the given length
+func scaleTo(vector utils.Coordinates, magnitude float64) utils.Coordinates {
+	current := length(vector)
+	if current == 0 {
+		return vector
+	}
+	return utils.Coordinates{X: vector.X * magnitude / current, Y: vector.Y * magnitude / current}
+}
@@ -0,0 +1,60 @@
+package physics
+
+import (
+	"SOMAS2023/internal/common/config"
+	utils "SOMAS2023/internal/common/utils"
+	"math"
+)
+
+/*
+Pursuit of moving objects: how soon, and where, a pursuer can catch up with a target
+*/
+
+// TopSpeed is the speed an object keeps up applying a force: that at which drag balances it, capped at the maximum speed
+func TopSpeed(force float64, params config.PhysicsConfig) float64 {
+	if params.DragCoefficient == 0 {
+		return params.MaxSpeed
+	}
+	return math.Min(math.Sqrt(math.Max(force, 0.0)/params.DragCoefficient), params.MaxSpeed)
+}
+
+// InterceptTime is how long a pursuer moving at speed takes to catch up with a target keeping its velocity (along the
+// shortest path), if it ever can
+func InterceptTime(pursuer utils.Coordinates, speed float64, target utils.Coordinates, targetVelocity utils.Coordinates, world config.EnvironmentConfig) (float64, bool) {
+	dx, dy := Displacement(pursuer, target, world)
+	// the first t > 0 for which |d + t * v| = speed * t
+	c := math.Pow(dx, 2) + math.Pow(dy, 2)
+	if c == 0 {
+		return 0.0, true
+	}
+	a := math.Pow(targetVelocity.X, 2) + math.Pow(targetVelocity.Y, 2) - math.Pow(speed, 2)
+	b := 2 * (dx*targetVelocity.X + dy*targetVelocity.Y)
+	if math.Abs(a) < 1e-12 {
+		// the target is as fast as the pursuer: it can only be caught heading towards it
+		if b >= 0 {
+			return 0.0, false
+		}
+		return -c / b, true
+	}
+	discriminant := math.Pow(b, 2) - 4*a*c
+	if discriminant < 0 {
+		return 0.0, false
+	}
+	root := math.Sqrt(discriminant)
+	for _, t := range []float64{math.Min((-b-root)/(2*a), (-b+root)/(2*a)), math.Max((-b-root)/(2*a), (-b+root)/(2*a))} {
+		if t > 0 {
+			return t, true
+		}
+	}
+	return 0.0, false
+}
+
+// InterceptPoint is where a pursuer moving at speed meets a target keeping its velocity, looking no further ahead than
+// horizon: a target it can't catch up with within it is aimed at where it will be by then
+func InterceptPoint(pursuer utils.Coordinates, speed float64, target utils.Coordinates, targetVelocity utils.Coordinates, horizon float64, world config.EnvironmentConfig) utils.Coordinates {
+	interceptTime, ok := InterceptTime(pursuer, speed, target, targetVelocity, world)
+	if !ok || interceptTime > horizon {
+		interceptTime = horizon
+	}
+	return utils.Coordinates{X: target.X + interceptTime*targetVelocity.X, Y: target.Y + interceptTime*targetVelocity.Y}
+}
@@ -0,0 +1,85 @@
+package physics_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func world(boundary config.BoundaryMode) config.EnvironmentConfig {
+	environment := config.DefaultConfig().Environment
+	environment.Boundary = boundary
+	return environment
+}
+
+func TestShortestPathOnTorus(t *testing.T) {
+	src := utils.Coordinates{X: 245.0, Y: 10.0}
+	target := utils.Coordinates{X: 5.0, Y: 240.0}
+
+	// without wrapping, the target is across the grid...
+	assert.InDelta(t, 240.0*240.0+230.0*230.0, physics.ComputeDistance(src, target, world(config.NoBoundary)), 1e-9)
+	assert.InDelta(t, 0.75, physics.ComputeOrientation(src, target, world(config.NoBoundary)), 0.01)
+	// ...but on a torus it's just over the corner (10 along x, 20 back along y)
+	assert.InDelta(t, 10.0*10.0+20.0*20.0, physics.ComputeDistance(src, target, world(config.WrapBoundary)), 1e-9)
+	dx, dy := physics.Displacement(src, target, world(config.WrapBoundary))
+	assert.InDelta(t, 10.0, dx, 1e-9)
+	assert.InDelta(t, -20.0, dy, 1e-9)
+	assert.Less(t, physics.ComputeOrientation(src, target, world(config.WrapBoundary)), 0.0)
+
+	// walls don't change the shortest path
+	assert.Equal(t, physics.ComputeDistance(src, target, world(config.NoBoundary)), physics.ComputeDistance(src, target, world(config.ReflectBoundary)))
+
+	fmt.Printf("\nShortest path on torus passed \n")
+}
+
+func TestBoundaryModes(t *testing.T) {
+	// a bike heading right (orientation 0) past the right edge of the 250x250 grid
+	state := utils.PhysicalState{Position: utils.Coordinates{X: 252.0, Y: 100.0}, Velocity: 4.0, VelocityVector: utils.Coordinates{X: 4.0}, Acceleration: 1.0, Mass: 1.0}
+
+	// no boundary: the bike carries on
+	free, contact := physics.ApplyBoundary(state, 0.0, world(config.NoBoundary))
+	assert.Equal(t, state, free)
+	assert.False(t, contact.Hit)
+
+	// wrap: it comes back on the left
+	wrapped, contact := physics.ApplyBoundary(state, 0.0, world(config.WrapBoundary))
+	assert.InDelta(t, 2.0, wrapped.Position.X, 1e-9)
+	assert.Equal(t, 100.0, wrapped.Position.Y)
+	assert.Equal(t, state.Velocity, wrapped.Velocity)
+	assert.False(t, contact.Hit)
+
+	// reflect: it bounces back, now heading left
+	reflected, contact := physics.ApplyBoundary(state, 0.0, world(config.ReflectBoundary))
+	assert.InDelta(t, 248.0, reflected.Position.X, 1e-9)
+	assert.Equal(t, state.Velocity, reflected.Velocity)
+	assert.True(t, contact.Hit)
+	assert.Equal(t, 1.0, contact.Orientation)
+	assert.Equal(t, utils.Coordinates{X: -4.0}, reflected.VelocityVector)
+	// going past the bottom edge flips the vertical heading
+	below := utils.PhysicalState{Position: utils.Coordinates{X: 100.0, Y: -1.0}, Velocity: 2.0, Mass: 1.0}
+	reflected, contact = physics.ApplyBoundary(below, -0.25, world(config.ReflectBoundary))
+	assert.InDelta(t, 1.0, reflected.Position.Y, 1e-9)
+	assert.Equal(t, 0.25, contact.Orientation)
+
+	// absorb: it's stopped at the wall
+	absorbed, contact := physics.ApplyBoundary(state, 0.0, world(config.AbsorbBoundary))
+	assert.Equal(t, utils.Coordinates{X: 250.0, Y: 100.0}, absorbed.Position)
+	assert.Equal(t, 0.0, absorbed.Velocity)
+	assert.Equal(t, utils.Coordinates{}, absorbed.VelocityVector)
+	assert.True(t, contact.Hit)
+	assert.Equal(t, 0.0, contact.Orientation)
+
+	// objects within the grid are left alone by every boundary
+	inside := utils.PhysicalState{Position: utils.Coordinates{X: 50.0, Y: 50.0}, Velocity: 1.0, Mass: 1.0}
+	for boundary := config.NoBoundary; boundary < config.NumBoundaryModes; boundary++ {
+		kept, contact := physics.ApplyBoundary(inside, 0.5, world(boundary))
+		assert.Equal(t, inside, kept, boundary.String())
+		assert.Equal(t, physics.WallContact{Orientation: 0.5}, contact, boundary.String())
+	}
+
+	fmt.Printf("\nBoundary modes passed \n")
+}
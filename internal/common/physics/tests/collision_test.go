@@ -0,0 +1,56 @@
+package physics_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSweptCollisionCatchesFastObjects(t *testing.T) {
+	environment := world(config.NoBoundary)
+	lootbox := utils.Coordinates{X: 50.0, Y: 50.0}
+
+	// a bike going 40 units in a step passes right through the lootbox: it's nowhere near it at either end...
+	from, to := utils.Coordinates{X: 30.0, Y: 50.0}, utils.Coordinates{X: 70.0, Y: 50.0}
+	assert.Greater(t, physics.ComputeDistance(from, lootbox, environment), 49.0)
+	assert.Greater(t, physics.ComputeDistance(to, lootbox, environment), 49.0)
+	// ...but collides with it a third of the way in (when it's 7 units away)
+	impactTime, ok := physics.SweptCollision(from, to, lootbox, lootbox, 7.0, environment)
+	assert.True(t, ok)
+	assert.InDelta(t, 13.0/40.0, impactTime, 1e-9)
+
+	// a bike going past it doesn't
+	_, ok = physics.SweptCollision(utils.Coordinates{X: 30.0, Y: 60.0}, utils.Coordinates{X: 70.0, Y: 60.0}, lootbox, lootbox, 7.0, environment)
+	assert.False(t, ok)
+	// nor does one stopping short of it
+	_, ok = physics.SweptCollision(from, utils.Coordinates{X: 40.0, Y: 50.0}, lootbox, lootbox, 7.0, environment)
+	assert.False(t, ok)
+	// one already on it collides at once, even if it doesn't move
+	impactTime, ok = physics.SweptCollision(lootbox, lootbox, lootbox, utils.Coordinates{X: 52.0, Y: 50.0}, 7.0, environment)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, impactTime)
+
+	fmt.Printf("\nSwept collision catches fast objects passed \n")
+}
+
+func TestSweptCollisionOfMovingObjects(t *testing.T) {
+	// two bikes heading towards each other meet in the middle of the step
+	impactTime, ok := physics.SweptCollision(utils.Coordinates{X: 0.0}, utils.Coordinates{X: 20.0}, utils.Coordinates{X: 24.0}, utils.Coordinates{X: 4.0}, 4.0, world(config.NoBoundary))
+	assert.True(t, ok)
+	assert.InDelta(t, 0.5, impactTime, 1e-9)
+
+	// two bikes going the same way at the same speed never get any closer
+	_, ok = physics.SweptCollision(utils.Coordinates{X: 0.0}, utils.Coordinates{X: 20.0}, utils.Coordinates{X: 10.0}, utils.Coordinates{X: 30.0}, 4.0, world(config.NoBoundary))
+	assert.False(t, ok)
+
+	// on a torus, a bike wrapping round from the right edge to the left hits a lootbox by the left edge
+	impactTime, ok = physics.SweptCollision(utils.Coordinates{X: 240.0, Y: 10.0}, utils.Coordinates{X: 10.0, Y: 10.0}, utils.Coordinates{X: 2.0, Y: 10.0}, utils.Coordinates{X: 2.0, Y: 10.0}, 2.0, world(config.WrapBoundary))
+	assert.True(t, ok)
+	assert.InDelta(t, 10.0/20.0, impactTime, 1e-9)
+
+	fmt.Printf("\nSwept collision of moving objects passed \n")
+}
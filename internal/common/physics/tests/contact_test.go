@@ -0,0 +1,87 @@
+package physics_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// two bikes heading straight at each other along y = 50, meeting three quarters of the way through the step
+func headOn(mass float64, otherMass float64) (utils.PhysicalState, utils.Coordinates, utils.PhysicalState, utils.Coordinates) {
+	state := utils.PhysicalState{Position: utils.Coordinates{X: 50.0, Y: 50.0}, Velocity: 20.0, VelocityVector: utils.Coordinates{X: 20.0, Y: 0.0}, Mass: mass}
+	otherState := utils.PhysicalState{Position: utils.Coordinates{X: 50.0, Y: 50.0}, Velocity: 20.0, VelocityVector: utils.Coordinates{X: -20.0, Y: 0.0}, Mass: otherMass}
+	return state, utils.Coordinates{X: 30.0, Y: 50.0}, otherState, utils.Coordinates{X: 70.0, Y: 50.0}
+}
+
+func TestElasticBumpSwapsVelocities(t *testing.T) {
+	environment := world(config.NoBoundary)
+	state, from, otherState, otherFrom := headOn(1.0, 1.0)
+	impactTime, ok := physics.SweptCollision(from, state.Position, otherFrom, otherState.Position, 10.0, environment)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.75, impactTime, 1e-9)
+
+	bounced, otherBounced, closingSpeed := physics.Bounce(state, from, otherState, otherFrom, impactTime, 10.0, 1.0, environment)
+	assert.InDelta(t, 40.0, closingSpeed, 1e-9)
+	// bikes of the same mass swap velocities, and spend the rest of the step moving apart
+	assert.InDelta(t, -20.0, bounced.VelocityVector.X, 1e-9)
+	assert.InDelta(t, 20.0, otherBounced.VelocityVector.X, 1e-9)
+	assert.InDelta(t, 20.0, bounced.Velocity, 1e-9)
+	assert.InDelta(t, 40.0, bounced.Position.X, 1e-9)
+	assert.InDelta(t, 60.0, otherBounced.Position.X, 1e-9)
+	assert.InDelta(t, 50.0, bounced.Position.Y, 1e-9)
+
+	fmt.Printf("\nElastic bump swaps velocities passed \n")
+}
+
+func TestBumpConservesMomentum(t *testing.T) {
+	environment := world(config.NoBoundary)
+	for _, restitution := range []float64{0.0, 0.5, 1.0} {
+		state, from, otherState, otherFrom := headOn(3.0, 1.0)
+		bounced, otherBounced, closingSpeed := physics.Bounce(state, from, otherState, otherFrom, 0.75, 10.0, restitution, environment)
+
+		momentum := state.Mass*state.VelocityVector.X + otherState.Mass*otherState.VelocityVector.X
+		assert.InDelta(t, momentum, bounced.Mass*bounced.VelocityVector.X+otherBounced.Mass*otherBounced.VelocityVector.X, 1e-9)
+		// they move apart at restitution of the speed they closed at, so speed is lost unless the bump is elastic
+		assert.InDelta(t, restitution*closingSpeed, otherBounced.VelocityVector.X-bounced.VelocityVector.X, 1e-9)
+		if restitution < 1 {
+			energy := state.Mass*state.Velocity*state.Velocity + otherState.Mass*otherState.Velocity*otherState.Velocity
+			assert.Less(t, bounced.Mass*bounced.Velocity*bounced.Velocity+otherBounced.Mass*otherBounced.Velocity*otherBounced.Velocity, energy)
+		}
+	}
+
+	fmt.Printf("\nBump conserves momentum passed \n")
+}
+
+func TestBumpPushesOverlappingObjectsApart(t *testing.T) {
+	environment := world(config.NoBoundary)
+	// two stationary bikes too close to each other are pushed apart to the threshold, without closing speed
+	state := utils.PhysicalState{Position: utils.Coordinates{X: 50.0, Y: 50.0}, Mass: 1.0}
+	otherState := utils.PhysicalState{Position: utils.Coordinates{X: 52.0, Y: 50.0}, Mass: 1.0}
+	bounced, otherBounced, closingSpeed := physics.Bounce(state, state.Position, otherState, otherState.Position, 0.0, 10.0, 0.8, environment)
+	assert.Equal(t, 0.0, closingSpeed)
+	assert.InDelta(t, 46.0, bounced.Position.X, 1e-9)
+	assert.InDelta(t, 56.0, otherBounced.Position.X, 1e-9)
+	assert.Equal(t, 0.0, bounced.Velocity)
+
+	fmt.Printf("\nBump pushes overlapping objects apart passed \n")
+}
+
+func TestDraftingBehindALeader(t *testing.T) {
+	environment := world(config.NoBoundary)
+	position := utils.Coordinates{X: 40.0, Y: 50.0}
+	// heading right (orientation 0), a bike drafts behind a leader just ahead of it...
+	assert.True(t, physics.IsDrafting(position, 0.0, utils.Coordinates{X: 45.0, Y: 50.0}, 10.0, environment))
+	assert.True(t, physics.IsDrafting(position, 0.0, utils.Coordinates{X: 48.0, Y: 53.0}, 10.0, environment))
+	// ...but not one too far off its heading, too far ahead, or behind it
+	assert.False(t, physics.IsDrafting(position, 0.0, utils.Coordinates{X: 45.0, Y: 54.0}, 10.0, environment))
+	assert.False(t, physics.IsDrafting(position, 0.0, utils.Coordinates{X: 60.0, Y: 50.0}, 10.0, environment))
+	assert.False(t, physics.IsDrafting(position, 0.0, utils.Coordinates{X: 35.0, Y: 50.0}, 10.0, environment))
+	// heading left, the one behind is the leader
+	assert.True(t, physics.IsDrafting(position, 1.0, utils.Coordinates{X: 35.0, Y: 50.0}, 10.0, environment))
+
+	fmt.Printf("\nDrafting behind a leader passed \n")
+}
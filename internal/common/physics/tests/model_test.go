@@ -0,0 +1,97 @@
+package physics_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func vectorParams() config.PhysicsConfig {
+	params := config.DefaultConfig().Physics
+	params.Model = config.VectorPhysics
+	return params
+}
+
+func TestSimpleModelTurnsAtOnce(t *testing.T) {
+	params := config.DefaultConfig().Physics
+	state := utils.PhysicalState{Position: utils.Coordinates{X: 10.0, Y: 10.0}, Velocity: 1.0, Mass: 2.0}
+
+	final, orientation := physics.SimpleModel{}.Step(state, 0.8, 0.0, 0.5, params)
+	assert.Equal(t, 0.5, orientation)
+	// the bike moves straight up, at the speed the force and drag give it
+	velocity := 1.0 + (0.8-params.DragCoefficient*1.0)/2.0
+	assert.InDelta(t, velocity, final.Velocity, 1e-9)
+	assert.InDelta(t, 10.0, final.Position.X, 1e-9)
+	assert.InDelta(t, 10.0+velocity, final.Position.Y, 1e-9)
+	assert.InDelta(t, velocity, final.VelocityVector.Y, 1e-9)
+
+	// the top speed is never exceeded
+	final, _ = physics.SimpleModel{}.Step(state, 100.0, 0.0, 0.0, params)
+	assert.Equal(t, params.MaxSpeed, final.Velocity)
+
+	fmt.Printf("\nSimple model turns at once passed \n")
+}
+
+func TestVectorModelHasMomentum(t *testing.T) {
+	params := vectorParams()
+	// a bike going right, steered up
+	state := utils.PhysicalState{Velocity: 2.0, VelocityVector: utils.Coordinates{X: 2.0}, Mass: 9.0}
+
+	final, orientation := physics.VectorModel{}.Step(state, 0.8, 0.0, 0.5, params)
+	// it can only turn so far in an iteration...
+	assert.InDelta(t, params.TurnRate/9.0, orientation, 1e-9)
+	// ...and keeps drifting right as it does
+	assert.Greater(t, final.VelocityVector.X, 1.0)
+	assert.Greater(t, final.Position.X, 1.0)
+	assert.InDelta(t, math.Hypot(final.VelocityVector.X, final.VelocityVector.Y), final.Velocity, 1e-9)
+
+	// lighter objects turn faster
+	state.Mass = 2.0
+	_, lightOrientation := physics.VectorModel{}.Step(state, 0.8, 0.0, 0.5, params)
+	assert.Greater(t, lightOrientation, orientation)
+	// turning goes the short way round (from 0.9 to -0.9 is 0.2 counterclockwise)
+	_, orientation = physics.VectorModel{}.Step(state, 0.0, 0.9, -0.9, params)
+	assert.Greater(t, orientation, 0.9)
+
+	fmt.Printf("\nVector model has momentum passed \n")
+}
+
+func TestVectorModelSpeedLimits(t *testing.T) {
+	params := vectorParams()
+
+	// the top speed is never exceeded
+	state := utils.PhysicalState{Mass: 1.0}
+	for i := 0; i < 10; i++ {
+		state, _ = physics.VectorModel{}.Step(state, 100.0, 0.25, 0.25, params)
+		assert.LessOrEqual(t, state.Velocity, params.MaxSpeed+1e-9)
+	}
+	assert.InDelta(t, params.MaxSpeed, state.Velocity, 1e-9)
+
+	// braking stops an object, but doesn't send it backwards
+	for i := 0; i < 10; i++ {
+		state, _ = physics.VectorModel{}.Step(state, -100.0, 0.25, 0.25, params)
+		assert.GreaterOrEqual(t, state.VelocityVector.X, 0.0)
+		assert.GreaterOrEqual(t, state.VelocityVector.Y, 0.0)
+	}
+	assert.Equal(t, 0.0, state.Velocity)
+
+	// a state only given a speed moves along its orientation
+	state = utils.PhysicalState{Velocity: 1.0, Mass: 1.0}
+	state, _ = physics.VectorModel{}.Step(state, 0.0, -0.5, -0.5, params)
+	assert.InDelta(t, 0.0, state.Position.X, 1e-9)
+	assert.Less(t, state.Position.Y, 0.0)
+
+	fmt.Printf("\nVector model speed limits passed \n")
+}
+
+func TestNewModelFollowsConfig(t *testing.T) {
+	assert.IsType(t, physics.SimpleModel{}, physics.NewModel(config.SimplePhysics))
+	assert.IsType(t, physics.VectorModel{}, physics.NewModel(config.VectorPhysics))
+
+	fmt.Printf("\nNew model follows config passed \n")
+}
@@ -0,0 +1,83 @@
+package physics_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptTime(t *testing.T) {
+	environment := world(config.NoBoundary)
+	origin := utils.Coordinates{X: 0.0, Y: 0.0}
+	target := utils.Coordinates{X: 10.0, Y: 0.0}
+
+	// a stationary target is caught up with in a straight line
+	interceptTime, ok := physics.InterceptTime(origin, 2.0, target, utils.Coordinates{}, environment)
+	assert.True(t, ok)
+	assert.InDelta(t, 5.0, interceptTime, 1e-9)
+	// one getting away more slowly than the pursuer later on
+	interceptTime, ok = physics.InterceptTime(origin, 2.0, target, utils.Coordinates{X: 1.0, Y: 0.0}, environment)
+	assert.True(t, ok)
+	assert.InDelta(t, 10.0, interceptTime, 1e-9)
+	// one as fast as the pursuer only if it's heading its way
+	interceptTime, ok = physics.InterceptTime(origin, 2.0, target, utils.Coordinates{X: -2.0, Y: 0.0}, environment)
+	assert.True(t, ok)
+	assert.InDelta(t, 2.5, interceptTime, 1e-9)
+	_, ok = physics.InterceptTime(origin, 2.0, target, utils.Coordinates{X: 0.0, Y: 2.0}, environment)
+	assert.False(t, ok)
+	// and one getting away faster never
+	_, ok = physics.InterceptTime(origin, 2.0, target, utils.Coordinates{X: 3.0, Y: 0.0}, environment)
+	assert.False(t, ok)
+
+	// on a torus the pursuer goes the short way round
+	interceptTime, ok = physics.InterceptTime(utils.Coordinates{X: 1.0, Y: 125.0}, 1.0, utils.Coordinates{X: 249.0, Y: 125.0}, utils.Coordinates{}, world(config.WrapBoundary))
+	assert.True(t, ok)
+	assert.InDelta(t, 2.0, interceptTime, 1e-9)
+
+	fmt.Printf("\nIntercept time passed \n")
+}
+
+func TestInterceptPoint(t *testing.T) {
+	environment := world(config.NoBoundary)
+	origin := utils.Coordinates{X: 0.0, Y: 0.0}
+	target := utils.Coordinates{X: 10.0, Y: 0.0}
+
+	// a stationary target is aimed at directly
+	assert.Equal(t, target, physics.InterceptPoint(origin, 2.0, target, utils.Coordinates{}, 10.0, environment))
+	// one crossing the pursuer's path is met ahead of it, where |(10, t)| = 2t
+	meeting := physics.InterceptPoint(origin, 2.0, target, utils.Coordinates{X: 0.0, Y: 1.0}, 10.0, environment)
+	assert.InDelta(t, 10.0, meeting.X, 1e-9)
+	assert.InDelta(t, 10.0/math.Sqrt(3.0), meeting.Y, 1e-9)
+	// looking no further than the horizon
+	assert.Equal(t, utils.Coordinates{X: 10.0, Y: 2.0}, physics.InterceptPoint(origin, 2.0, target, utils.Coordinates{X: 0.0, Y: 1.0}, 2.0, environment))
+	// and at where one it can't catch up with will be by then
+	assert.Equal(t, utils.Coordinates{X: 25.0, Y: 0.0}, physics.InterceptPoint(origin, 2.0, target, utils.Coordinates{X: 3.0, Y: 0.0}, 5.0, environment))
+
+	fmt.Printf("\nIntercept point passed \n")
+}
+
+func TestVelocityOfAStateOnlyGivenASpeed(t *testing.T) {
+	velocity := physics.VelocityOf(utils.PhysicalState{Velocity: 2.0}, 0.5)
+	assert.InDelta(t, 0.0, velocity.X, 1e-9)
+	assert.InDelta(t, 2.0, velocity.Y, 1e-9)
+	// a velocity vector is kept as it is
+	assert.Equal(t, utils.Coordinates{X: 1.0, Y: 1.0}, physics.VelocityOf(utils.PhysicalState{Velocity: math.Sqrt2, VelocityVector: utils.Coordinates{X: 1.0, Y: 1.0}}, 0.5))
+
+	fmt.Printf("\nVelocity of a state only given a speed passed \n")
+}
+
+func TestTopSpeed(t *testing.T) {
+	params := config.DefaultConfig().Physics
+	params.DragCoefficient, params.MaxSpeed = 0.5, 5.0
+	assert.InDelta(t, math.Sqrt(2.0), physics.TopSpeed(1.0, params), 1e-9)
+	assert.Equal(t, 5.0, physics.TopSpeed(100.0, params))
+	params.DragCoefficient = 0.0
+	assert.Equal(t, 5.0, physics.TopSpeed(1.0, params))
+
+	fmt.Printf("\nTop speed passed \n")
+}
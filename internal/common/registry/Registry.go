@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// InitFunction turns a base biker into an agent of a team (a nil function keeps the base biker)
+type InitFunction func(baseBiker *objects.BaseBiker) objects.IBaseBiker
+
+// BaseBiker is the name the base biker is always registered under
+const BaseBiker = "base"
+
+var (
+	mutex sync.RWMutex
+	teams = map[string]InitFunction{BaseBiker: nil}
+	// names registered more than once, which can't be looked up (as it's unclear which team is meant)
+	clashes = make(map[string]bool)
+)
+
+// Register makes a team available under the given name (client packages register themselves from
+// an init function, so importing them is enough for their agents to be spawned by name)
+func Register(name string, initFunction InitFunction) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, ok := teams[name]; ok {
+		clashes[name] = true
+	}
+	teams[name] = initFunction
+}
+
+// Lookup gives the init function of the team registered under the given name
+func Lookup(name string) (InitFunction, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if clashes[name] {
+		return nil, fmt.Errorf("more than one team is registered as %q", name)
+	}
+	initFunction, ok := teams[name]
+	if !ok {
+		return nil, fmt.Errorf("no team is registered as %q (registered teams: %s)", name, strings.Join(namesLocked(), ", "))
+	}
+	return initFunction, nil
+}
+
+// Names lists the registered teams in alphabetical order
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ValidateMix checks that every team of an agent mix can be looked up
+func ValidateMix(mix config.AgentMix) error {
+	for _, team := range mix {
+		if _, err := Lookup(team.Team); err != nil {
+			return err
+		}
+	}
+	return nil
+}
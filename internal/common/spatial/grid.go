@@ -0,0 +1,239 @@
+package spatial
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"bytes"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+/*
+The Grid is a spatial index over the positions of objects (lootboxes, bikes...): the plane is split into square
+cells, and each object is filed under the cell its position falls in, so that nearest-neighbour and radius queries
+only visit the cells around the position they're about rather than every object. Cells are only held while they
+have objects in them, so the grid isn't bounded (objects may wander off the map). A wrapping grid indexes a toroidal
+map instead, on which distances are measured along the shortest path (possibly across its edges).
+*/
+
+type cell struct {
+	x, y int
+}
+
+type Grid struct {
+	cellSize  float64
+	width     float64 // dimensions of the toroidal map of a wrapping grid (0 when the grid doesn't wrap)
+	height    float64
+	cells     map[cell][]uuid.UUID
+	positions map[uuid.UUID]utils.Coordinates
+}
+
+// NewGrid creates an empty grid whose cells have the given size (which should be about the radius of the usual queries)
+func NewGrid(cellSize float64) *Grid {
+	return &Grid{
+		cellSize:  cellSize,
+		cells:     make(map[cell][]uuid.UUID),
+		positions: make(map[uuid.UUID]utils.Coordinates),
+	}
+}
+
+// NewWrappingGrid creates an empty grid over a toroidal map of the given dimensions, whose objects must be within it
+func NewWrappingGrid(cellSize float64, width float64, height float64) *Grid {
+	grid := NewGrid(cellSize)
+	grid.width, grid.height = width, height
+	return grid
+}
+
+// Len gives the number of objects in the grid
+func (g *Grid) Len() int {
+	return len(g.positions)
+}
+
+// Position gives the position an object was filed at
+func (g *Grid) Position(id uuid.UUID) (utils.Coordinates, bool) {
+	position, ok := g.positions[id]
+	return position, ok
+}
+
+// Insert files an object at the given position, moving it there if it's already in the grid
+func (g *Grid) Insert(id uuid.UUID, position utils.Coordinates) {
+	if old, ok := g.positions[id]; ok {
+		if g.cellOf(old) == g.cellOf(position) {
+			g.positions[id] = position
+			return
+		}
+		g.Remove(id)
+	}
+	g.positions[id] = position
+	c := g.cellOf(position)
+	g.cells[c] = append(g.cells[c], id)
+}
+
+// Remove takes an object out of the grid (if it's in it)
+func (g *Grid) Remove(id uuid.UUID) {
+	position, ok := g.positions[id]
+	if !ok {
+		return
+	}
+	delete(g.positions, id)
+	c := g.cellOf(position)
+	ids := slices.DeleteFunc(g.cells[c], func(other uuid.UUID) bool {
+		return other == id
+	})
+	if len(ids) == 0 {
+		delete(g.cells, c)
+	} else {
+		g.cells[c] = ids
+	}
+}
+
+// Clone gives a copy of the grid, which can be altered without affecting the original
+func (g *Grid) Clone() *Grid {
+	cells := make(map[cell][]uuid.UUID, len(g.cells))
+	for c, ids := range g.cells {
+		cells[c] = slices.Clone(ids)
+	}
+	return &Grid{cellSize: g.cellSize, width: g.width, height: g.height, cells: cells, positions: maps.Clone(g.positions)}
+}
+
+// InRadius gives the objects at most radius away from the given position, in ID order
+func (g *Grid) InRadius(position utils.Coordinates, radius float64) []uuid.UUID {
+	found := make([]uuid.UUID, 0)
+	if radius < 0 || math.IsNaN(radius) {
+		return found
+	}
+	// on a toroidal map an object may be near any of the images of the position
+	seen := make(map[uuid.UUID]bool)
+	for _, image := range g.images(position) {
+		inRadius := func(id uuid.UUID) {
+			if !seen[id] && Distance(image, g.positions[id]) <= radius {
+				seen[id] = true
+				found = append(found, id)
+			}
+		}
+
+		low := g.cellOf(utils.Coordinates{X: image.X - radius, Y: image.Y - radius})
+		high := g.cellOf(utils.Coordinates{X: image.X + radius, Y: image.Y + radius})
+		// when the query covers more cells than there are objects, it's quicker to check every object
+		if spansMoreThan(low, high, len(g.positions)) {
+			for id := range g.positions {
+				inRadius(id)
+			}
+		} else {
+			for x := low.x; x <= high.x; x++ {
+				for y := low.y; y <= high.y; y++ {
+					for _, id := range g.cells[cell{x, y}] {
+						inRadius(id)
+					}
+				}
+			}
+		}
+	}
+	utils.SortIDs(found)
+	return found
+}
+
+// Nearest gives the object closest to the given position among those accepted (all of them when accept is nil),
+// ties going to the lowest ID. It reports false when no object is accepted.
+func (g *Grid) Nearest(position utils.Coordinates, accept func(uuid.UUID) bool) (uuid.UUID, bool) {
+	nearest, nearestDistance := uuid.Nil, math.Inf(1)
+	// on a toroidal map the nearest object is the nearest to any of the images of the position
+	for _, image := range g.images(position) {
+		id, distance := g.nearestTo(image, accept)
+		if distance < nearestDistance || (distance == nearestDistance && bytes.Compare(id[:], nearest[:]) < 0) {
+			nearest, nearestDistance = id, distance
+		}
+	}
+	return nearest, !math.IsInf(nearestDistance, 1)
+}
+
+// the accepted object closest to a position on the plane, and how far it is (infinitely far if there's none)
+func (g *Grid) nearestTo(position utils.Coordinates, accept func(uuid.UUID) bool) (uuid.UUID, float64) {
+	nearest, nearestDistance := uuid.Nil, math.Inf(1)
+	consider := func(id uuid.UUID) {
+		if accept != nil && !accept(id) {
+			return
+		}
+		distance := Distance(position, g.positions[id])
+		if distance < nearestDistance || (distance == nearestDistance && bytes.Compare(id[:], nearest[:]) < 0) {
+			nearest, nearestDistance = id, distance
+		}
+	}
+
+	// the cells are searched in rings around the cell of the position: once ring r has been searched, any object
+	// left is more than r cells away, so the search stops as soon as the nearest object found is closer than that
+	centre := g.cellOf(position)
+	visited := 0
+	for ring := 0; visited < len(g.cells); ring++ {
+		if nearestDistance <= float64(ring-1)*g.cellSize {
+			return nearest, nearestDistance
+		}
+		// once the rings have gone past more cells than there are objects, it's quicker to check every object
+		if spansMoreThan(cell{centre.x - ring, centre.y - ring}, cell{centre.x + ring, centre.y + ring}, len(g.positions)) {
+			nearest, nearestDistance = uuid.Nil, math.Inf(1)
+			for id := range g.positions {
+				consider(id)
+			}
+			break
+		}
+		for _, c := range ringCells(centre, ring) {
+			if ids, ok := g.cells[c]; ok {
+				visited++
+				for _, id := range ids {
+					consider(id)
+				}
+			}
+		}
+	}
+	return nearest, nearestDistance
+}
+
+// the positions a query about the given position is made from: the position itself, or on a toroidal map, the
+// position brought within the map and its images in the copies of the map around it
+func (g *Grid) images(position utils.Coordinates) []utils.Coordinates {
+	if g.width <= 0 || g.height <= 0 {
+		return []utils.Coordinates{position}
+	}
+	position.X -= g.width * math.Floor(position.X/g.width)
+	position.Y -= g.height * math.Floor(position.Y/g.height)
+	images := make([]utils.Coordinates, 0, 9)
+	for _, dx := range []float64{0, -g.width, g.width} {
+		for _, dy := range []float64{0, -g.height, g.height} {
+			images = append(images, utils.Coordinates{X: position.X + dx, Y: position.Y + dy})
+		}
+	}
+	return images
+}
+
+// the cell a position falls in
+func (g *Grid) cellOf(position utils.Coordinates) cell {
+	return cell{int(math.Floor(position.X / g.cellSize)), int(math.Floor(position.Y / g.cellSize))}
+}
+
+// the cells at Chebyshev distance ring from the centre
+func ringCells(centre cell, ring int) []cell {
+	if ring == 0 {
+		return []cell{centre}
+	}
+	cells := make([]cell, 0, 8*ring)
+	for i := -ring; i <= ring; i++ {
+		cells = append(cells, cell{centre.x + i, centre.y - ring}, cell{centre.x + i, centre.y + ring})
+	}
+	for i := -ring + 1; i <= ring-1; i++ {
+		cells = append(cells, cell{centre.x - ring, centre.y + i}, cell{centre.x + ring, centre.y + i})
+	}
+	return cells
+}
+
+// reports whether the block of cells from low to high (inclusive) holds more than n cells
+func spansMoreThan(low cell, high cell, n int) bool {
+	width, height := float64(high.x-low.x+1), float64(high.y-low.y+1)
+	return width*height > float64(n)
+}
+
+// Distance is the Euclidean distance between two positions (computed as the collision checks of physics objects do)
+func Distance(a utils.Coordinates, b utils.Coordinates) float64 {
+	return math.Sqrt(math.Pow(a.X-b.X, 2) + math.Pow(a.Y-b.Y, 2))
+}
@@ -0,0 +1,170 @@
+package spatial_test
+
+import (
+	"SOMAS2023/internal/common/spatial"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fills a grid with objects at random positions on a 250x250 map, plus a few that have wandered far off it
+func randomGrid(rng *rand.Rand, n int) (*spatial.Grid, map[uuid.UUID]utils.Coordinates) {
+	grid := spatial.NewGrid(25.0)
+	positions := make(map[uuid.UUID]utils.Coordinates, n)
+	for i := 0; i < n; i++ {
+		position := utils.GenerateRandomCoordinates(rng, 250.0, 250.0)
+		if i%50 == 0 {
+			position = utils.Coordinates{X: -3000.0 * rng.Float64(), Y: 5000.0 * rng.Float64()}
+		}
+		id := utils.GenerateRandomID(rng)
+		grid.Insert(id, position)
+		positions[id] = position
+	}
+	return grid, positions
+}
+
+// the nearest accepted object, found by visiting all of them
+func bruteForceNearest(positions map[uuid.UUID]utils.Coordinates, position utils.Coordinates, accept func(uuid.UUID) bool) uuid.UUID {
+	nearest, nearestDistance := uuid.Nil, math.Inf(1)
+	for _, id := range utils.SortedIDs(positions) {
+		if distance := spatial.Distance(position, positions[id]); distance < nearestDistance && (accept == nil || accept(id)) {
+			nearest, nearestDistance = id, distance
+		}
+	}
+	return nearest
+}
+
+func TestNearestMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	grid, positions := randomGrid(rng, 500)
+	ids := utils.SortedIDs(positions)
+	// only half of the objects are accepted by the filtered queries
+	accept := func(id uuid.UUID) bool {
+		return id[0]%2 == 0
+	}
+
+	for i := 0; i < 200; i++ {
+		position := utils.Coordinates{X: rng.Float64()*400 - 75, Y: rng.Float64()*400 - 75}
+		nearest, ok := grid.Nearest(position, nil)
+		assert.True(t, ok)
+		assert.Equal(t, bruteForceNearest(positions, position, nil), nearest)
+		nearest, ok = grid.Nearest(position, accept)
+		assert.True(t, ok)
+		assert.Equal(t, bruteForceNearest(positions, position, accept), nearest)
+	}
+
+	// objects far away are found all the same
+	far := ids[0]
+	nearest, ok := grid.Nearest(utils.Coordinates{X: 10000.0, Y: -10000.0}, func(id uuid.UUID) bool { return id == far })
+	assert.True(t, ok)
+	assert.Equal(t, far, nearest)
+
+	_, ok = grid.Nearest(utils.Coordinates{}, func(uuid.UUID) bool { return false })
+	assert.False(t, ok)
+	_, ok = spatial.NewGrid(25.0).Nearest(utils.Coordinates{}, nil)
+	assert.False(t, ok)
+}
+
+func TestInRadiusMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	grid, positions := randomGrid(rng, 500)
+
+	for _, radius := range []float64{0.0, 7.0, 30.0, 120.0, 10000.0} {
+		for i := 0; i < 50; i++ {
+			position := utils.GenerateRandomCoordinates(rng, 250.0, 250.0)
+			expected := make([]uuid.UUID, 0)
+			for _, id := range utils.SortedIDs(positions) {
+				if spatial.Distance(position, positions[id]) <= radius {
+					expected = append(expected, id)
+				}
+			}
+			assert.Equal(t, expected, grid.InRadius(position, radius), "radius %v", radius)
+		}
+	}
+	assert.Empty(t, grid.InRadius(utils.Coordinates{}, -1.0))
+}
+
+func TestGridFollowsObjects(t *testing.T) {
+	grid := spatial.NewGrid(10.0)
+	a, b := uuid.New(), uuid.New()
+	grid.Insert(a, utils.Coordinates{X: 1, Y: 1})
+	grid.Insert(b, utils.Coordinates{X: 50, Y: 50})
+	assert.Equal(t, 2, grid.Len())
+
+	// moving an object files it under its new cell
+	grid.Insert(a, utils.Coordinates{X: 95, Y: 95})
+	assert.Equal(t, 2, grid.Len())
+	assert.Empty(t, grid.InRadius(utils.Coordinates{X: 1, Y: 1}, 5.0))
+	assert.Equal(t, []uuid.UUID{a}, grid.InRadius(utils.Coordinates{X: 100, Y: 100}, 10.0))
+	nearest, _ := grid.Nearest(utils.Coordinates{X: 0, Y: 0}, nil)
+	assert.Equal(t, b, nearest)
+
+	// a clone doesn't follow the original
+	clone := grid.Clone()
+	grid.Remove(b)
+	grid.Remove(b)
+	assert.Equal(t, 1, grid.Len())
+	nearest, _ = grid.Nearest(utils.Coordinates{X: 0, Y: 0}, nil)
+	assert.Equal(t, a, nearest)
+	position, ok := clone.Position(b)
+	assert.True(t, ok)
+	assert.Equal(t, utils.Coordinates{X: 50, Y: 50}, position)
+}
+
+func TestNearestBreaksTiesByID(t *testing.T) {
+	grid := spatial.NewGrid(10.0)
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	// all of them are as far from the origin, in different cells
+	grid.Insert(ids[0], utils.Coordinates{X: 30, Y: 0})
+	grid.Insert(ids[1], utils.Coordinates{X: 0, Y: -30})
+	grid.Insert(ids[2], utils.Coordinates{X: -30, Y: 0})
+
+	nearest, _ := grid.Nearest(utils.Coordinates{}, nil)
+	utils.SortIDs(ids)
+	assert.Equal(t, ids[0], nearest)
+}
+
+func TestWrappingGridMeasuresAcrossEdges(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	grid := spatial.NewWrappingGrid(25.0, 250.0, 250.0)
+	positions := make(map[uuid.UUID]utils.Coordinates)
+	for i := 0; i < 300; i++ {
+		id := utils.GenerateRandomID(rng)
+		positions[id] = utils.GenerateRandomCoordinates(rng, 250.0, 250.0)
+		grid.Insert(id, positions[id])
+	}
+	// the shortest distance on the torus, found by visiting all of the objects
+	torusDistance := func(a, b utils.Coordinates) float64 {
+		dx, dy := math.Abs(a.X-b.X), math.Abs(a.Y-b.Y)
+		return math.Hypot(math.Min(dx, 250.0-dx), math.Min(dy, 250.0-dy))
+	}
+
+	for i := 0; i < 100; i++ {
+		position := utils.GenerateRandomCoordinates(rng, 250.0, 250.0)
+		radius := 40.0 * rng.Float64()
+		expected := make([]uuid.UUID, 0)
+		nearest, nearestDistance := uuid.Nil, math.Inf(1)
+		for _, id := range utils.SortedIDs(positions) {
+			distance := torusDistance(position, positions[id])
+			if distance <= radius+1e-9 {
+				expected = append(expected, id)
+			}
+			if distance < nearestDistance {
+				nearest, nearestDistance = id, distance
+			}
+		}
+		assert.Equal(t, expected, grid.InRadius(position, radius))
+		found, _ := grid.Nearest(position, nil)
+		assert.Equal(t, nearest, found)
+	}
+
+	// an object in a corner is next to the opposite corner
+	corner := uuid.New()
+	grid.Insert(corner, utils.Coordinates{X: 249.0, Y: 249.0})
+	assert.Contains(t, grid.InRadius(utils.Coordinates{X: 1.0, Y: 1.0}, 3.0), corner)
+	assert.Contains(t, grid.InRadius(utils.Coordinates{X: -1.0, Y: 250.0}, 1.0), corner)
+}
@@ -1,25 +1,65 @@
 package utils
 
 import (
+	"bytes"
 	"math/rand"
+	"slices"
+
+	"github.com/google/uuid"
 )
 
 // GenerateRandomCoordinates creates random X and Y coordinates within the grid boundaries.
-func GenerateRandomCoordinates() Coordinates {
+func GenerateRandomCoordinates(rng *rand.Rand, gridWidth float64, gridHeight float64) Coordinates {
 	// Generate random coordinates
 	return Coordinates{
-		X: rand.Float64() * GridWidth,
-		Y: rand.Float64() * GridHeight,
+		X: rng.Float64() * gridWidth,
+		Y: rng.Float64() * gridHeight,
 	}
 }
 
-// GenerateRandomCoordinates creates random X and Y coordinates within the grid boundaries.
-func GenerateRandomColour() Colour {
+// GenerateRandomColour picks one of the lootbox colours at random.
+func GenerateRandomColour(rng *rand.Rand) Colour {
 	// Generate a random index between 0 and the number of colours - 1.
-	randomIndex := rand.Intn(int(NumOfColours))
+	randomIndex := rng.Intn(int(NumOfColours))
 	return Colour(randomIndex)
 }
 
-func GenerateRandomFloat(min float64, max float64) float64 {
-	return min + rand.Float64()*(max-min)
+func GenerateRandomFloat(rng *rand.Rand, min float64, max float64) float64 {
+	return min + rng.Float64()*(max-min)
+}
+
+// GenerateRandomID creates a (version 4) UUID from the given random source, so that the IDs
+// handed out in a seeded run are the same every time it is replayed
+func GenerateRandomID(rng *rand.Rand) uuid.UUID {
+	// reading from a rand.Rand never fails
+	id, _ := uuid.NewRandomFromReader(rng)
+	return id
+}
+
+// SortedIDs returns the keys of an ID-indexed map in ascending order. Ranging over a map visits
+// its keys in a randomised order, so anything whose outcome depends on the visiting order (ties,
+// draws from the random source, floating point sums) should iterate over these instead.
+func SortedIDs[V any](m map[uuid.UUID]V) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	SortIDs(ids)
+	return ids
+}
+
+// SortIDs sorts a slice of IDs in place, in ascending order
+func SortIDs(ids []uuid.UUID) {
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+}
+
+// PickRandomID returns the key of a uniformly chosen entry of an ID-indexed map, or uuid.Nil if it's empty
+func PickRandomID[V any](rng *rand.Rand, m map[uuid.UUID]V) uuid.UUID {
+	if len(m) == 0 {
+		return uuid.Nil
+	}
+	ids := SortedIDs(m)
+	return ids[rng.Intn(len(ids))]
 }
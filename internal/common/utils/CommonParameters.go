@@ -1,67 +1,59 @@
 package utils
 
-/*
-Environment Parameters
-*/
-const GridHeight float64 = 250.0
-const GridWidth float64 = 250.0
-const CollisionThreshold float64 = 7.0
-const Epsilon float64 = 0.01 // tolerance for FP rounding and checking if == 1.0
-const BikersOnBike = 8
-const ReplenishEnergyEveryRound = true
-const ResetPointsEveryRound = true
-const RespawnEveryRound = true
-const RoundIterations = 100
+import "fmt"
 
 /*
-Server Parameters
+Tunable simulation parameters live in the config package (see config.DefaultConfig),
+so that they can be loaded from a scenario file instead of being recompiled.
 */
-const ReplenishLootBoxes bool = true
-const ReplenishMegaBikes bool = true
-
-/*
-Physics Parameters
-*/
-const MassBike float64 = 1.0
-const MassBiker float64 = 1.0
-const MassAwdi float64 = 7.0
-
-const BikerMaxForce float64 = 0.8 // The max force a biker can pedal
-const AwdiMaxForce float64 = 1.0  // The awdi's force is equivalent to that of one biker agent going at maximum speed
-
-const DragCoefficient float64 = 0.5 // Drag coefficient can be optimised in experimentation
-
-const MovingDepletion float64 = 0.01 // proportionality of energy loss
 
-const LimboEnergyPenalty float64 = -0.05 // amount of energy lost per round when off a bike
-
-const DeliberativeDemocracyPenalty float64 = 0.05 // amount of energy lost per vote in a deliberative democracy
-const LeadershipDemocracyPenalty float64 = 0.025  // amount of energy lost per vote in a leadership democracy
-
-/*
-Resources - Points and Energy
-*/
-const PointsFromSameColouredLootBox = 5.0
-
-/*
-Awdi Behavior
-*/
-const AwdiTargetsEmptyMegaBike bool = false
-const AwdiOnlyTargetsStationaryMegaBike bool = false // if false, targeting slowest
-const AwdiRemovesMegaBike bool = false
+const Epsilon float64 = 0.01 // tolerance for FP rounding and checking if == 1.0
 
 /*
 Voting Method Choice
 */
-type voteMethods int
+type VoteMethod int
 
 const (
-	PLURALITY voteMethods = iota
+	PLURALITY VoteMethod = iota
 	RUNOFF
 	BORDACOUNT
 	INSTANTRUNOFF
 	APPROVAL
 	COPELANDSCORING
+	NumVoteMethods // add a sentinel for counting the number of voting methods
 )
 
-const VoteAction voteMethods = PLURALITY
+func (v VoteMethod) String() string {
+	switch v {
+	case PLURALITY:
+		return "plurality"
+	case RUNOFF:
+		return "runoff"
+	case BORDACOUNT:
+		return "borda_count"
+	case INSTANTRUNOFF:
+		return "instant_runoff"
+	case APPROVAL:
+		return "approval"
+	case COPELANDSCORING:
+		return "copeland_scoring"
+	default:
+		return "unknown"
+	}
+}
+
+// allows voting methods to be written by name in scenario files
+func (v VoteMethod) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v *VoteMethod) UnmarshalText(text []byte) error {
+	for method := PLURALITY; method < NumVoteMethods; method++ {
+		if method.String() == string(text) {
+			*v = method
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown voting method %q", string(text))
+}
@@ -77,21 +77,44 @@ type Coordinates struct {
 }
 
 type PhysicalState struct {
-	Position     Coordinates `json:"position"`
-	Acceleration float64     `json:"acceleration"`
-	Velocity     float64     `json:"velocity"`
-	Mass         float64     `json:"mass"`
+	Position       Coordinates `json:"position"`
+	Acceleration   float64     `json:"acceleration"`
+	Velocity       float64     `json:"velocity"`        // the speed of the object
+	VelocityVector Coordinates `json:"velocity_vector"` // the direction it's moving in, as a 2D velocity (of length Velocity)
+	Mass           float64     `json:"mass"`
 }
 
 type Governance int
 
 const (
-	Democracy Governance = iota
-	Leadership
-	Dictatorship
+	Democracy    Governance = iota // the riders vote as equals
+	Leadership                     // a leader weighs the votes of the riders
+	Dictatorship                   // the dictator decides alone
+	Council                        // a council of elected riders votes among itself
+	Sortition                      // a ruler drawn by lot decides alone
+	Rotation                       // the riders take turns leading, in ID order
 	Invalid
 )
 
+func (g Governance) String() string {
+	switch g {
+	case Democracy:
+		return "democracy"
+	case Leadership:
+		return "leadership"
+	case Dictatorship:
+		return "dictatorship"
+	case Council:
+		return "council"
+	case Sortition:
+		return "sortition"
+	case Rotation:
+		return "rotation"
+	default:
+		return "invalid"
+	}
+}
+
 type Action int
 
 const (
@@ -37,7 +37,8 @@ func GetAcceptanceRanking(rankings map[uuid.UUID]map[uuid.UUID]bool, weights map
 	// sum the number of acceptance rankings for all the agents
 	cumulativeRank := make(map[uuid.UUID]float64)
 	quorum := float64(len(rankings)) / 2.0
-	for voter, ranking := range rankings {
+	for _, voter := range utils.SortedIDs(rankings) {
+		ranking := rankings[voter]
 		for agent, outcome := range ranking {
 			val, ok := cumulativeRank[agent]
 			if outcome && ok {
@@ -54,14 +55,9 @@ func GetAcceptanceRanking(rankings map[uuid.UUID]map[uuid.UUID]bool, weights map
 		}
 	}
 
-	// sort according to ranking
-	unsortedAcceptedList := make([]uuid.UUID, len(passedUnsorted))
-	i := 0
-	for key := range passedUnsorted {
-		unsortedAcceptedList[i] = key
-		i += 1
-	}
-	sort.Slice(unsortedAcceptedList, func(i, j int) bool {
+	// sort according to ranking (agents with the same ranking stay in ID order)
+	unsortedAcceptedList := utils.SortedIDs(passedUnsorted)
+	sort.SliceStable(unsortedAcceptedList, func(i, j int) bool {
 		return passedUnsorted[unsortedAcceptedList[i]] > passedUnsorted[unsortedAcceptedList[j]]
 	})
 	return unsortedAcceptedList
@@ -70,17 +66,18 @@ func GetAcceptanceRanking(rankings map[uuid.UUID]map[uuid.UUID]bool, weights map
 
 func SumOfValues(voteMap IVoter) float64 {
 	sum := 0.0
-	for _, value := range voteMap.GetVotes() {
-		sum += value
+	votes := voteMap.GetVotes()
+	for _, id := range utils.SortedIDs(votes) {
+		sum += votes[id]
 	}
 	return sum
 }
 
 // Returns the normalized vote outcome (assumes all the maps contain a voting between 0-1
-// for each option, and that all the votings sum to 1)
-func CumulativeDist(voters map[uuid.UUID]IVoter, weights map[uuid.UUID]float64) map[uuid.UUID]float64 {
+// for each option, and that all the votings sum to 1), or an error if the votes can't be normalised
+func CumulativeDist(voters map[uuid.UUID]IVoter, weights map[uuid.UUID]float64) (map[uuid.UUID]float64, error) {
 	if len(voters) == 0 {
-		panic("no votes provided")
+		return nil, errors.New("no votes provided")
 	}
 	// Vote checks for each voter
 	aggregateVotes := make(map[uuid.UUID]float64)
@@ -90,7 +87,8 @@ func CumulativeDist(voters map[uuid.UUID]IVoter, weights map[uuid.UUID]float64)
 		aggregateVotes[voter] = 0.0
 	}
 
-	for agentID, voter := range voters {
+	for _, agentID := range utils.SortedIDs(voters) {
+		voter := voters[agentID]
 		voteSum := SumOfValues(voter)
 		votes := voter.GetVotes()
 		weight := weights[agentID]
@@ -100,23 +98,23 @@ func CumulativeDist(voters map[uuid.UUID]IVoter, weights map[uuid.UUID]float64)
 	}
 
 	normalizeFactor := 0.0
-	for _, vote := range aggregateVotes {
-		normalizeFactor += vote
+	for _, agentId := range utils.SortedIDs(aggregateVotes) {
+		normalizeFactor += aggregateVotes[agentId]
 	}
 	if normalizeFactor == 0.0 {
-		panic("all votes summed to zero")
+		return nil, errors.New("all votes summed to zero")
 	}
 	// normalising step for all voters involved
 	for agentId, vote := range aggregateVotes {
 		aggregateVotes[agentId] = vote / normalizeFactor
 	}
-	return aggregateVotes
+	return aggregateVotes, nil
 }
 
 // return the votesMap
-func GetVotesMap(voters map[uuid.UUID]IVoter) map[uuid.UUID]map[uuid.UUID]float64 {
+func GetVotesMap(voters map[uuid.UUID]IVoter) (map[uuid.UUID]map[uuid.UUID]float64, error) {
 	if len(voters) == 0 {
-		panic("no votes provided")
+		return nil, errors.New("no votes provided")
 	}
 	// Vote checks for each voter
 	VotesOfAgents := make(map[uuid.UUID]map[uuid.UUID]float64)
@@ -128,22 +126,25 @@ func GetVotesMap(voters map[uuid.UUID]IVoter) map[uuid.UUID]map[uuid.UUID]float6
 		}
 		for id := range votes {
 			if id == uuid.Nil {
-				panic("agent voted for a nil uuid")
+				return nil, errors.New("agent voted for a nil uuid")
 			}
 			votes[id] /= voteSum
 		}
 		VotesOfAgents[agentID] = votes
 	}
 
-	return VotesOfAgents
+	return VotesOfAgents, nil
 }
 
 // returns the winner accoring to chosen voting strategy (assumes all the maps contain a voting between 0-1
-// for each option, and that all the votings sum to 1)
-func WinnerFromDist(voters map[uuid.UUID]IVoter, voteWeight map[uuid.UUID]float64) uuid.UUID {
-	VotesOfAgents := GetVotesMap(voters)
+// for each option, and that all the votings sum to 1), or an error if the votes can't be counted
+func WinnerFromDist(voters map[uuid.UUID]IVoter, voteWeight map[uuid.UUID]float64, method utils.VoteMethod) (uuid.UUID, error) {
+	VotesOfAgents, err := GetVotesMap(voters)
+	if err != nil {
+		return uuid.Nil, err
+	}
 	var winner uuid.UUID
-	switch utils.VoteAction {
+	switch method {
 	case utils.PLURALITY:
 		winner = Plurality(VotesOfAgents, voteWeight)
 	case utils.RUNOFF:
@@ -158,7 +159,7 @@ func WinnerFromDist(voters map[uuid.UUID]IVoter, voteWeight map[uuid.UUID]float6
 		winner = CopelandScoring(VotesOfAgents, voteWeight)
 	}
 	// TODO call group 8 voting function
-	return winner
+	return winner, nil
 }
 
 func WinnerFromGovernance(voters []GovernanceVote) (utils.Governance, error) {
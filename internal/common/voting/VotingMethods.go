@@ -1,6 +1,7 @@
 package voting
 
 import (
+	"SOMAS2023/internal/common/utils"
 	"math"
 	"sort"
 
@@ -20,7 +21,8 @@ func Plurality(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.
 	*/
 	//initialise the votes with weights
 	var voteList []map[uuid.UUID]float64
-	for agent, votes := range voteMap {
+	for _, agent := range utils.SortedIDs(voteMap) {
+		votes := voteMap[agent]
 		weight := voteWeight[agent]
 		weightedvotes := make(map[uuid.UUID]float64)
 		for key, value := range votes {
@@ -36,7 +38,8 @@ func Plurality(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.
 	for _, preference := range voteList {
 		var maxPreference float64 = -1
 		var firstLootBoxChoice uuid.UUID
-		for lootBox, value := range preference {
+		for _, lootBox := range utils.SortedIDs(preference) {
+			value := preference[lootBox]
 			if value > maxPreference {
 				firstLootBoxChoice = lootBox
 				maxPreference = value
@@ -48,7 +51,8 @@ func Plurality(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.
 	// final step: we need to find the winner with highest count number in map.
 	var maxVotes float64 = -1
 
-	for lootBox, votes := range voteCount {
+	for _, lootBox := range utils.SortedIDs(voteCount) {
+		votes := voteCount[lootBox]
 		if votes > maxVotes {
 			maxVotes = votes
 			winner = lootBox
@@ -68,7 +72,8 @@ func Runoff(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.UUI
 	*/
 	//initialise the votes with weights
 	var voteList []map[uuid.UUID]float64
-	for agent, votes := range voteMap {
+	for _, agent := range utils.SortedIDs(voteMap) {
+		votes := voteMap[agent]
 		weight := voteWeight[agent]
 		weightedvotes := make(map[uuid.UUID]float64)
 		for key, value := range votes {
@@ -86,7 +91,8 @@ func Runoff(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.UUI
 	for _, preference := range voteList {
 		var maxPreference float64
 		var firstLootBoxChoice uuid.UUID
-		for lootBox, value := range preference {
+		for _, lootBox := range utils.SortedIDs(preference) {
+			value := preference[lootBox]
 			if value > maxPreference {
 				firstLootBoxChoice = lootBox
 				maxPreference = value
@@ -98,7 +104,8 @@ func Runoff(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.UUI
 	// find the two candidates with most first-placed votes
 	var maxVotes1, maxVotes2 float64
 	var winner1, winner2 uuid.UUID
-	for lootBox, votes := range voteCount {
+	for _, lootBox := range utils.SortedIDs(voteCount) {
+		votes := voteCount[lootBox]
 		if votes > maxVotes1 {
 			winner2 = winner1
 			maxVotes2 = maxVotes1
@@ -142,7 +149,8 @@ func BordaCount(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid
 	*/
 	//initialise the votes with weights
 	voteListMap := make(map[uuid.UUID]map[uuid.UUID]float64)
-	for agent, votes := range voteMap {
+	for _, agent := range utils.SortedIDs(voteMap) {
+		votes := voteMap[agent]
 		weight := voteWeight[agent]
 		weightedvotes := make(map[uuid.UUID]float64)
 		for key, value := range votes {
@@ -164,16 +172,18 @@ func BordaCount(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid
 
 	// covert the unodered map into ordered list
 	ss := make(map[uuid.UUID][]kv)
-	for agent, preference := range voteListMap {
+	for _, agent := range utils.SortedIDs(voteListMap) {
+		preference := voteListMap[agent]
 		var s []kv
-		for k, v := range preference {
+		for _, k := range utils.SortedIDs(preference) {
+			v := preference[k]
 			// ignore the lootbox if value is 0
 			if v != 0 {
 				s = append(s, kv{k, v})
 			}
 		}
 		// sort the list using preference value of each lootbox
-		sort.Slice(s, func(i, j int) bool {
+		sort.SliceStable(s, func(i, j int) bool {
 			// in the order from large to small
 			return s[i].Value > s[j].Value
 		})
@@ -181,7 +191,8 @@ func BordaCount(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid
 	}
 
 	// calculate the Borda score for each candidates
-	for agent, sortedList := range ss {
+	for _, agent := range utils.SortedIDs(ss) {
+		sortedList := ss[agent]
 		usedKeys := make(map[uuid.UUID]bool)
 		for i, kv := range sortedList {
 			score := float64(len(voteCount)) - float64(i) + 1
@@ -201,7 +212,8 @@ func BordaCount(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid
 
 	// find the winner with highest score
 	var maxScore float64
-	for key, value := range voteCount {
+	for _, key := range utils.SortedIDs(voteCount) {
+		value := voteCount[key]
 		if value > maxScore {
 			winner = key
 			maxScore = value
@@ -219,7 +231,8 @@ func InstantRunoff(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[u
 	*/
 	//initialise the votes with weights
 	var voteList []map[uuid.UUID]float64
-	for agent, votes := range voteMap {
+	for _, agent := range utils.SortedIDs(voteMap) {
+		votes := voteMap[agent]
 		weight := voteWeight[agent]
 		weightedvotes := make(map[uuid.UUID]float64)
 		for key, value := range votes {
@@ -251,7 +264,8 @@ func InstantRunoff(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[u
 		for _, preference := range voteList {
 			var maxScore float64
 			var firstLootBoxChoice uuid.UUID
-			for key, value := range preference {
+			for _, key := range utils.SortedIDs(preference) {
+				value := preference[key]
 				if (value > maxScore) && !eliminateVote[key] {
 					maxScore = value
 					firstLootBoxChoice = key
@@ -263,7 +277,8 @@ func InstantRunoff(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[u
 		// eliminate the lootbox with least votes
 		var minVotes float64 = math.MaxFloat64
 		var candidateToEliminate uuid.UUID
-		for key, value := range voteCount {
+		for _, key := range utils.SortedIDs(voteCount) {
+			value := voteCount[key]
 			if value < minVotes {
 				minVotes = value
 				candidateToEliminate = key
@@ -289,7 +304,8 @@ func Approval(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.U
 	*/
 	//initialise the votes with weights
 	var voteList []map[uuid.UUID]float64
-	for agent, votes := range voteMap {
+	for _, agent := range utils.SortedIDs(voteMap) {
+		votes := voteMap[agent]
 		weight := voteWeight[agent]
 		weightedvotes := make(map[uuid.UUID]float64)
 		for key, value := range votes {
@@ -303,7 +319,8 @@ func Approval(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.U
 	var winner uuid.UUID
 
 	for _, preference := range voteList {
-		for key, value := range preference {
+		for _, key := range utils.SortedIDs(preference) {
+			value := preference[key]
 			if value > 0 {
 				voteCount[key] += value
 			}
@@ -313,7 +330,8 @@ func Approval(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map[uuid.U
 	// find the lootbox with max score
 	var maxVotes float64
 
-	for lootBox, votes := range voteCount {
+	for _, lootBox := range utils.SortedIDs(voteCount) {
+		votes := voteCount[lootBox]
 		if votes > maxVotes {
 			maxVotes = votes
 			winner = lootBox
@@ -331,7 +349,8 @@ func CopelandScoring(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map
 	*/
 	//initialise the votes with weights
 	voteListMap := make(map[uuid.UUID]map[uuid.UUID]float64)
-	for agent, votes := range voteMap {
+	for _, agent := range utils.SortedIDs(voteMap) {
+		votes := voteMap[agent]
 		weight := voteWeight[agent]
 		weightedvotes := make(map[uuid.UUID]float64)
 		for key, value := range votes {
@@ -345,9 +364,13 @@ func CopelandScoring(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map
 	scores := make(map[uuid.UUID]float64)
 
 	// iterate the voting
-	for agent, vote := range voteListMap {
-		for candidate1, score1 := range vote {
-			for candidate2, score2 := range vote {
+	for _, agent := range utils.SortedIDs(voteListMap) {
+		vote := voteListMap[agent]
+		candidates := utils.SortedIDs(vote)
+		for _, candidate1 := range candidates {
+			score1 := vote[candidate1]
+			for _, candidate2 := range candidates {
+				score2 := vote[candidate2]
 				// do not compare with itself
 				if candidate1 == candidate2 {
 					continue
@@ -368,7 +391,8 @@ func CopelandScoring(voteMap map[uuid.UUID]map[uuid.UUID]float64, voteWeight map
 	// find the lootbox with the highest score
 	var maxScore float64
 	var maxCandidate uuid.UUID
-	for candidate, score := range scores {
+	for _, candidate := range utils.SortedIDs(scores) {
+		score := scores[candidate]
 		if score > maxScore || maxCandidate == uuid.Nil {
 			maxScore = score
 			maxCandidate = candidate
@@ -0,0 +1,107 @@
+package replay
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"reflect"
+)
+
+// Divergence describes the first point at which a replayed run stopped matching its recording
+type Divergence struct {
+	GameLoop  int    `json:"game_loop"`
+	Iteration int    `json:"iteration"`
+	Aspect    string `json:"aspect"` // what diverged: "bikes", "awdis", "agents", "votes" or "length"
+	Detail    string `json:"detail"`
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("game loop %d, iteration %d: %s diverged (%s)", d.GameLoop, d.Iteration, d.Aspect, d.Detail)
+}
+
+// Verify replays a recording from its scenario, returning where the replay diverged from it (nil if it didn't)
+func Verify(recording *Recording) (*Divergence, error) {
+	replayed, err := Record(recording.Config)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(recording.Snapshots, replayed.Snapshots), nil
+}
+
+// Compare finds the first snapshot where the replayed run diverged from the recorded one (nil if it didn't).
+// Determinism means the exact same values, so no tolerance is allowed.
+func Compare(recorded []Snapshot, replayed []Snapshot) *Divergence {
+	for i := 0; i < min(len(recorded), len(replayed)); i++ {
+		if divergence := compareSnapshots(&recorded[i], &replayed[i]); divergence != nil {
+			return divergence
+		}
+	}
+	if len(recorded) != len(replayed) {
+		last := Snapshot{Iteration: -1}
+		if n := min(len(recorded), len(replayed)); n > 0 {
+			last = recorded[n-1]
+		}
+		return &Divergence{
+			GameLoop:  last.GameLoop,
+			Iteration: last.Iteration,
+			Aspect:    "length",
+			Detail:    fmt.Sprintf("%d snapshots replayed, %d recorded", len(replayed), len(recorded)),
+		}
+	}
+	return nil
+}
+
+func compareSnapshots(recorded *Snapshot, replayed *Snapshot) *Divergence {
+	diverged := func(aspect string, format string, args ...any) *Divergence {
+		return &Divergence{
+			GameLoop:  recorded.GameLoop,
+			Iteration: recorded.Iteration,
+			Aspect:    aspect,
+			Detail:    fmt.Sprintf(format, args...),
+		}
+	}
+
+	if recorded.GameLoop != replayed.GameLoop || recorded.Iteration != replayed.Iteration {
+		return diverged("length", "replayed game loop %d, iteration %d instead", replayed.GameLoop, replayed.Iteration)
+	}
+
+	if len(recorded.Bikes) != len(replayed.Bikes) {
+		return diverged("bikes", "%d bikes replayed, %d recorded", len(replayed.Bikes), len(recorded.Bikes))
+	}
+	for _, id := range utils.SortedIDs(recorded.Bikes) {
+		replayedBike, ok := replayed.Bikes[id]
+		if !ok {
+			return diverged("bikes", "bike %s is missing", id)
+		}
+		if recordedBike := recorded.Bikes[id]; recordedBike != replayedBike {
+			return diverged("bikes", "bike %s replayed as %+v, recorded as %+v", id, replayedBike, recordedBike)
+		}
+	}
+
+	if !reflect.DeepEqual(recorded.Awdis, replayed.Awdis) {
+		return diverged("awdis", "replayed as %+v, recorded as %+v", replayed.Awdis, recorded.Awdis)
+	}
+
+	if len(recorded.Agents) != len(replayed.Agents) {
+		return diverged("agents", "%d agents replayed, %d recorded", len(replayed.Agents), len(recorded.Agents))
+	}
+	for _, id := range utils.SortedIDs(recorded.Agents) {
+		replayedAgent, ok := replayed.Agents[id]
+		if !ok {
+			return diverged("agents", "agent %s is missing", id)
+		}
+		if recordedAgent := recorded.Agents[id]; recordedAgent != replayedAgent {
+			return diverged("agents", "agent %s replayed as %+v, recorded as %+v", id, replayedAgent, recordedAgent)
+		}
+	}
+
+	if len(recorded.Votes) != len(replayed.Votes) {
+		return diverged("votes", "%d votes replayed, %d recorded", len(replayed.Votes), len(recorded.Votes))
+	}
+	for i := range recorded.Votes {
+		if !reflect.DeepEqual(recorded.Votes[i], replayed.Votes[i]) {
+			return diverged("votes", "%s on bike %s replayed as %+v, recorded as %+v",
+				recorded.Votes[i].Type, recorded.Votes[i].BikeID, replayed.Votes[i], recorded.Votes[i])
+		}
+	}
+	return nil
+}
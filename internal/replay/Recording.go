@@ -0,0 +1,187 @@
+package replay
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Recording holds the inputs of a run (its scenario, seed included) along with the state of the world
+// after every iteration of it, so that the run can be replayed and compared against it
+type Recording struct {
+	Config    *config.Config
+	Snapshots []Snapshot
+}
+
+// Snapshot is the state of the world after an iteration of a game loop (-1 being the state once the
+// bikes were founded), along with the votes cast during that iteration
+type Snapshot struct {
+	GameLoop  int                      `json:"game_loop"`
+	Iteration int                      `json:"iteration"`
+	Bikes     map[uuid.UUID]BikeState  `json:"bikes"`
+	Awdis     []utils.PhysicalState    `json:"awdis"`
+	Agents    map[uuid.UUID]AgentState `json:"agents"`
+	Votes     []Vote                   `json:"votes"`
+}
+
+type BikeState struct {
+	PhysicalState utils.PhysicalState `json:"physical_state"`
+	Orientation   float64             `json:"orientation"`
+}
+
+type AgentState struct {
+	Energy float64 `json:"energy"`
+	Points int     `json:"points"`
+}
+
+// Vote is a ruler election or direction vote held on a bike
+type Vote struct {
+	Type      server.EventType                    `json:"type"`
+	BikeID    uuid.UUID                           `json:"bike_id"`
+	Outcome   uuid.UUID                           `json:"outcome"` // the elected ruler or chosen lootbox
+	Proposals map[uuid.UUID]uuid.UUID             `json:"proposals,omitempty"`
+	Votes     map[uuid.UUID]map[uuid.UUID]float64 `json:"votes,omitempty"`
+}
+
+// Record runs the scenario, recording the state of the world after each of its iterations. A scenario
+// without a seed is given one from the clock (kept in the recording's copy of the scenario).
+func Record(cfg *config.Config) (*Recording, error) {
+	recorded := *cfg
+	if recorded.Simulation.Seed == nil {
+		recorded.Simulation.Seed = config.Seed(time.Now().UnixNano())
+	}
+
+	// the server runs on its own copy of the scenario, dumping the whole game state to the recorder
+	running := recorded
+	running.Output.DumpLevel = config.FullDump
+	running.Output.Statistics = config.StatisticsConfig{}
+	snapshots := &recorder{}
+	s := server.NewServer(server.WithConfig(&running), server.WithEventSubscribers(snapshots))
+	s.Initialize(running.Simulation.Iterations)
+	if _, err := s.Run(snapshots); err != nil {
+		return nil, err
+	}
+	return &Recording{Config: &recorded, Snapshots: snapshots.snapshots}, nil
+}
+
+// recorder turns the full game dump of a run, and the votes published during it, into snapshots
+type recorder struct {
+	snapshots []Snapshot
+	gameLoop  int
+	// votes are published as they're cast, before the game loop they're part of is dumped
+	votes map[int][]Vote // by iteration
+}
+
+func (r *recorder) HandleEvent(event server.Event) {
+	if r.votes == nil {
+		r.votes = make(map[int][]Vote)
+	}
+	switch event.Type {
+	case server.ElectionHeld:
+		r.votes[event.Iteration] = append(r.votes[event.Iteration], Vote{Type: event.Type, BikeID: event.BikeID, Outcome: event.AgentID, Votes: nilIfEmpty(event.Votes)})
+	case server.DirectionChosen:
+		r.votes[event.Iteration] = append(r.votes[event.Iteration], Vote{Type: event.Type, BikeID: event.BikeID, Outcome: event.LootBoxID, Proposals: nilIfEmpty(event.Proposals), Votes: nilIfEmpty(event.Votes)})
+	}
+}
+
+// empty maps are left out of the recording file, so they're read back as nil
+func nilIfEmpty[M ~map[K]V, K comparable, V any](m M) M {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func (r *recorder) WriteGameStates(gameStates []server.GameStateDump) error {
+	for _, gameState := range gameStates {
+		snapshot := Snapshot{
+			GameLoop:  r.gameLoop,
+			Iteration: gameState.Iteration,
+			Bikes:     make(map[uuid.UUID]BikeState, len(gameState.Bikes)),
+			Awdis:     make([]utils.PhysicalState, 0, len(gameState.Awdis)),
+			Agents:    make(map[uuid.UUID]AgentState, len(gameState.Agents)),
+			Votes:     append(make([]Vote, 0), r.votes[gameState.Iteration]...),
+		}
+		for id, bike := range gameState.Bikes {
+			snapshot.Bikes[id] = BikeState{PhysicalState: bike.PhysicalState, Orientation: bike.Orientation}
+		}
+		for _, awdi := range gameState.Awdis {
+			snapshot.Awdis = append(snapshot.Awdis, awdi.PhysicalState)
+		}
+		for id, agent := range gameState.Agents {
+			snapshot.Agents[id] = AgentState{Energy: agent.EnergyLevel, Points: agent.Points}
+		}
+		r.snapshots = append(r.snapshots, snapshot)
+	}
+
+	r.votes = nil
+	r.gameLoop++
+	return nil
+}
+
+func (r *recorder) WriteIteration(*server.SimplifiedIterationDump) error {
+	return errors.New("the recorder needs the full game dump")
+}
+
+func (r *recorder) Close() error {
+	return nil
+}
+
+// the first line of a recording file
+type header struct {
+	Config *config.Config `json:"config"`
+}
+
+// WriteRecording writes the scenario of a recording on its first line, followed by a line per snapshot
+func WriteRecording(w io.Writer, recording *Recording) error {
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	if err := encoder.Encode(header{Config: recording.Config}); err != nil {
+		return fmt.Errorf("encoding recording header: %w", err)
+	}
+	for _, snapshot := range recording.Snapshots {
+		if err := encoder.Encode(snapshot); err != nil {
+			return fmt.Errorf("encoding snapshot of game loop %d, iteration %d: %w", snapshot.GameLoop, snapshot.Iteration, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("writing recording: %w", err)
+	}
+	return nil
+}
+
+// ReadRecording reads back a recording written by WriteRecording
+func ReadRecording(r io.Reader) (*Recording, error) {
+	decoder := json.NewDecoder(r)
+	recordingHeader := header{}
+	if err := decoder.Decode(&recordingHeader); err != nil {
+		return nil, fmt.Errorf("reading recording header: %w", err)
+	}
+	if recordingHeader.Config == nil {
+		return nil, errors.New("recording has no scenario")
+	}
+	if err := recordingHeader.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("recorded scenario: %w", err)
+	}
+
+	recording := &Recording{Config: recordingHeader.Config, Snapshots: make([]Snapshot, 0)}
+	for {
+		snapshot := Snapshot{}
+		err := decoder.Decode(&snapshot)
+		if err == io.EOF {
+			return recording, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot %d: %w", len(recording.Snapshots), err)
+		}
+		recording.Snapshots = append(recording.Snapshots, snapshot)
+	}
+}
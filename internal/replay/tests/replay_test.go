@@ -0,0 +1,86 @@
+package replay_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/replay"
+	"SOMAS2023/internal/server"
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func smallScenario() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.BikerAgentCount = 16
+	cfg.Simulation.Iterations = 2
+	cfg.Simulation.RoundIterations = 10
+	cfg.Simulation.Seed = config.Seed(21)
+	return cfg
+}
+
+func TestRecordingIsReplayedExactly(t *testing.T) {
+	recording, err := replay.Record(smallScenario())
+	assert.NoError(t, err)
+	// a snapshot once the bikes are founded, then one per iteration
+	assert.Len(t, recording.Snapshots, 2*(10+1))
+	assert.Equal(t, -1, recording.Snapshots[0].Iteration)
+	assert.Equal(t, 1, recording.Snapshots[len(recording.Snapshots)-1].GameLoop)
+	votes := 0
+	for _, snapshot := range recording.Snapshots {
+		votes += len(snapshot.Votes)
+	}
+	assert.Positive(t, votes)
+
+	// the recording goes through a file and back
+	file := new(bytes.Buffer)
+	assert.NoError(t, replay.WriteRecording(file, recording))
+	read, err := replay.ReadRecording(file)
+	assert.NoError(t, err)
+	assert.Equal(t, recording.Config, read.Config)
+
+	divergence, err := replay.Verify(read)
+	assert.NoError(t, err)
+	assert.Nil(t, divergence)
+
+	fmt.Printf("\nRecording is replayed exactly passed \n")
+}
+
+func TestUnseededRecordingKeepsItsSeed(t *testing.T) {
+	cfg := smallScenario()
+	cfg.Simulation.Seed = nil
+
+	recording, err := replay.Record(cfg)
+	assert.NoError(t, err)
+	assert.NotNil(t, recording.Config.Simulation.Seed)
+	assert.Nil(t, cfg.Simulation.Seed)
+
+	fmt.Printf("\nUnseeded recording keeps its seed passed \n")
+}
+
+func TestFirstDivergenceIsReported(t *testing.T) {
+	recording, err := replay.Record(smallScenario())
+	assert.NoError(t, err)
+	replayed, err := replay.Record(recording.Config)
+	assert.NoError(t, err)
+
+	// change a vote cast in the 4th iteration of the second game loop, and an agent's energy after it
+	tampered := 11 + 4
+	assert.NotEmpty(t, replayed.Snapshots[tampered].Votes)
+	vote := &replayed.Snapshots[tampered].Votes[0]
+	vote.Type = server.ElectionHeld
+	for id, agent := range replayed.Snapshots[tampered+1].Agents {
+		agent.Energy += 0.5
+		replayed.Snapshots[tampered+1].Agents[id] = agent
+		break
+	}
+
+	divergence := replay.Compare(recording.Snapshots, replayed.Snapshots)
+	assert.Equal(t, &replay.Divergence{GameLoop: 1, Iteration: 3, Aspect: "votes", Detail: divergence.Detail}, divergence)
+
+	divergence = replay.Compare(recording.Snapshots, replayed.Snapshots[:tampered])
+	assert.Equal(t, "length", divergence.Aspect)
+
+	fmt.Printf("\nFirst divergence is reported passed \n")
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"bytes"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// draftingBikes finds the moving bikes close behind another moving bike (physics.drafting_range), which feel less
+// drag as they move. It's run before any bike moves, so that the order bikes move in doesn't matter.
+func (s *Server) draftingBikes() map[uuid.UUID]bool {
+	drafting := make(map[uuid.UUID]bool)
+	if s.cfg.Physics.DraftingRange <= 0 {
+		return drafting
+	}
+	for _, bike := range s.sortedMegaBikes() {
+		if bike.GetVelocity() == 0 {
+			continue
+		}
+		for _, leader := range s.GetMegaBikesInRadius(bike.GetPosition(), s.cfg.Physics.DraftingRange) {
+			if leader.GetID() != bike.GetID() && leader.GetVelocity() > 0 &&
+				physics.IsDrafting(bike.GetPosition(), bike.GetOrientation(), leader.GetPosition(), s.cfg.Physics.DraftingRange, s.cfg.Environment) {
+				drafting[bike.GetID()] = true
+				break
+			}
+		}
+	}
+	return drafting
+}
+
+// BikeCollisionCheck bounces the bikes that ran into each other as they moved off each other (physics.bike_collisions),
+// which costs their riders energy (resources.bike_collision_damage)
+func (s *Server) BikeCollisionCheck() {
+	if !s.cfg.Physics.BikeCollisions {
+		return
+	}
+	threshold := s.cfg.Environment.CollisionThreshold
+	for _, bike := range s.sortedMegaBikes() {
+		// bikes that met during their moves can't have ended up further apart than that
+		nearbyBikes := s.GetMegaBikesInRadius(bike.GetPosition(), threshold+2*s.cfg.Physics.MaxSpeed)
+		for _, otherID := range utils.SortedIDs(nearbyBikes) {
+			// each pair of bikes is only looked at once
+			if bikeID := bike.GetID(); bytes.Compare(bikeID[:], otherID[:]) >= 0 {
+				continue
+			}
+			other := nearbyBikes[otherID]
+			impactTime, ok := bike.CheckForSweptCollision(other)
+			if !ok {
+				continue
+			}
+			state, otherState, closingSpeed := physics.Bounce(bike.GetPhysicalState(), bike.GetPreviousPosition(), other.GetPhysicalState(), other.GetPreviousPosition(), impactTime, threshold, s.cfg.Physics.Restitution, s.cfg.Environment)
+			s.redirect(bike, state)
+			s.redirect(other, otherState)
+			for _, collided := range []objects.IMegaBike{bike, other} {
+				for _, agent := range collided.GetAgents() {
+					agent.UpdateEnergyLevel(-s.cfg.Resources.BikeCollisionDamage * closingSpeed)
+				}
+			}
+		}
+	}
+}
+
+// redirect changes where a bike's last move took it (keeping where it set off from)
+func (s *Server) redirect(bike objects.IMegaBike, state utils.PhysicalState) {
+	state, wall := physics.ApplyBoundary(state, bike.GetOrientation(), s.cfg.Environment)
+	start := bike.GetPhysicalState()
+	start.Position = bike.GetPreviousPosition()
+	bike.SetPhysicalState(start)
+	bike.MoveTo(state)
+	bike.SetOrientation(wall.Orientation)
+	// under the simple model bikes move along their orientation, so a bump turns them the way they bounced off
+	if s.cfg.Physics.Model == config.SimplePhysics && state.Velocity > 0 {
+		bike.SetOrientation(math.Atan2(state.VelocityVector.Y, state.VelocityVector.X) / math.Pi)
+	}
+	s.index.megaBikes.Insert(bike.GetID(), state.Position)
+}
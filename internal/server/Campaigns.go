@@ -0,0 +1,130 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"maps"
+	"math"
+
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
+	"github.com/google/uuid"
+)
+
+/*
+The campaign held before a ruler election when governance.nominations is set: riders stand for ruler with a manifesto
+(what they promise to do if elected), which is handed to the other riders, and the ruler is then checked against it
+*/
+
+// asks the riders of a bike whether they stand for its ruler, handing the manifesto of each candidate to the other
+// riders as a ManifestoMessage. Gives the manifesto of every candidate.
+func (s *Server) runCampaign(bike objects.IMegaBike, agents []objects.IBaseBiker) map[uuid.UUID]objects.Manifesto {
+	candidacies := decideAll(s, agents, "DecideCandidacy", objects.IBaseBiker.DecideCandidacy, validCandidacy(idSet(agents)), func(objects.IBaseBiker) *objects.Manifesto {
+		return nil
+	})
+	manifestos := make(map[uuid.UUID]objects.Manifesto)
+	for i, candidate := range agents {
+		if candidacies[i] == nil {
+			continue
+		}
+		manifestos[candidate.GetID()] = cloneManifesto(*candidacies[i])
+		for _, recipient := range agents {
+			if recipient.GetID() == candidate.GetID() {
+				continue
+			}
+			msg := objects.ManifestoMessage{
+				BaseMessage: messaging.CreateMessage[objects.IBaseBiker](candidate, agents),
+				BikeId:      bike.GetID(),
+				Manifesto:   cloneManifesto(*candidacies[i]),
+			}
+			callAgentAction(s, recipient, "HandleMessage", func() {
+				msg.InvokeMessageHandler(recipient)
+			})
+		}
+	}
+	return manifestos
+}
+
+// a copy of a manifesto sharing nothing with it, so that it can't be changed behind the server's back
+func cloneManifesto(manifesto objects.Manifesto) objects.Manifesto {
+	clone := objects.Manifesto{
+		Weights:    maps.Clone(manifesto.Weights),
+		Allocation: maps.Clone(manifesto.Allocation),
+	}
+	if manifesto.Colour != nil {
+		colour := *manifesto.Colour
+		clone.Colour = &colour
+	}
+	return clone
+}
+
+// the bike an agent rules and the manifesto it was elected on, if it was elected on one
+func (s *Server) rulerManifesto(ruler objects.IBaseBiker) (objects.IMegaBike, objects.Manifesto, bool) {
+	bike, ok := s.megaBikes[ruler.GetBike()]
+	if !ok || bike.GetRuler() != ruler.GetID() {
+		return nil, objects.Manifesto{}, false
+	}
+	manifesto, ok := s.manifestos[bike.GetID()]
+	return bike, manifesto, ok
+}
+
+// publishes the ruler of a bike going back on one of the promises it was elected on
+func (s *Server) breakPromise(bike objects.IMegaBike, manifesto objects.Manifesto, promise string, event Event) {
+	event.Type = ManifestoBroken
+	event.BikeID = bike.GetID()
+	event.Governance = bike.GetGovernance()
+	event.AgentID = bike.GetRuler()
+	event.Manifesto = &manifesto
+	event.Cause = promise
+	s.publishEvent(event)
+}
+
+// checks the weights a ruler gave the votes of its riders against those it promised
+func (s *Server) checkWeightsPromise(ruler objects.IBaseBiker, weights map[uuid.UUID]float64) {
+	bike, manifesto, ok := s.rulerManifesto(ruler)
+	if !ok || len(manifesto.Weights) == 0 || sameShares(manifesto.Weights, weights) {
+		return
+	}
+	s.breakPromise(bike, manifesto, WeightsPromise, Event{Weights: maps.Clone(weights)})
+}
+
+// checks the loot split a ruler dictated against the one it promised
+func (s *Server) checkAllocationPromise(ruler objects.IBaseBiker, allocation map[uuid.UUID]float64) {
+	bike, manifesto, ok := s.rulerManifesto(ruler)
+	if !ok || len(manifesto.Allocation) == 0 || sameShares(manifesto.Allocation, allocation) {
+		return
+	}
+	s.breakPromise(bike, manifesto, AllocationPromise, Event{Allocation: maps.Clone(allocation)})
+}
+
+// checks the lootbox a ruler steered its bike to against the colour it promised (no lootbox breaking no promise)
+func (s *Server) checkColourPromise(ruler objects.IBaseBiker, direction uuid.UUID) {
+	bike, manifesto, ok := s.rulerManifesto(ruler)
+	lootbox, found := s.lootBoxes[direction]
+	if !ok || manifesto.Colour == nil || !found || lootbox.GetColour() == *manifesto.Colour {
+		return
+	}
+	s.breakPromise(bike, manifesto, ColourPromise, Event{LootBoxID: direction})
+}
+
+// whether a distribution gives the same share to each of its options as promised, the promise being rescaled to the
+// options still available (a promise about none of them can't be broken)
+func sameShares(promised map[uuid.UUID]float64, decided map[uuid.UUID]float64) bool {
+	options := utils.SortedIDs(decided)
+	promisedTotal, decidedTotal := 0.0, 0.0
+	for _, option := range options {
+		promisedTotal += promised[option]
+		decidedTotal += decided[option]
+	}
+	if promisedTotal == 0 {
+		return true
+	}
+	if decidedTotal == 0 {
+		return false
+	}
+	for _, option := range options {
+		if math.Abs(promised[option]/promisedTotal-decided[option]/decidedTotal) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
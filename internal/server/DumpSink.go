@@ -0,0 +1,105 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DumpSink receives the game dump of a run as it's produced, one game loop at a time, so that
+// the whole dump never has to be held in memory. Only one of the write methods is called in a
+// run, depending on its dump level.
+type DumpSink interface {
+	WriteIteration(iteration *SimplifiedIterationDump) error // called at the end of every game loop (simplified dumps)
+	WriteGameStates(gameStates []GameStateDump) error        // called at the end of every game loop (full dumps)
+	Close() error                                            // called once the run is over (or has failed)
+}
+
+// NDJSONSink writes each game loop as a line of JSON, flushing it straight away so that a run
+// that crashes still leaves the game loops it completed. Wrapping simplified lines in {"iteration": [...]}
+// gives the same document as a SimplifiedGameStateDump, while full lines are the game states
+// after each iteration of the game loop (read them back with ReadGameStateDumps).
+type NDJSONSink struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+func NewNDJSONSink(w io.Writer) *NDJSONSink {
+	writer := bufio.NewWriter(w)
+	sink := &NDJSONSink{
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}
+	if closer, ok := w.(io.Closer); ok {
+		sink.closer = closer
+	}
+	return sink
+}
+
+// CreateNDJSONSink streams the game dump to a new file at the given path (creating its directory
+// if it doesn't exist yet)
+func CreateNDJSONSink(path string) (*NDJSONSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating game dump directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating game dump file: %w", err)
+	}
+	return NewNDJSONSink(file), nil
+}
+
+func (sink *NDJSONSink) WriteIteration(iteration *SimplifiedIterationDump) error {
+	return sink.writeLine(iteration)
+}
+
+func (sink *NDJSONSink) WriteGameStates(gameStates []GameStateDump) error {
+	return sink.writeLine(gameStates)
+}
+
+func (sink *NDJSONSink) writeLine(value any) error {
+	// the encoder ends every value with a newline
+	if err := sink.encoder.Encode(value); err != nil {
+		return fmt.Errorf("encoding game dump: %w", err)
+	}
+	if err := sink.writer.Flush(); err != nil {
+		return fmt.Errorf("writing game dump: %w", err)
+	}
+	return nil
+}
+
+func (sink *NDJSONSink) Close() error {
+	err := sink.writer.Flush()
+	if sink.closer != nil {
+		if closeErr := sink.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("closing game dump: %w", err)
+	}
+	return nil
+}
+
+// ReadGameStateDumps reads back a full game dump written by an NDJSONSink, giving the game states
+// of each game loop (as taken by CalculateStatistics). A truncated last line, as left by a run
+// that crashed while writing, is ignored.
+func ReadGameStateDumps(r io.Reader) ([][]GameStateDump, error) {
+	gameStates := make([][]GameStateDump, 0)
+	decoder := json.NewDecoder(r)
+	for {
+		gameLoop := make([]GameStateDump, 0)
+		err := decoder.Decode(&gameLoop)
+		switch {
+		case err == io.EOF, err == io.ErrUnexpectedEOF:
+			return gameStates, nil
+		case err != nil:
+			return gameStates, fmt.Errorf("reading game loop %d of game dump: %w", len(gameStates), err)
+		}
+		gameStates = append(gameStates, gameLoop)
+	}
+}
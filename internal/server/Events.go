@@ -0,0 +1,313 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/common/voting"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+type EventType int
+
+const (
+	ElectionHeld         EventType = iota // a ruler was elected on a bike
+	AgentKicked                           // an agent was kicked off a bike
+	JoinAccepted                          // an agent was accepted onto the bike it asked to join
+	JoinRejected                          // an agent was refused by the bike it asked to join
+	DirectionChosen                       // a bike settled on the lootbox it heads for this iteration
+	LootAllocated                         // a bike split (its share of) a lootbox between its riders
+	AgentDied                             // an agent ran out of energy or was hit by the Awdi
+	AwdiCollision                         // the Awdi hit a bike
+	AgentMisbehaved                       // one of an agent's callbacks panicked or returned an invalid output
+	AmendmentPassed                       // the riders of a bike voted to switch it to another governance
+	AmendmentRejected                     // a motion to switch a bike to another governance fell short of the supermajority
+	NoConfidencePassed                    // the riders of a bike voted to remove its ruler
+	NoConfidenceRejected                  // a motion of no confidence in the ruler of a bike fell short of the majority needed
+	ManifestoBroken                       // the ruler of a bike went back on a promise it was elected on
+	ExpulsionPassed                       // the riders of a democracy voted to expel one of them
+	ExpulsionRejected                     // a motion to expel a rider of a democracy fell short of the threshold
+	NumEventTypes
+)
+
+func (e EventType) String() string {
+	switch e {
+	case ElectionHeld:
+		return "election_held"
+	case AgentKicked:
+		return "agent_kicked"
+	case JoinAccepted:
+		return "join_accepted"
+	case JoinRejected:
+		return "join_rejected"
+	case DirectionChosen:
+		return "direction_chosen"
+	case LootAllocated:
+		return "loot_allocated"
+	case AgentDied:
+		return "agent_died"
+	case AwdiCollision:
+		return "awdi_collision"
+	case AgentMisbehaved:
+		return "agent_misbehaved"
+	case AmendmentPassed:
+		return "amendment_passed"
+	case AmendmentRejected:
+		return "amendment_rejected"
+	case NoConfidencePassed:
+		return "no_confidence_passed"
+	case NoConfidenceRejected:
+		return "no_confidence_rejected"
+	case ManifestoBroken:
+		return "manifesto_broken"
+	case ExpulsionPassed:
+		return "expulsion_passed"
+	case ExpulsionRejected:
+		return "expulsion_rejected"
+	}
+	return "invalid"
+}
+
+func (e EventType) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *EventType) UnmarshalText(text []byte) error {
+	for eventType := ElectionHeld; eventType < NumEventTypes; eventType++ {
+		if eventType.String() == string(text) {
+			*e = eventType
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event type %q", text)
+}
+
+// causes of an AgentDied event
+const (
+	DiedOfExhaustion = "exhaustion"
+	DiedInCollision  = "awdi_collision"
+)
+
+// causes of an ElectionHeld event held while the ruler was still on the bike
+const (
+	TermEnded      = "term_ended"
+	LostConfidence = "no_confidence"
+)
+
+// causes of a ManifestoBroken event: the promise the ruler went back on
+const (
+	WeightsPromise    = "weights"
+	AllocationPromise = "allocation"
+	ColourPromise     = "colour"
+)
+
+// cause of a JoinRejected event for an agent refused without a vote, as it was kicked off the bike too recently
+const InCooldown = "rejoin_cooldown"
+
+// Event records an institutional decision (or one of its consequences) along with the reasons behind it.
+// Only the fields that apply to its type are set:
+//   - ElectionHeld: the bike, its governance, the elected ruler (AgentID), each rider's vote (under Leadership,
+//     Dictatorship and Council), the elected council (under Council, its chair being the ruler) and, if the ruler
+//     it replaced was still on the bike, the cause (TermEnded or LostConfidence). With nominations, the candidates
+//     and the manifesto the ruler was elected on
+//   - AgentKicked: the bike, its governance and the kicked agent
+//   - JoinAccepted/JoinRejected: the bike, its governance, the agent that asked to join and, if it was refused
+//     without a vote, the cause (InCooldown)
+//   - DirectionChosen: the bike, its governance, the lootbox, each rider's proposal and final vote (under
+//     Democracy and Leadership) with the weight of their vote, or the dictator (AgentID) under Dictatorship
+//   - LootAllocated: the bike, its governance, the lootbox, the resources the bike got from it, each rider's share
+//     and when the bike reached the lootbox (ImpactTime)
+//   - AgentDied: the agent, the bike it was on (if any) and the cause (DiedOfExhaustion or DiedInCollision)
+//   - AwdiCollision: the bike, the riders it had, the Awdi that hit it and when (ImpactTime)
+//   - AgentMisbehaved: the agent, the bike it's on (if any) and the offence (the callback and what went wrong)
+//   - AmendmentPassed/AmendmentRejected: the bike, the governance it had, the governance tabled (Motion), the riders
+//     that wanted it tabled (Riders) and each rider's ballot
+//   - NoConfidencePassed/NoConfidenceRejected: the bike, its governance, the ruler (AgentID) and the ballot of each
+//     of the other riders (true for no confidence)
+//   - ExpulsionPassed/ExpulsionRejected: the bike, its governance, the accused rider (AgentID), the riders that moved
+//     to expel it (Riders) and the ballot of each of the other riders
+//   - ManifestoBroken: the bike, its governance, the ruler (AgentID), its manifesto, the promise it broke (Cause) and
+//     what it did instead: the weights it gave, the allocation it dictated or the lootbox it dictated (LootBoxID)
+type Event struct {
+	Type       EventType                           `json:"type"`
+	GameLoop   int                                 `json:"game_loop"`
+	Iteration  int                                 `json:"iteration"` // -1 while the bikes are being founded
+	BikeID     uuid.UUID                           `json:"bike_id"`
+	Governance utils.Governance                    `json:"governance"`
+	AgentID    uuid.UUID                           `json:"agent_id"`
+	LootBoxID  uuid.UUID                           `json:"loot_box_id"`
+	AwdiID     uuid.UUID                           `json:"awdi_id"`
+	Motion     utils.Governance                    `json:"motion"`
+	Riders     []uuid.UUID                         `json:"riders,omitempty"`
+	Proposals  map[uuid.UUID]uuid.UUID             `json:"proposals,omitempty"`
+	Votes      map[uuid.UUID]map[uuid.UUID]float64 `json:"votes,omitempty"`
+	Ballots    map[uuid.UUID]bool                  `json:"ballots,omitempty"`
+	Council    []uuid.UUID                         `json:"council,omitempty"`
+	Candidates []uuid.UUID                         `json:"candidates,omitempty"`
+	Manifesto  *objects.Manifesto                  `json:"manifesto,omitempty"`
+	Weights    map[uuid.UUID]float64               `json:"weights,omitempty"`
+	Resources  float64                             `json:"resources,omitempty"`
+	Allocation map[uuid.UUID]float64               `json:"allocation,omitempty"`
+	Cause      string                              `json:"cause,omitempty"`
+	ImpactTime float64                             `json:"impact_time,omitempty"` // how far into the iteration the collision happened (0 to 1)
+}
+
+// EventSubscriber is handed every event published on the bus it's subscribed to, in the order they happen
+type EventSubscriber interface {
+	HandleEvent(event Event)
+}
+
+// EventSubscriberFunc lets a plain function subscribe to an event bus
+type EventSubscriberFunc func(event Event)
+
+func (f EventSubscriberFunc) HandleEvent(event Event) {
+	f(event)
+}
+
+// EventBus passes the events of a server on to its subscribers. Subscribers are called synchronously,
+// from the goroutine running the simulation, so they mustn't hold on to the maps of an event they change.
+type EventBus struct {
+	subscribers []EventSubscriber
+}
+
+func (bus *EventBus) Subscribe(subscriber EventSubscriber) {
+	bus.subscribers = append(bus.subscribers, subscriber)
+}
+
+func (bus *EventBus) Publish(event Event) {
+	for _, subscriber := range bus.subscribers {
+		subscriber.HandleEvent(event)
+	}
+}
+
+// EventRecorder keeps every event it's handed in memory (e.g. to be inspected in tests)
+type EventRecorder struct {
+	events []Event
+}
+
+func (recorder *EventRecorder) HandleEvent(event Event) {
+	recorder.events = append(recorder.events, event)
+}
+
+func (recorder *EventRecorder) Events() []Event {
+	return recorder.events
+}
+
+// OfType gives the recorded events of the given type, in the order they happened
+func (recorder *EventRecorder) OfType(eventType EventType) []Event {
+	events := make([]Event, 0)
+	for _, event := range recorder.events {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// EventCounter counts the events of each type (as included in the statistics report)
+type EventCounter struct {
+	counts map[string]int
+}
+
+func NewEventCounter() *EventCounter {
+	counter := &EventCounter{counts: make(map[string]int)}
+	for eventType := ElectionHeld; eventType < NumEventTypes; eventType++ {
+		counter.counts[eventType.String()] = 0
+	}
+	return counter
+}
+
+func (counter *EventCounter) HandleEvent(event Event) {
+	counter.counts[event.Type.String()]++
+}
+
+func (counter *EventCounter) Counts() map[string]int {
+	return counter.counts
+}
+
+// EventWriter writes each event as a line of JSON. As events can't fail to be handled, the first error
+// met is kept (and no more events are written) until the writer is closed.
+type EventWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	closer  io.Closer
+	err     error
+}
+
+func NewEventWriter(w io.Writer) *EventWriter {
+	writer := bufio.NewWriter(w)
+	eventWriter := &EventWriter{
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}
+	if closer, ok := w.(io.Closer); ok {
+		eventWriter.closer = closer
+	}
+	return eventWriter
+}
+
+func (ew *EventWriter) HandleEvent(event Event) {
+	if ew.err != nil {
+		return
+	}
+	if err := ew.encoder.Encode(event); err != nil {
+		ew.err = fmt.Errorf("writing event log: %w", err)
+	}
+}
+
+// Close flushes the events still buffered, returning the first error met while writing them
+func (ew *EventWriter) Close() error {
+	err := ew.err
+	if flushErr := ew.writer.Flush(); err == nil && flushErr != nil {
+		err = fmt.Errorf("writing event log: %w", flushErr)
+	}
+	if ew.closer != nil {
+		if closeErr := ew.closer.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("closing event log: %w", closeErr)
+		}
+	}
+	return err
+}
+
+// creates the file an event log is written to (and its directory, if needed)
+func createEventWriter(path string) (*EventWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating event log directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating event log: %w", err)
+	}
+	return NewEventWriter(file), nil
+}
+
+// publishes an event stamped with the current game loop and iteration
+func (s *Server) publishEvent(event Event) {
+	event.GameLoop = s.gameLoop
+	event.Iteration = s.iteration
+	s.events.Publish(event)
+}
+
+func riderIDs(agents []objects.IBaseBiker) []uuid.UUID {
+	ids := make([]uuid.UUID, len(agents))
+	for i, agent := range agents {
+		ids[i] = agent.GetID()
+	}
+	return ids
+}
+
+// copies the votes cast into a plain map (voter -> candidate -> score)
+func votesOf[V voting.IVoter](votes map[uuid.UUID]V) map[uuid.UUID]map[uuid.UUID]float64 {
+	copied := make(map[uuid.UUID]map[uuid.UUID]float64, len(votes))
+	for voter, vote := range votes {
+		copied[voter] = maps.Clone(vote.GetVotes())
+	}
+	return copied
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+
+	"github.com/google/uuid"
+)
+
+/*
+Expulsion from bikes without a ruler (Democracy): riders move to expel each other, and the motions (once seconded, with
+governance.expulsion_seconding) are put to the vote of the other riders
+*/
+
+// holds the motions to expel the riders of a democracy, giving the riders that are to be kicked off. A rider moves to
+// expel every other rider it votes to kick out (VoteForKickout), and every rider but the accused one votes on each
+// motion (VoteExpulsion), which passes if at least governance.expulsion_threshold of them vote for it. The motions are
+// taken in ID order of the accused riders, those expelled already neither tabling nor voting on the next ones.
+func (s *Server) expulsionMotions(bike objects.IMegaBike) []uuid.UUID {
+	agents := bike.GetAgents()
+	onBike := idSet(agents)
+	motions := byAgent(agents, decideAll(s, agents, "VoteForKickout", objects.IBaseBiker.VoteForKickout, nil, func(objects.IBaseBiker) map[uuid.UUID]int {
+		return make(map[uuid.UUID]int)
+	}))
+	movers := make(map[uuid.UUID][]uuid.UUID)
+	for _, agent := range agents {
+		for _, accused := range utils.SortedIDs(motions[agent.GetID()]) {
+			if motions[agent.GetID()][accused] > 0 && onBike[accused] && accused != agent.GetID() {
+				movers[accused] = append(movers[accused], agent.GetID())
+			}
+		}
+	}
+
+	expelled := make([]uuid.UUID, 0)
+	gone := make(map[uuid.UUID]bool)
+	for _, accused := range utils.SortedIDs(movers) {
+		backers := make([]uuid.UUID, 0, len(movers[accused]))
+		for _, mover := range movers[accused] {
+			if !gone[mover] {
+				backers = append(backers, mover)
+			}
+		}
+		// a motion nobody seconds lapses without a vote
+		if len(backers) == 0 || (s.cfg.Governance.ExpulsionSeconding && len(backers) < 2) {
+			continue
+		}
+		voters := make([]objects.IBaseBiker, 0, len(agents))
+		for _, agent := range agents {
+			if agent.GetID() != accused && !gone[agent.GetID()] {
+				voters = append(voters, agent)
+			}
+		}
+		ballots := byAgent(voters, decideAll(s, voters, "VoteExpulsion", func(agent objects.IBaseBiker) bool {
+			return agent.VoteExpulsion(accused)
+		}, nil, func(objects.IBaseBiker) bool {
+			return false
+		}))
+		ayes := 0
+		for _, ballot := range ballots {
+			if ballot {
+				ayes++
+			}
+		}
+		event := Event{
+			Type:       ExpulsionRejected,
+			BikeID:     bike.GetID(),
+			Governance: bike.GetGovernance(),
+			AgentID:    accused,
+			Riders:     backers,
+			Ballots:    ballots,
+		}
+		if float64(ayes)/float64(len(voters)) >= s.cfg.Governance.ExpulsionThreshold {
+			event.Type = ExpulsionPassed
+			expelled = append(expelled, accused)
+			gone[accused] = true
+		}
+		s.publishEvent(event)
+	}
+	bike.UpdateKickedOutCount(len(expelled))
+	return expelled
+}
+
+// whether an agent kicked off a bike must still wait before it may rejoin it (governance.rejoin_cooldown)
+func (s *Server) coolingDown(agentID uuid.UUID, bikeID uuid.UUID) bool {
+	kickedOff, ok := s.kickedOff[bikeID][agentID]
+	return ok && s.iteration-kickedOff < s.cfg.Governance.RejoinCooldown
+}
+
+// records an agent being kicked off a bike, which it may only rejoin once the cooldown is over
+func (s *Server) recordKickOff(agentID uuid.UUID, bikeID uuid.UUID) {
+	if _, ok := s.kickedOff[bikeID]; !ok {
+		s.kickedOff[bikeID] = make(map[uuid.UUID]int)
+	}
+	s.kickedOff[bikeID][agentID] = s.iteration
+}
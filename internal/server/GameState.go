@@ -2,7 +2,7 @@ package server
 
 import (
 	"SOMAS2023/internal/common/objects"
-	"math/rand"
+	"SOMAS2023/internal/common/utils"
 	"slices"
 
 	"github.com/google/uuid"
@@ -17,7 +17,11 @@ func (s *Server) GetLootBoxes() map[uuid.UUID]objects.ILootBox {
 }
 
 func (s *Server) GetAwdi() objects.IAwdi {
-	return s.awdi
+	return s.awdis[0]
+}
+
+func (s *Server) GetAwdis() []objects.IAwdi {
+	return slices.Clone(s.awdis)
 }
 
 // get a map of megaBikeIDs mapping to the ids of all Bikers that are trying to join it
@@ -25,7 +29,9 @@ func (s *Server) GetJoiningRequests(inLimbo []uuid.UUID) map[uuid.UUID][]uuid.UU
 	// iterate over all agents, if their onBike is false add to the map their id in correspondance of that of their desired bike
 	bikeRequests := make(map[uuid.UUID][]uuid.UUID)
 
-	for agentID, agent := range s.GetAgentMap() {
+	agents := s.GetAgentMap()
+	for _, agentID := range utils.SortedIDs(agents) {
+		agent := agents[agentID]
 		// don't process joining requests of agents in first round of limbo (ie the ones that have just left the bike)
 		if !agent.GetBikeStatus() && !slices.Contains(inLimbo, agentID) {
 			bike := agent.GetBike()
@@ -44,13 +50,29 @@ func (s *Server) GetJoiningRequests(inLimbo []uuid.UUID) map[uuid.UUID][]uuid.UU
 
 // GetRandomBikeId returns the ID of a random bike.
 func (s *Server) GetRandomBikeId() uuid.UUID {
-	i, targetI := 0, rand.Intn(len(s.GetMegaBikes()))
-	// Go doesn't have a sensible way to do this...
-	for id := range s.GetMegaBikes() {
-		if i == targetI {
-			return id
-		}
-		i++
+	bikeID := utils.PickRandomID(s.rng, s.GetMegaBikes())
+	if bikeID == uuid.Nil {
+		panic("no bikes")
+	}
+	return bikeID
+}
+
+// returns all the agents in ID order, so that the order in which they're asked to act
+// is the same in every replay of a seeded run
+func (s *Server) sortedAgents() []objects.IBaseBiker {
+	agentMap := s.GetAgentMap()
+	agents := make([]objects.IBaseBiker, 0, len(agentMap))
+	for _, id := range utils.SortedIDs(agentMap) {
+		agents = append(agents, agentMap[id])
+	}
+	return agents
+}
+
+// returns all the mega bikes in ID order (see sortedAgents)
+func (s *Server) sortedMegaBikes() []objects.IMegaBike {
+	bikes := make([]objects.IMegaBike, 0, len(s.megaBikes))
+	for _, id := range utils.SortedIDs(s.megaBikes) {
+		bikes = append(bikes, s.megaBikes[id])
 	}
-	panic("no bikes")
+	return bikes
 }
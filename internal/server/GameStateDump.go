@@ -3,8 +3,10 @@ package server
 import (
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/utils"
+	"fmt"
 	"maps"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/google/uuid"
@@ -16,13 +18,15 @@ type GameStateDump struct {
 	Bikes     map[uuid.UUID]BikeDump    `json:"bikes"`
 	LootBoxes map[uuid.UUID]LootBoxDump `json:"loot_boxes"`
 	Awdis     []AwdiDump                `json:"awdis"`
+	Events    []BikeEventDump           `json:"events"` // agents joining, leaving or kicked off bikes since the previous dump
 }
 
 type PhysicsObjectDump struct {
-	ID            uuid.UUID           `json:"-"`
-	PhysicalState utils.PhysicalState `json:"physical_state"`
-	Orientation   float64             `json:"orientation"`
-	Force         float64             `json:"force"`
+	ID               uuid.UUID           `json:"-"`
+	PhysicalState    utils.PhysicalState `json:"physical_state"`
+	PreviousPosition utils.Coordinates   `json:"previous_position"` // where the object set off from in its last move
+	Orientation      float64             `json:"orientation"`
+	Force            float64             `json:"force"`
 }
 
 type BikeDump struct {
@@ -31,6 +35,24 @@ type BikeDump struct {
 	AgentIDs   []uuid.UUID      `json:"agent_ids"`
 	Governance utils.Governance `json:"governance"`
 	Ruler      uuid.UUID        `json:"ruler"`
+	Council    []uuid.UUID      `json:"council,omitempty"` // the members of the council running the bike (under Council)
+	Rules      []RuleDump       `json:"rules"`
+	Regimes    []RegimeDump     `json:"regimes,omitempty"` // the governances the bike has had this round, in order
+}
+
+// RegimeDump records a bike taking on a governance, when founded (at iteration -1) or through an amendment
+type RegimeDump struct {
+	Iteration  int              `json:"iteration"`
+	Governance utils.Governance `json:"governance"`
+}
+
+type RuleDump struct {
+	ID          uuid.UUID               `json:"id"`
+	Name        string                  `json:"name"`
+	Action      objects.Action          `json:"action"`
+	Inputs      objects.RuleInputs      `json:"inputs"`
+	Matrix      objects.RuleMatrix      `json:"matrix"`
+	Comparators objects.RuleComparators `json:"comparators"`
 }
 
 type AgentDump struct {
@@ -59,14 +81,58 @@ type AwdiDump struct {
 	PhysicsObjectDump
 	ID         uuid.UUID `json:"id"`
 	TargetBike uuid.UUID `json:"target_bike"`
+	Strategy   string    `json:"strategy"`
+}
+
+type BikeEventType int
+
+const (
+	JoinedBike BikeEventType = iota
+	LeftBike
+	KickedOffBike
+)
+
+func (e BikeEventType) String() string {
+	switch e {
+	case JoinedBike:
+		return "joined"
+	case LeftBike:
+		return "left"
+	case KickedOffBike:
+		return "kicked"
+	default:
+		return "unknown"
+	}
+}
+
+// events are written by name in the game dump
+func (e BikeEventType) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *BikeEventType) UnmarshalText(text []byte) error {
+	for eventType := JoinedBike; eventType <= KickedOffBike; eventType++ {
+		if eventType.String() == string(text) {
+			*e = eventType
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown bike event %q", string(text))
+}
+
+type BikeEventDump struct {
+	Type    BikeEventType `json:"type"`
+	AgentID uuid.UUID     `json:"agent_id"`
+	BikeID  uuid.UUID     `json:"bike_id"`
 }
 
 func newPhysicsObjectDump(physicsObject objects.IPhysicsObject) PhysicsObjectDump {
 	return PhysicsObjectDump{
-		ID:            physicsObject.GetID(),
-		PhysicalState: physicsObject.GetPhysicalState(),
-		Orientation:   physicsObject.GetOrientation(),
-		Force:         physicsObject.GetForce(),
+		ID:               physicsObject.GetID(),
+		PhysicalState:    physicsObject.GetPhysicalState(),
+		PreviousPosition: physicsObject.GetPreviousPosition(),
+		Orientation:      physicsObject.GetOrientation(),
+		Force:            physicsObject.GetForce(),
 	}
 }
 
@@ -112,6 +178,9 @@ func (s *Server) NewGameStateDump(iteration int) GameStateDump {
 			AgentIDs:          agentIDs,
 			Governance:        bike.GetGovernance(),
 			Ruler:             bike.GetRuler(),
+			Council:           slices.Clone(s.councils[id]),
+			Rules:             newRuleDumps(bike.ViewLocalRuleMap()),
+			Regimes:           slices.Clone(s.regimes[id]),
 		}
 	}
 
@@ -125,15 +194,52 @@ func (s *Server) NewGameStateDump(iteration int) GameStateDump {
 		}
 	}
 
+	awdis := make([]AwdiDump, 0, len(s.awdis))
+	for _, awdi := range s.awdis {
+		awdis = append(awdis, AwdiDump{
+			PhysicsObjectDump: newPhysicsObjectDump(awdi),
+			ID:                awdi.GetID(),
+			TargetBike:        awdi.GetTargetID(),
+			Strategy:          awdi.GetStrategyName(),
+		})
+	}
+
 	return GameStateDump{
 		Iteration: iteration,
 		Agents:    agents,
 		Bikes:     bikes,
 		LootBoxes: lootBoxes,
-		Awdis: []AwdiDump{{
-			PhysicsObjectDump: newPhysicsObjectDump(s.awdi),
-			ID:                s.awdi.GetID(),
-			TargetBike:        s.awdi.GetTargetID(),
-		}},
+		Awdis:     awdis,
+		Events:    slices.Clone(s.bikeEvents),
+	}
+}
+
+// lists the rules active on a bike, by action
+func newRuleDumps(ruleMap map[objects.Action][]*objects.Rule) []RuleDump {
+	rules := make([]RuleDump, 0)
+	for action := objects.Action(0); action < objects.MAX_ACTIONS; action++ {
+		for _, rule := range ruleMap[action] {
+			// the dump gets its own copy, as agents are shown it
+			rule = rule.Copy()
+			rules = append(rules, RuleDump{
+				ID:          rule.GetRuleID(),
+				Name:        rule.GetRuleName(),
+				Action:      rule.GetRuleAction(),
+				Inputs:      rule.GetRuleInputs(),
+				Matrix:      rule.GetRuleMatrix(),
+				Comparators: rule.GetRuleComparators(),
+			})
+		}
 	}
+	return rules
+}
+
+// records a bike taking on its current governance, for the game state dumps of the round
+func (s *Server) recordRegime(bike objects.IMegaBike) {
+	s.regimes[bike.GetID()] = append(s.regimes[bike.GetID()], RegimeDump{Iteration: s.iteration, Governance: bike.GetGovernance()})
+}
+
+// records an agent joining, leaving or being kicked off a bike, for the next game state dump
+func (s *Server) recordBikeEvent(eventType BikeEventType, agentID uuid.UUID, bikeID uuid.UUID) {
+	s.bikeEvents = append(s.bikeEvents, BikeEventDump{Type: eventType, AgentID: agentID, BikeID: bikeID})
 }
@@ -19,6 +19,14 @@ func (o PhysicsObjectDump) SetPhysicalState(state utils.PhysicalState) {
 	panic(bannedFunctionErrorMessage)
 }
 
+func (o PhysicsObjectDump) SetOrientation(float64) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (o PhysicsObjectDump) MoveTo(utils.PhysicalState) {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (o PhysicsObjectDump) UpdateForce() {
 	panic(bannedFunctionErrorMessage)
 }
@@ -31,6 +39,10 @@ func (o PhysicsObjectDump) CheckForCollision(objects.IPhysicsObject) bool {
 	panic(bannedFunctionErrorMessage)
 }
 
+func (o PhysicsObjectDump) CheckForSweptCollision(objects.IPhysicsObject) (float64, bool) {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (a AgentDump) GetAllMessages([]objects.IBaseBiker) []messaging.IMessage[objects.IBaseBiker] {
 	panic(bannedFunctionErrorMessage)
 }
@@ -95,6 +107,22 @@ func (a AgentDump) VoteLeader() voting.IdVoteMap {
 	panic(bannedFunctionErrorMessage)
 }
 
+func (a AgentDump) VoteAmendment(proposed utils.Governance) bool {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) VoteNoConfidence() bool {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) DecideCandidacy() *objects.Manifesto {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) VoteExpulsion(accused uuid.UUID) bool {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (a AgentDump) DictateDirection() uuid.UUID {
 	panic(bannedFunctionErrorMessage)
 }
@@ -181,6 +209,10 @@ func (a AgentDump) HandleVoteAllocationMessage(msg objects.VoteAllocationMessage
 	panic(bannedFunctionErrorMessage)
 }
 
+func (a AgentDump) HandleManifestoMessage(msg objects.ManifestoMessage) {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (a AgentDump) DecideDictatorAllocation() voting.IdVoteMap {
 	panic(bannedFunctionErrorMessage)
 }
@@ -265,6 +297,14 @@ func (b BikeDump) GetKickedOutCount() int {
 	panic(bannedFunctionErrorMessage)
 }
 
+func (b BikeDump) UpdateKickedOutCount(int) {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (b BikeDump) ResetKickedOutCount() {
 	panic(bannedFunctionErrorMessage)
 }
+
+func (v *GameStateView) AddToGlobalRuleCache(*objects.Rule) {
+	panic(bannedFunctionErrorMessage)
+}
@@ -38,6 +38,14 @@ func (gs GameStateDump) GetAwdi() objects.IAwdi {
 	return gs.Awdis[0]
 }
 
+func (gs GameStateDump) GetAwdis() []objects.IAwdi {
+	result := make([]objects.IAwdi, 0, len(gs.Awdis))
+	for _, a := range gs.Awdis {
+		result = append(result, a)
+	}
+	return result
+}
+
 func (o PhysicsObjectDump) GetID() uuid.UUID {
 	return o.ID
 }
@@ -62,6 +70,10 @@ func (o PhysicsObjectDump) GetPhysicalState() utils.PhysicalState {
 	return o.PhysicalState
 }
 
+func (o PhysicsObjectDump) GetPreviousPosition() utils.Coordinates {
+	return o.PreviousPosition
+}
+
 func (a AgentDump) GetID() uuid.UUID {
 	return a.ID
 }
@@ -126,5 +138,9 @@ func (a AwdiDump) GetTargetID() uuid.UUID {
 	return a.TargetBike
 }
 
+func (a AwdiDump) GetStrategyName() string {
+	return a.Strategy
+}
+
 func (a AwdiDump) InjectGameState(gs objects.IGameState) {
 }
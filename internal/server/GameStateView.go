@@ -0,0 +1,67 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"maps"
+
+	"github.com/google/uuid"
+)
+
+// GameStateView is the game state agents are given: a read-only snapshot of the world (bikes, agents and
+// lootboxes are dumps, whose mutators are banned), which the server refreshes before every phase in which
+// agents make decisions. Agents keep hold of the same view for the whole run, so it's refreshed in place.
+type GameStateView struct {
+	GameStateDump
+	config      config.Config
+	globalRules map[uuid.UUID]*objects.Rule
+	// the dumps as the interfaces agents are handed, built once per refresh (agents are given clones
+	// of these maps, which are much cheaper to make than building them again on every call)
+	lootBoxes map[uuid.UUID]objects.ILootBox
+	megaBikes map[uuid.UUID]objects.IMegaBike
+	agents    map[uuid.UUID]objects.IBaseBiker
+	index     spatialIndex // the server's spatial index, as it was when the view was refreshed
+}
+
+// refreshes the view agents have of the game, so that it reflects the current state of the world
+func (s *Server) refreshGameStateView() {
+	gameState := s.NewGameStateDump(s.iteration)
+	*s.view = GameStateView{
+		GameStateDump: gameState,
+		config:        *s.cfg,
+		globalRules:   s.globalRuleCache.ViewGlobalRuleSet(),
+		lootBoxes:     gameState.GetLootBoxes(),
+		megaBikes:     gameState.GetMegaBikes(),
+		agents:        gameState.GetAgents(),
+		index:         s.index.clone(),
+	}
+}
+
+func (v *GameStateView) GetLootBoxes() map[uuid.UUID]objects.ILootBox {
+	return maps.Clone(v.lootBoxes)
+}
+
+func (v *GameStateView) GetMegaBikes() map[uuid.UUID]objects.IMegaBike {
+	return maps.Clone(v.megaBikes)
+}
+
+func (v *GameStateView) GetAgents() map[uuid.UUID]objects.IBaseBiker {
+	return maps.Clone(v.agents)
+}
+
+func (v *GameStateView) GetAgentMap() map[uuid.UUID]objects.IBaseBiker {
+	return v.GetAgents()
+}
+
+func (v *GameStateView) GetConfig() config.Config {
+	return v.config
+}
+
+// agents are given copies of the rules, so that they can't alter the ones in play
+func (v *GameStateView) ViewGlobalRuleCache() map[uuid.UUID]*objects.Rule {
+	rules := make(map[uuid.UUID]*objects.Rule, len(v.globalRules))
+	for id, rule := range v.globalRules {
+		rules[id] = rule.Copy()
+	}
+	return rules
+}
@@ -4,32 +4,125 @@ import (
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/common/voting"
+	"errors"
+	"maps"
 
 	"github.com/google/uuid"
 )
 
 // obtain direction for current round from the dictator
 func (s *Server) RunRulerAction(bike objects.IMegaBike) uuid.UUID {
-	agents := s.GetAgentMap()
-	ruler := agents[bike.GetRuler()]
+	// a bike whose ruler is gone (dead, kicked off or never elected) has no direction
+	ruler, ok := s.GetAgentMap()[bike.GetRuler()]
+	if !ok {
+		return uuid.Nil
+	}
 	// get dictators direction choice
-	direction := ruler.DictateDirection()
+	direction := callAgent(s, ruler, "DictateDirection", ruler.DictateDirection, validChoice(s.lootBoxes), func() uuid.UUID {
+		return uuid.Nil
+	})
+	s.checkColourPromise(ruler, direction)
 	return direction
 }
 
 // elect ruler (happens during the foundation stage, or when a bike with ruler-lead
 // governance is left without ruler for any of various reasons)
 func (s *Server) RulerElection(agents []objects.IBaseBiker, governance utils.Governance) uuid.UUID {
-	votes := make(map[uuid.UUID]voting.IdVoteMap, len(agents))
+	s.refreshGameStateView()
+	ruler, _ := s.rulerElection(agents, governance, nil)
+	return ruler
+}
+
+// the term the ruler of a bike is serving
+type rulerTerm struct {
+	ruler  uuid.UUID
+	start  int // the iteration the ruler was (re-)elected in
+	served int // how many terms in a row the ruler has been elected for, this one included
+}
+
+// elects the ruler of a bike from its riders, publishing the outcome
+func (s *Server) holdRulerElection(bike objects.IMegaBike, governance utils.Governance) {
+	s.callRulerElection(bike, governance, "")
+}
+
+// elects the ruler of a bike, giving why the election was called if its ruler is still on the bike (TermEnded or
+// LostConfidence). A ruler that lost the confidence of its riders, or served as many terms in a row as it may
+// (governance.term_limit), can't be re-elected unless nobody else can rule. With governance.nominations, only the
+// riders that stand for ruler can be elected (or drawn by lot), unless none of them does.
+func (s *Server) callRulerElection(bike objects.IMegaBike, governance utils.Governance, cause string) {
+	agents := bike.GetAgents()
+	term := s.terms[bike.GetID()]
+	barred := make(map[uuid.UUID]bool)
+	termLimit := s.cfg.Governance.TermLimit
+	if term.ruler == bike.GetRuler() && (cause == LostConfidence || (termLimit > 0 && term.served >= termLimit)) {
+		barred[term.ruler] = true
+	}
+	var ruler uuid.UUID
+	var votes map[uuid.UUID]voting.IdVoteMap
+	var council []uuid.UUID
+	// the newer regimes pick their ruler their own way, whichever election was called
+	if regime := bike.GetGovernance(); regime == utils.Council || regime == utils.Sortition || regime == utils.Rotation {
+		governance = regime
+	}
+	// riders take turns under Rotation, so nobody campaigns
+	var manifestos map[uuid.UUID]objects.Manifesto
+	if s.cfg.Governance.Nominations && governance != utils.Rotation {
+		manifestos = s.runCampaign(bike, agents)
+		for _, agent := range agents {
+			if _, standing := manifestos[agent.GetID()]; !standing && len(manifestos) != 0 {
+				barred[agent.GetID()] = true
+			}
+		}
+	}
+	switch governance {
+	case utils.Council:
+		council, votes = s.councilElection(agents, barred)
+		s.councils[bike.GetID()] = council
+		if len(council) > 0 {
+			ruler = council[0]
+		}
+	case utils.Sortition:
+		ruler = s.drawLot(agents, barred)
+	case utils.Rotation:
+		ruler = nextInRotation(agents, bike.GetRuler(), barred)
+	default:
+		ruler, votes = s.rulerElection(agents, governance, barred)
+	}
+	bike.SetRuler(ruler)
+	if ruler == term.ruler {
+		term.served++
+	} else {
+		term = rulerTerm{ruler: ruler, served: 1}
+	}
+	term.start = s.iteration
+	s.terms[bike.GetID()] = term
+	event := Event{
+		Type:       ElectionHeld,
+		BikeID:     bike.GetID(),
+		Governance: governance,
+		AgentID:    ruler,
+		Riders:     riderIDs(agents),
+		Votes:      votesOf(votes),
+		Council:    council,
+		Cause:      cause,
+	}
+	if len(manifestos) != 0 {
+		event.Candidates = utils.SortedIDs(manifestos)
+	}
+	delete(s.manifestos, bike.GetID())
+	if manifesto, ok := manifestos[ruler]; ok {
+		s.manifestos[bike.GetID()] = manifesto
+		event.Manifesto = &manifesto
+	}
+	s.publishEvent(event)
+}
+
+// runs a ruler election, returning the ruler along with the vote of each agent (as counted, without the barred candidates)
+func (s *Server) rulerElection(agents []objects.IBaseBiker, governance utils.Governance, barred map[uuid.UUID]bool) (uuid.UUID, map[uuid.UUID]voting.IdVoteMap) {
+	votes := s.rulerVotes(agents, governance, barred)
 	voteWeight := make(map[uuid.UUID]float64)
 	for _, agent := range agents {
 		voteWeight[agent.GetID()] = 1
-		switch governance {
-		case utils.Dictatorship:
-			votes[agent.GetID()] = agent.VoteDictator()
-		case utils.Leadership:
-			votes[agent.GetID()] = agent.VoteLeader()
-		}
 	}
 
 	// required as a list of interfaces that implement IVoter is not percieved as a list of IVoters due to Go weirdness
@@ -38,8 +131,211 @@ func (s *Server) RulerElection(agents []objects.IBaseBiker, governance utils.Gov
 		IVotes[i] = vote
 	}
 
-	ruler := voting.WinnerFromDist(IVotes, voteWeight)
-	return ruler
+	// votes that can't be counted elect nobody
+	ruler, err := voting.WinnerFromDist(IVotes, voteWeight, s.cfg.Voting.Method)
+	if err != nil {
+		return uuid.Nil, votes
+	}
+	return ruler, votes
+}
+
+// collects the votes of the riders of a bike for its ruler (council members being voted for as leaders), votes
+// for barred candidates being struck out
+func (s *Server) rulerVotes(agents []objects.IBaseBiker, governance utils.Governance, barred map[uuid.UUID]bool) map[uuid.UUID]voting.IdVoteMap {
+	votes := make(map[uuid.UUID]voting.IdVoteMap, len(agents))
+	// votes can only go to the candidates (the riders of the bike), an even vote being cast for an agent that misbehaves
+	validVote := validDistribution[voting.IdVoteMap](idSet(agents))
+	evenVote := func(objects.IBaseBiker) voting.IdVoteMap {
+		return evenSplit[voting.IdVoteMap](riderIDs(agents), 1.0)
+	}
+	switch governance {
+	case utils.Dictatorship:
+		votes = byAgent(agents, decideAll(s, agents, "VoteDictator", objects.IBaseBiker.VoteDictator, validVote, evenVote))
+	case utils.Leadership, utils.Council:
+		votes = byAgent(agents, decideAll(s, agents, "VoteLeader", objects.IBaseBiker.VoteLeader, validVote, evenVote))
+	}
+
+	// a voter left without a vote once the barred candidates are struck out is given an even one for the others
+	eligible := make([]uuid.UUID, 0, len(agents))
+	for _, agent := range agents {
+		if !barred[agent.GetID()] {
+			eligible = append(eligible, agent.GetID())
+		}
+	}
+	if len(eligible) > 0 && len(eligible) < len(agents) {
+		for voter, vote := range votes {
+			counted := make(voting.IdVoteMap, len(vote))
+			total := 0.0
+			for candidate, share := range vote {
+				if !barred[candidate] {
+					counted[candidate] = share
+					total += share
+				}
+			}
+			if total == 0 {
+				counted = evenSplit[voting.IdVoteMap](eligible, 1.0)
+			}
+			votes[voter] = counted
+		}
+	}
+	return votes
+}
+
+// holds the constitutional amendment phase: on each bike, the governance most wanted by its riders (other than
+// the one it has) is tabled and put to them, the bike switching to it if a supermajority of them votes for it
+func (s *Server) RunAmendments() {
+	s.refreshGameStateView()
+	for _, bike := range s.sortedMegaBikes() {
+		riders := make([]objects.IBaseBiker, 0)
+		for _, agent := range bike.GetAgents() {
+			if agent.GetBikeStatus() {
+				riders = append(riders, agent)
+			}
+		}
+		if len(riders) == 0 {
+			continue
+		}
+		current := bike.GetGovernance()
+		motion, movers := s.tableAmendment(riders, current)
+		if len(movers) == 0 {
+			continue
+		}
+
+		ballots := byAgent(riders, decideAll(s, riders, "VoteAmendment", func(agent objects.IBaseBiker) bool {
+			return agent.VoteAmendment(motion)
+		}, nil, func(objects.IBaseBiker) bool {
+			return false
+		}))
+		ayes := 0
+		for _, ballot := range ballots {
+			if ballot {
+				ayes++
+			}
+		}
+		event := Event{
+			Type:       AmendmentRejected,
+			BikeID:     bike.GetID(),
+			Governance: current,
+			Motion:     motion,
+			Riders:     movers,
+			Ballots:    ballots,
+		}
+		if float64(ayes)/float64(len(riders)) >= s.cfg.Governance.AmendmentSupermajority {
+			event.Type = AmendmentPassed
+		}
+		s.publishEvent(event)
+		if event.Type == AmendmentPassed {
+			s.changeGovernance(bike, motion)
+		}
+	}
+}
+
+// picks the governance the most riders would rather have than the current one (the first one breaking ties),
+// along with the riders that want it tabled (none if every rider is happy with the current governance)
+func (s *Server) tableAmendment(riders []objects.IBaseBiker, current utils.Governance) (utils.Governance, []uuid.UUID) {
+	preferences := decideAll(s, riders, "DecideGovernance", objects.IBaseBiker.DecideGovernance, validGovernance, func(objects.IBaseBiker) utils.Governance {
+		return current
+	})
+	movers := make(map[utils.Governance][]uuid.UUID)
+	for i, governance := range preferences {
+		if governance != current {
+			movers[governance] = append(movers[governance], riders[i].GetID())
+		}
+	}
+	motion := current
+	for governance := utils.Democracy; governance < utils.Invalid; governance++ {
+		if len(movers[governance]) > len(movers[motion]) {
+			motion = governance
+		}
+	}
+	return motion, movers[motion]
+}
+
+// holds the no-confidence votes of the riders of ruler-led bikes (governance.no_confidence_majority), then the
+// elections of the rulers whose term is over (governance.term_length, or the period of sortitions and rotations)
+func (s *Server) CheckRulerMandates() {
+	s.refreshGameStateView()
+	for _, bike := range s.sortedMegaBikes() {
+		governance := bike.GetGovernance()
+		if !rulerLed(governance) || bike.GetRuler() == uuid.Nil || len(bike.GetAgents()) == 0 {
+			continue
+		}
+		termLength := s.termLength(governance)
+		switch {
+		case s.cfg.Governance.NoConfidenceMajority > 0 && s.noConfidenceVote(bike):
+			s.callRulerElection(bike, governance, LostConfidence)
+		case termLength > 0 && s.iteration-s.terms[bike.GetID()].start >= termLength:
+			s.callRulerElection(bike, governance, TermEnded)
+		}
+	}
+}
+
+// how many iterations the ruler of a bike serves before it's up for re-election (or replaced, under Sortition and
+// Rotation), 0 meaning for as long as it stays on the bike
+func (s *Server) termLength(governance utils.Governance) int {
+	switch governance {
+	case utils.Sortition:
+		return s.cfg.Governance.SortitionPeriod
+	case utils.Rotation:
+		return s.cfg.Governance.RotationPeriod
+	default:
+		return s.cfg.Governance.TermLength
+	}
+}
+
+// whether a bike with the given governance is run by a ruler (every governance but Democracy)
+func rulerLed(governance utils.Governance) bool {
+	return governance != utils.Democracy
+}
+
+// puts the ruler of a bike to a vote of no confidence if any of the other riders has lost confidence in it,
+// reporting whether enough of them did for the ruler to be removed
+func (s *Server) noConfidenceVote(bike objects.IMegaBike) bool {
+	voters := make([]objects.IBaseBiker, 0)
+	for _, agent := range bike.GetAgents() {
+		if agent.GetBikeStatus() && agent.GetID() != bike.GetRuler() {
+			voters = append(voters, agent)
+		}
+	}
+	ballots := byAgent(voters, decideAll(s, voters, "VoteNoConfidence", objects.IBaseBiker.VoteNoConfidence, nil, func(objects.IBaseBiker) bool {
+		return false
+	}))
+	noes := 0
+	for _, ballot := range ballots {
+		if ballot {
+			noes++
+		}
+	}
+	// the motion is only tabled by a rider that lost confidence in the ruler
+	if noes == 0 {
+		return false
+	}
+	event := Event{
+		Type:       NoConfidenceRejected,
+		BikeID:     bike.GetID(),
+		Governance: bike.GetGovernance(),
+		AgentID:    bike.GetRuler(),
+		Ballots:    ballots,
+	}
+	if float64(noes)/float64(len(voters)) >= s.cfg.Governance.NoConfidenceMajority {
+		event.Type = NoConfidencePassed
+	}
+	s.publishEvent(event)
+	return event.Type == NoConfidencePassed
+}
+
+// switches a bike to another governance, electing its ruler (or dismissing the one it had, under Democracy)
+func (s *Server) changeGovernance(bike objects.IMegaBike, governance utils.Governance) {
+	bike.SetGovernance(governance)
+	s.recordRegime(bike)
+	delete(s.councils, bike.GetID())
+	delete(s.manifestos, bike.GetID())
+	if governance == utils.Democracy {
+		bike.SetRuler(uuid.Nil)
+		delete(s.terms, bike.GetID())
+		return
+	}
+	s.holdRulerElection(bike, governance)
 }
 
 func (s *Server) PruneLootboxes(bike objects.IMegaBike) map[uuid.UUID]objects.ILootBox {
@@ -56,7 +352,7 @@ func (s *Server) PruneLootboxes(bike objects.IMegaBike) map[uuid.UUID]objects.IL
 			if _, ok := validLootboxes[id]; !ok {
 				continue
 			}
-			if !r.EvaluateLootboxRule(bike, l) {
+			if !r.EvaluateLootboxRule(bike, l, s.cfg.Environment) {
 				delete(validLootboxes, id)
 			}
 		}
@@ -72,12 +368,21 @@ func (s *Server) UpdateBikeRules(bike objects.IMegaBike) {
 	rule := bike.GetActiveRulesForAction(objects.Lootbox)[0]
 	pRad := rule.GetRuleMatrix()[0][1]
 
+	riders := make([]objects.IBaseBiker, 0)
 	for _, agent := range bike.GetAgents() {
 		if agent.GetBikeStatus() {
-			tRad += agent.ProposeNewRadius(pRad)
-			nAg += 1
+			riders = append(riders, agent)
 		}
 	}
+	radii := decideAll(s, riders, "ProposeNewRadius", func(agent objects.IBaseBiker) float64 {
+		return agent.ProposeNewRadius(pRad)
+	}, validRadius, func(objects.IBaseBiker) float64 {
+		return pRad
+	})
+	for _, radius := range radii {
+		tRad += radius
+		nAg += 1
+	}
 
 	if nAg == 0 {
 		return
@@ -93,46 +398,72 @@ func (s *Server) UpdateBikeRules(bike objects.IMegaBike) {
 
 // select this round's decision following a voting-based approach (with weights in the case of a leadership-led governance)
 func (s *Server) RunDemocraticAction(bike objects.IMegaBike, weights map[uuid.UUID]float64) uuid.UUID {
+	s.refreshGameStateView()
+	direction, _, _ := s.runDemocraticAction(bike, weights, nil)
+	return direction
+}
+
+// runs the direction vote of a bike (its votes weighed by weigher, nil if they were weighed by the server), returning the
+// direction along with the lootbox proposed by each agent and their final votes
+func (s *Server) runDemocraticAction(bike objects.IMegaBike, weights map[uuid.UUID]float64, weigher objects.IBaseBiker) (uuid.UUID, map[uuid.UUID]uuid.UUID, map[uuid.UUID]voting.LootboxVoteMap) {
 	// map of the proposed lootboxes by bike (for each bike a list of lootbox proposals is made, with one lootbox proposed by each agent on the bike)
 	agents := bike.GetAgents()
 	proposedDirections := make(map[uuid.UUID]uuid.UUID)
 	validLootboxes := s.PruneLootboxes(bike)
+	// agents are shown the lootboxes as they are in their view of the game
+	viewedLootboxes := make(map[uuid.UUID]objects.ILootBox, len(validLootboxes))
+	for id := range validLootboxes {
+		viewedLootboxes[id] = s.view.lootBoxes[id]
+	}
 
+	// agents that have decided to stay on the bike (and that haven't been kicked off it)
+	// will participate in the voting for the directions
+	voters := make([]objects.IBaseBiker, 0, len(agents))
 	for _, agent := range agents {
-		// agents that have decided to stay on the bike (and that haven't been kicked off it)
-		// will participate in the voting for the directions
-		// ---------------------------VOTING ROUTINE - STEP 1 ---------------------
 		if agent.GetBikeStatus() {
-			// proposedDirection := agent.ProposeDirection()
-			proposedDirection := agent.ProposeDirectionFromSubset(validLootboxes)
-			if proposedDirection == uuid.Nil {
-				continue
-			}
-			if _, ok := s.lootBoxes[proposedDirection]; !ok {
-				panic("agent proposed a non-existent lootbox")
-			}
-			proposedDirections[agent.GetID()] = proposedDirection
+			voters = append(voters, agent)
+		}
+	}
+	// ---------------------------VOTING ROUTINE - STEP 1 ---------------------
+	proposals := decideAll(s, voters, "ProposeDirectionFromSubset", func(agent objects.IBaseBiker) uuid.UUID {
+		return agent.ProposeDirectionFromSubset(maps.Clone(viewedLootboxes))
+	}, validChoice(s.lootBoxes), func(objects.IBaseBiker) uuid.UUID {
+		return uuid.Nil
+	})
+	for i, agent := range voters {
+		if proposals[i] != uuid.Nil {
+			proposedDirections[agent.GetID()] = proposals[i]
 		}
 	}
 
 	s.UpdateBikeRules(bike)
 
 	if len(proposedDirections) == 0 {
-		return uuid.Nil
+		return uuid.Nil, proposedDirections, nil
 	}
 
-	finalVotes := make(map[uuid.UUID]voting.LootboxVoteMap, len(agents))
-	for _, agent := range agents {
-		// ---------------------------VOTING ROUTINE - STEP 2 ---------------------
-		// pass the pitched directions of a bike to all agents on that bike and get their final vote
-		finalVotes[agent.GetID()] = agent.FinalDirectionVote(proposedDirections)
+	// the final votes can only go to the proposed lootboxes, an even vote being cast for an agent that misbehaves
+	proposed := make(map[uuid.UUID]bool, len(proposedDirections))
+	for _, lootbox := range proposedDirections {
+		proposed[lootbox] = true
+	}
+	validVote := validDistribution[voting.LootboxVoteMap](proposed)
+	evenVote := func(objects.IBaseBiker) voting.LootboxVoteMap {
+		return evenSplit[voting.LootboxVoteMap](utils.SortedIDs(proposed), 1.0)
 	}
+	// ---------------------------VOTING ROUTINE - STEP 2 ---------------------
+	// pass the pitched directions of a bike to all agents on that bike and get their final vote
+	finalVotes := byAgent(agents, decideAll(s, agents, "FinalDirectionVote", func(agent objects.IBaseBiker) voting.LootboxVoteMap {
+		return agent.FinalDirectionVote(maps.Clone(proposedDirections))
+	}, validVote, evenVote))
 
 	// ---------------------------VOTING ROUTINE - STEP 3 --------------
 	// get the winning direction from the final votes
 	direction := s.GetWinningDirection(finalVotes, weights)
 	if _, ok := s.lootBoxes[direction]; !ok {
-		panic("agents voted on a non-existent lootbox")
+		// final votes that can't be tallied leave the bike without a direction
+		s.recordUntallied(agents, weigher, "FinalDirectionVote", errors.New("the final votes don't add up to a lootbox"))
+		direction = uuid.Nil
 	}
-	return direction
+	return direction, proposedDirections, finalVotes
 }
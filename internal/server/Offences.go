@@ -0,0 +1,299 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/common/voting"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Every decision the server asks of an agent goes through callAgent (or decideAll, for the agents of a phase), so
+// that a misbehaving agent can't bring the whole simulation down. A callback that panics, or whose output fails
+// validation, is an offence: it's recorded (see Offences), published as an AgentMisbehaved event, optionally
+// penalised in energy (resources.offence_penalty), and its output is replaced by the callback's default:
+//   - GetAllMessages, GetRecipients: no messages are sent
+//   - HandleMessage (any of the Handle...Message callbacks): the message is dropped
+//   - DecideGovernance: Democracy (or, when tabling an amendment, the bike's current governance)
+//   - UpdateAgentInternalState: skipped
+//   - DecideAction: Pedal (the agent stays on its bike)
+//   - ChangeBike: uuid.Nil (the agent doesn't ask to join a bike)
+//   - DecideWeights: equal weights for every rider of the bike
+//   - DecideKickOut: nobody is kicked off
+//   - VoteForKickout (by the riders of a democracy, or the members of a council): no votes against anybody
+//   - VoteExpulsion: against the motion
+//   - DecideJoining: nobody is accepted
+//   - VoteDictator, VoteLeader (also cast in council elections): an even vote for every rider of the bike
+//   - VoteAmendment: against the motion
+//   - VoteNoConfidence: confidence in the ruler
+//   - DecideCandidacy: the agent doesn't stand for ruler
+//   - ProposeDirectionFromSubset: uuid.Nil (no proposal)
+//   - ProposeNewRadius: the current radius
+//   - FinalDirectionVote: an even vote for every proposed lootbox
+//   - DictateDirection: uuid.Nil (no direction)
+//   - DecideForce: no forces (the agent neither pedals, brakes nor steers), which are set through SetForces
+//   - DecideAllocation, DecideDictatorAllocation: an even split between the riders of the bike
+//
+// Votes that are each valid can still fail to be tallied together (e.g. when they're only weighed for riders without
+// a say): the agent that weighed them misbehaved (or, when the server weighed them, every voter did), and the bike
+// falls back on the default of the vote, i.e. no direction or an even split of the loot.
+//
+// A default that has to be applied by the agent itself (clearing its forces, or telling the bike it was assigned) is
+// a callback like any other, so an agent misbehaving again while its default is applied can't crash the server
+// either: the offence is recorded once more, and the default given up on.
+//
+// A bike whose ruler is missing (dead, kicked off, or never elected) gets the defaults of its ruler's callbacks
+// straight away, without anybody being asked.
+
+// Offence records an agent misbehaving: one of its callbacks panicked, or returned an output the server can't use
+type Offence struct {
+	GameLoop  int       `json:"game_loop"`
+	Iteration int       `json:"iteration"`
+	AgentID   uuid.UUID `json:"agent_id"`
+	Callback  string    `json:"callback"`
+	Reason    string    `json:"reason"`
+}
+
+func (o Offence) String() string {
+	return fmt.Sprintf("agent %s in %s (game loop %d, iteration %d): %s", o.AgentID, o.Callback, o.GameLoop, o.Iteration, o.Reason)
+}
+
+// Offences lists the offences agents have committed so far, in the order they were committed
+func (s *Server) Offences() []Offence {
+	return slices.Clone(s.offences)
+}
+
+func (s *Server) recordOffence(agent objects.IBaseBiker, callback string, reason string) {
+	offence := Offence{GameLoop: s.gameLoop, Iteration: s.iteration, AgentID: agent.GetID(), Callback: callback, Reason: reason}
+	s.offences = append(s.offences, offence)
+	s.publishEvent(Event{Type: AgentMisbehaved, BikeID: agent.GetBike(), AgentID: agent.GetID(), Cause: callback + ": " + reason})
+	if penalty := s.cfg.Resources.OffencePenalty; penalty > 0 {
+		agent.UpdateEnergyLevel(-penalty)
+	}
+}
+
+// records the offence of votes that can't be tallied, against the agent that weighed them (if an agent did) or else
+// against every voter, for the callback they voted through
+func (s *Server) recordUntallied(voters []objects.IBaseBiker, weigher objects.IBaseBiker, callback string, err error) {
+	if weigher != nil {
+		s.recordOffence(weigher, "DecideWeights", err.Error())
+		return
+	}
+	for _, voter := range voters {
+		s.recordOffence(voter, callback, err.Error())
+	}
+}
+
+// callAgent runs one of an agent's callbacks. If the callback panics, or its output fails validation (when
+// validate isn't nil), the offence is recorded and the output of fallback is returned instead.
+func callAgent[T any](s *Server, agent objects.IBaseBiker, callback string, call func() T, validate func(T) error, fallback func() T) T {
+	output, offence := tryAgent(call, validate)
+	if offence != "" {
+		s.recordOffence(agent, callback, offence)
+		return fallback()
+	}
+	return output
+}
+
+// tryAgent runs a callback without touching the server, so that it can be run on any goroutine: it gives the
+// output of the callback, or why it can't be used (an empty reason meaning it can)
+func tryAgent[T any](call func() T, validate func(T) error) (output T, offence string) {
+	defer func() {
+		if r := recover(); r != nil {
+			offence = fmt.Sprintf("panicked: %v", r)
+		}
+	}()
+	output = call()
+	if validate != nil {
+		if err := validate(output); err != nil {
+			return output, err.Error()
+		}
+	}
+	return output, ""
+}
+
+// runs a callback without an output, reporting whether it completed
+func callAgentAction(s *Server, agent objects.IBaseBiker, callback string, call func()) bool {
+	return callAgent(s, agent, callback, func() bool {
+		call()
+		return true
+	}, nil, func() bool {
+		return false
+	})
+}
+
+// --------------------------------- validation ---------------------------------
+
+// the IDs of the given agents, as a set
+func idSet(agents []objects.IBaseBiker) map[uuid.UUID]bool {
+	ids := make(map[uuid.UUID]bool, len(agents))
+	for _, agent := range agents {
+		ids[agent.GetID()] = true
+	}
+	return ids
+}
+
+// an even split of a vote (or of weights) between the given options
+func evenSplit[M ~map[uuid.UUID]float64](options []uuid.UUID, total float64) M {
+	split := make(M, len(options))
+	for _, option := range options {
+		split[option] = total / float64(len(options))
+	}
+	return split
+}
+
+// checks that a distribution (of votes, weights or an allocation) is only over the given options, that none
+// of its values is negative (or not a number) and that they don't all sum to zero
+func validDistribution[M ~map[uuid.UUID]float64](options map[uuid.UUID]bool) func(M) error {
+	return func(distribution M) error {
+		sum := 0.0
+		for _, option := range utils.SortedIDs(distribution) {
+			value := distribution[option]
+			if !options[option] {
+				return fmt.Errorf("%s isn't one of the options", option)
+			}
+			if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+				return fmt.Errorf("%s was given %v", option, value)
+			}
+			sum += value
+		}
+		if sum == 0 {
+			return errors.New("the distribution sums to zero")
+		}
+		return nil
+	}
+}
+
+// an allocation decided alone can't hand out more than there is to allocate
+func validAllocation[M ~map[uuid.UUID]float64](riders map[uuid.UUID]bool) func(M) error {
+	validRiders := validDistribution[M](riders)
+	return func(allocation M) error {
+		if err := validRiders(allocation); err != nil {
+			return err
+		}
+		sum := 0.0
+		for _, id := range utils.SortedIDs(allocation) {
+			sum += allocation[id]
+		}
+		if sum > 1+1e-9 {
+			return fmt.Errorf("the allocation sums to %v, more than 1", sum)
+		}
+		return nil
+	}
+}
+
+// checks that a lootbox (or bike) exists, uuid.Nil standing for none
+func validChoice[V any](options map[uuid.UUID]V) func(uuid.UUID) error {
+	return func(id uuid.UUID) error {
+		if _, ok := options[id]; !ok && id != uuid.Nil {
+			return fmt.Errorf("%s doesn't exist", id)
+		}
+		return nil
+	}
+}
+
+func validGovernance(governance utils.Governance) error {
+	if governance < 0 || governance >= utils.Invalid {
+		return fmt.Errorf("%d isn't a governance", governance)
+	}
+	return nil
+}
+
+func validAction(action objects.BikerAction) error {
+	if action != objects.Pedal && action != objects.ChangeBike {
+		return fmt.Errorf("%d isn't an action", action)
+	}
+	return nil
+}
+
+// checks that a list of agents to kick off only holds riders of the bike, each of them once
+func validKickOut(riders map[uuid.UUID]bool) func([]uuid.UUID) error {
+	return func(kicked []uuid.UUID) error {
+		seen := make(map[uuid.UUID]bool, len(kicked))
+		for _, id := range kicked {
+			if !riders[id] {
+				return fmt.Errorf("%s isn't on the bike", id)
+			}
+			if seen[id] {
+				return fmt.Errorf("%s is kicked off more than once", id)
+			}
+			seen[id] = true
+		}
+		return nil
+	}
+}
+
+// checks that joining responses are only about the agents asking to join
+func validJoining(pending []uuid.UUID) func(map[uuid.UUID]bool) error {
+	return func(responses map[uuid.UUID]bool) error {
+		for _, id := range utils.SortedIDs(responses) {
+			if !slices.Contains(pending, id) {
+				return fmt.Errorf("%s didn't ask to join", id)
+			}
+		}
+		return nil
+	}
+}
+
+// the radius is stored negated in the lootbox rule of a bike (a lootbox is in reach when its distance plus the radius
+// is at most 0), so any finite value will do
+func validRadius(radius float64) error {
+	if math.IsNaN(radius) || math.IsInf(radius, 0) {
+		return fmt.Errorf("%v isn't a radius", radius)
+	}
+	return nil
+}
+
+// checks that the forces of an agent are within what a biker can exert
+func validForces(maxForce float64) func(utils.Forces) error {
+	return func(forces utils.Forces) error {
+		for _, force := range []float64{forces.Pedal, forces.Brake} {
+			if force < 0 || force > maxForce || math.IsNaN(force) {
+				return fmt.Errorf("%v isn't between 0 and the maximum force (%v)", force, maxForce)
+			}
+		}
+		if math.IsNaN(forces.Turning.SteeringForce) || math.IsInf(forces.Turning.SteeringForce, 0) {
+			return fmt.Errorf("%v isn't a steering force", forces.Turning.SteeringForce)
+		}
+		return nil
+	}
+}
+
+// checks that what a candidate promises only concerns the riders of its bike, and that it could be done
+func validCandidacy(riders map[uuid.UUID]bool) func(*objects.Manifesto) error {
+	validWeights := validDistribution[map[uuid.UUID]float64](riders)
+	validSplit := validAllocation[voting.IdVoteMap](riders)
+	return func(manifesto *objects.Manifesto) error {
+		if manifesto == nil {
+			return nil
+		}
+		if len(manifesto.Weights) != 0 {
+			if err := validWeights(manifesto.Weights); err != nil {
+				return fmt.Errorf("promised weights: %w", err)
+			}
+		}
+		if len(manifesto.Allocation) != 0 {
+			if err := validSplit(manifesto.Allocation); err != nil {
+				return fmt.Errorf("promised allocation: %w", err)
+			}
+		}
+		if colour := manifesto.Colour; colour != nil && (*colour < 0 || *colour >= utils.NumOfColours) {
+			return fmt.Errorf("%d isn't a colour", *colour)
+		}
+		return nil
+	}
+}
+
+// checks that messages have no missing recipient
+func validRecipients(recipients []objects.IBaseBiker) error {
+	for _, recipient := range recipients {
+		if recipient == nil {
+			return errors.New("a recipient is nil")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// The agents of a phase (deciding their actions, forces, proposals, votes, allocations...) are asked for their
+// decisions at once, on a bounded pool of workers (simulation.agent_workers, one per CPU by default), against the
+// game state view, which isn't refreshed until the phase is over. The server only applies their decisions once
+// they're all in, in the order of the agents, so that a run unfolds the same whether it's executed concurrently
+// or sequentially (simulation.sequential, which keeps every call on the server's goroutine for debugging).
+//
+// Agents are called concurrently with each other, never with themselves: a callback may only alter the state of
+// its own agent, and not what it was handed (which may be shared with the other agents of the phase).
+
+// decideAll asks every agent for a decision, returning them in the order of the agents. A decision that panics or
+// fails validation (when validate isn't nil) is an offence, recorded once all the decisions are in, and replaced
+// by the output of fallback for that agent.
+func decideAll[T any](s *Server, agents []objects.IBaseBiker, callback string, call func(objects.IBaseBiker) T, validate func(T) error, fallback func(objects.IBaseBiker) T) []T {
+	outputs := make([]T, len(agents))
+	offences := make([]string, len(agents))
+	s.runConcurrently(len(agents), func(i int) {
+		outputs[i], offences[i] = tryAgent(func() T {
+			return call(agents[i])
+		}, validate)
+	})
+	for i, agent := range agents {
+		if offences[i] != "" {
+			s.recordOffence(agent, callback, offences[i])
+			outputs[i] = fallback(agent)
+		}
+	}
+	return outputs
+}
+
+// runs a callback without an output for every agent, reporting whether each of them completed
+func actAll(s *Server, agents []objects.IBaseBiker, callback string, call func(objects.IBaseBiker)) []bool {
+	return decideAll(s, agents, callback, func(agent objects.IBaseBiker) bool {
+		call(agent)
+		return true
+	}, nil, func(objects.IBaseBiker) bool {
+		return false
+	})
+}
+
+// keys the decisions of agents by their IDs
+func byAgent[T any](agents []objects.IBaseBiker, decisions []T) map[uuid.UUID]T {
+	byID := make(map[uuid.UUID]T, len(agents))
+	for i, agent := range agents {
+		byID[agent.GetID()] = decisions[i]
+	}
+	return byID
+}
+
+// runs the jobs 0..n-1 on the worker pool, returning once they're all done
+func (s *Server) runConcurrently(n int, job func(i int)) {
+	workers := min(s.agentWorkers(), n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			job(i)
+		}
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1)) - 1; i < n; i = int(next.Add(1)) - 1 {
+				job(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// the number of agents that may make their decisions at once
+func (s *Server) agentWorkers() int {
+	switch {
+	case s.cfg.Simulation.Sequential:
+		return 1
+	case s.cfg.Simulation.AgentWorkers > 0:
+		return s.cfg.Simulation.AgentWorkers
+	default:
+		return runtime.GOMAXPROCS(0)
+	}
+}
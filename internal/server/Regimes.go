@@ -0,0 +1,149 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/common/voting"
+	"bytes"
+	"cmp"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+/*
+The regimes beyond the ruler-led ones of the original game: a council of elected riders, a ruler drawn by lot
+(sortition) and a leadership the riders take turns at (rotation)
+*/
+
+// elects the council of a bike (governance.council_size riders), the riders voting for its members as they would for
+// a leader. The members are given in order of the votes they got (the ID order breaking ties), the first chairing it.
+func (s *Server) councilElection(agents []objects.IBaseBiker, barred map[uuid.UUID]bool) ([]uuid.UUID, map[uuid.UUID]voting.IdVoteMap) {
+	votes := s.rulerVotes(agents, utils.Council, barred)
+	tally := make(map[uuid.UUID]float64, len(agents))
+	candidates := make([]uuid.UUID, 0, len(agents))
+	for _, agent := range agents {
+		if !barred[agent.GetID()] {
+			candidates = append(candidates, agent.GetID())
+		}
+	}
+	// nobody else can sit on the council
+	if len(candidates) == 0 {
+		candidates = riderIDs(agents)
+	}
+	for _, voter := range utils.SortedIDs(votes) {
+		total := voting.SumOfValues(votes[voter])
+		for candidate, share := range votes[voter] {
+			tally[candidate] += share / total
+		}
+	}
+	utils.SortIDs(candidates)
+	slices.SortStableFunc(candidates, func(a, b uuid.UUID) int {
+		return cmp.Compare(tally[b], tally[a])
+	})
+	return candidates[:min(s.cfg.Governance.CouncilSize, len(candidates))], votes
+}
+
+// the weight of the vote of each rider of a bike run by a council: only the votes of its members count. A council
+// that lost any of its members since it was elected is elected again first.
+func (s *Server) councilWeights(bike objects.IMegaBike) map[uuid.UUID]float64 {
+	agents := bike.GetAgents()
+	onBike := idSet(agents)
+	council := s.councils[bike.GetID()]
+	vacant := len(council) == 0
+	for _, member := range council {
+		vacant = vacant || !onBike[member]
+	}
+	if vacant && len(agents) != 0 {
+		s.holdRulerElection(bike, utils.Council)
+	}
+
+	members := make(map[uuid.UUID]bool)
+	for _, member := range s.councils[bike.GetID()] {
+		members[member] = true
+	}
+	weights := make(map[uuid.UUID]float64, len(agents))
+	for _, agent := range agents {
+		weights[agent.GetID()] = 0.0
+		if members[agent.GetID()] {
+			weights[agent.GetID()] = 1.0
+		}
+	}
+	return weights
+}
+
+// the members of the council running a bike, in the order they sit on the bike
+func (s *Server) councilMembers(bike objects.IMegaBike) []objects.IBaseBiker {
+	weights := s.councilWeights(bike)
+	members := make([]objects.IBaseBiker, 0)
+	for _, agent := range bike.GetAgents() {
+		if weights[agent.GetID()] > 0 {
+			members = append(members, agent)
+		}
+	}
+	return members
+}
+
+// the riders the council of a bike kicks off: those more than half of its members vote against
+func (s *Server) councilKickOut(bike objects.IMegaBike) []uuid.UUID {
+	members := s.councilMembers(bike)
+	ballots := decideAll(s, members, "VoteForKickout", objects.IBaseBiker.VoteForKickout, nil, func(objects.IBaseBiker) map[uuid.UUID]int {
+		return make(map[uuid.UUID]int)
+	})
+	against := make(map[uuid.UUID]int)
+	for _, ballot := range ballots {
+		for agentID, votes := range ballot {
+			if votes > 0 {
+				against[agentID]++
+			}
+		}
+	}
+	onBike := idSet(bike.GetAgents())
+	kicked := make([]uuid.UUID, 0)
+	for _, agentID := range utils.SortedIDs(against) {
+		if onBike[agentID] && float64(against[agentID]) > float64(len(members))/2.0 {
+			kicked = append(kicked, agentID)
+		}
+	}
+	return kicked
+}
+
+// draws the ruler of a bike by lot among its riders (any of them, if every one of them is barred)
+func (s *Server) drawLot(agents []objects.IBaseBiker, barred map[uuid.UUID]bool) uuid.UUID {
+	candidates := make([]uuid.UUID, 0, len(agents))
+	for _, agent := range agents {
+		if !barred[agent.GetID()] {
+			candidates = append(candidates, agent.GetID())
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = riderIDs(agents)
+	}
+	if len(candidates) == 0 {
+		return uuid.Nil
+	}
+	utils.SortIDs(candidates)
+	return candidates[s.rng.Intn(len(candidates))]
+}
+
+// the rider whose turn it is to lead a bike after the previous leader: the next one in ID order (wrapping around),
+// skipping barred riders unless every one of them is
+func nextInRotation(agents []objects.IBaseBiker, previous uuid.UUID, barred map[uuid.UUID]bool) uuid.UUID {
+	riders := riderIDs(agents)
+	utils.SortIDs(riders)
+	// the riders following the previous leader come first
+	next := 0
+	for next < len(riders) && bytes.Compare(riders[next][:], previous[:]) <= 0 {
+		next++
+	}
+	turns := append(slices.Clone(riders[next:]), riders[:next]...)
+	for _, rider := range turns {
+		if !barred[rider] {
+			return rider
+		}
+	}
+	if len(turns) == 0 {
+		return uuid.Nil
+	}
+	return turns[0]
+}
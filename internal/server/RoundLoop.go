@@ -1,11 +1,13 @@
 package server
 
 import (
-	"SOMAS2023/internal/common/globals"
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/common/voting"
+	"maps"
+	"math"
 	"slices"
 
 	"github.com/google/uuid"
@@ -22,16 +24,21 @@ func (s *Server) RunRoundLoop(iterationDump *SimplifiedIterationDump) {
 	s.RunActionProcess()
 	// The Awdi makes a decision
 
-	// Move the mega bikes
-	for _, bike := range s.megaBikes {
+	// Move the mega bikes (those drafting behind another one feeling less drag), then bounce those that ran into each other
+	s.drafting = s.draftingBikes()
+	for _, bike := range s.sortedMegaBikes() {
 		// update mass dependent on number of agents on bike
 		bike.UpdateMass()
 		s.runActionDeliberation(objects.Lootbox)
 		s.MovePhysicsObject(bike)
 	}
+	s.drafting = nil
+	s.BikeCollisionCheck()
 
-	// Move the awdi
-	s.MovePhysicsObject(s.awdi)
+	// Move the awdis
+	for _, awdi := range s.awdis {
+		s.MovePhysicsObject(awdi)
+	}
 
 	// Lootbox Distribution
 	s.runActionDeliberation(objects.Allocation)
@@ -49,24 +56,35 @@ func (s *Server) RunRoundLoop(iterationDump *SimplifiedIterationDump) {
 	iterationDump.AddRoundToIteration(roundDump)
 
 	// if the leader dies hold new elections
-	for _, bike := range s.GetMegaBikes() {
+	s.refreshGameStateView()
+	for _, bike := range s.sortedMegaBikes() {
 		gov := bike.GetGovernance()
 		agents := bike.GetAgents()
-		if len(agents) != 0 && (gov == utils.Leadership || gov == utils.Dictatorship) {
+		if len(agents) != 0 && rulerLed(gov) {
 			ruler := bike.GetRuler()
 			if _, ok := s.deadAgents[ruler]; ok {
-				agents := bike.GetAgents()
-				ruler := s.RulerElection(agents, gov)
-				bike.SetRuler(ruler)
+				s.holdRulerElection(bike, gov)
 			}
 		}
 	}
 
+	// rulers are held to account, through votes of no confidence and scheduled elections (rulers drawn by lot or
+	// taking turns being replaced every so often)
+	governance := s.cfg.Governance
+	if governance.NoConfidenceMajority > 0 || governance.TermLength > 0 || governance.SortitionPeriod > 0 || governance.RotationPeriod > 0 {
+		s.CheckRulerMandates()
+	}
+
+	// riders may vote to change the governance of their bike every so often
+	if interval := s.cfg.Governance.AmendmentInterval; interval > 0 && (s.iteration+1)%interval == 0 {
+		s.RunAmendments()
+	}
+
 	// Replenish objects
-	if utils.ReplenishLootBoxes {
+	if s.cfg.Environment.ReplenishLootBoxes {
 		s.replenishLootBoxes()
 	}
-	if utils.ReplenishMegaBikes {
+	if s.cfg.Environment.ReplenishMegaBikes {
 		s.replenishMegaBikes()
 	}
 
@@ -80,7 +98,7 @@ func (s *Server) RunRoundLoop(iterationDump *SimplifiedIterationDump) {
 
 func (s *Server) runActionDeliberation(action objects.Action) {
 	for _, bike := range s.megaBikes {
-		if *globals.StratifyRules {
+		if s.cfg.Simulation.StratifyRules {
 			bike.ActionIsValidForRuleset(action)
 		} else {
 			bike.ActionCompliesWithLinearRuleset()
@@ -107,11 +125,13 @@ func (s *Server) RunBikeSwitch() {
 
 // handles the kick out process according to each bike's governance
 func (s *Server) HandleKickoutProcess() []uuid.UUID {
+	s.refreshGameStateView()
 	allKicked := make([]uuid.UUID, 0)
-	for _, bike := range s.GetMegaBikes() {
+	for _, bike := range s.sortedMegaBikes() {
 		agents := bike.GetAgents()
 
-		if bike.GetRuler() == uuid.Nil {
+		// a ruler-led bike left without a ruler can't kick anybody out
+		if rulerLed(bike.GetGovernance()) && bike.GetRuler() == uuid.Nil {
 			continue
 		}
 
@@ -120,36 +140,43 @@ func (s *Server) HandleKickoutProcess() []uuid.UUID {
 			agentsVotes := make([]uuid.UUID, 0)
 
 			// the kickout process only happens through a (possibly weighted) vote in deliberative democracy and leadership democracy
+			// (and, among its members, by a council)
 			switch bike.GetGovernance() {
 			case utils.Democracy:
-				// make map of weights of 1 for all agents on bike (as they all have the same voting power)
-				agents := bike.GetAgents()
-				weights := make(map[uuid.UUID]float64)
-				for _, agent := range agents {
-					weights[agent.GetID()] = 1.0
-				}
+				// riders move to expel each other, every motion being put to the vote
+				agentsVotes = s.expulsionMotions(bike)
 
+			case utils.Leadership, utils.Rotation:
+				// get the map of weights from the leader (nobody is kicked off without one)
+				leader, ok := s.GetAgentMap()[bike.GetRuler()]
+				if !ok {
+					break
+				}
+				weights := s.decideWeights(leader, agents, utils.Kickout)
 				// get which agents are getting kicked out
 				agentsVotes = bike.KickOutAgent(weights)
 
-			case utils.Leadership:
-				// get the map of weights from the leader
-				ruler := bike.GetRuler()
-				leader := s.GetAgentMap()[ruler]
-				weights := leader.DecideWeights(utils.Kickout)
-				// get which agents are getting kicked out
-				agentsVotes = bike.KickOutAgent(weights)
+			case utils.Council:
+				agentsVotes = s.councilKickOut(bike)
 
-			case utils.Dictatorship:
-				// in a dictatorship only the ruler can kick out people
-				dictator := s.GetAgentMap()[bike.GetRuler()]
-				agentsVotes = dictator.DecideKickOut()
+			case utils.Dictatorship, utils.Sortition:
+				// in a dictatorship only the ruler can kick out people (as can the one drawn by lot), nobody without one
+				dictator, ok := s.GetAgentMap()[bike.GetRuler()]
+				if !ok {
+					break
+				}
+				agentsVotes = callAgent(s, dictator, "DecideKickOut", dictator.DecideKickOut, validKickOut(idSet(agents)), func() []uuid.UUID {
+					return make([]uuid.UUID, 0)
+				})
 			}
 
 			// perform kickout
 			leaderKickedOut := false
 			allKicked = append(allKicked, agentsVotes...)
 			for _, agentID := range agentsVotes {
+				s.recordBikeEvent(KickedOffBike, agentID, bike.GetID())
+				s.recordKickOff(agentID, bike.GetID())
+				s.publishEvent(Event{Type: AgentKicked, BikeID: bike.GetID(), Governance: bike.GetGovernance(), AgentID: agentID})
 				s.RemoveAgentFromBike(s.GetAgentMap()[agentID])
 				// if the leader was kicked out will need to vote for a new one
 				if agentID == bike.GetRuler() {
@@ -158,9 +185,8 @@ func (s *Server) HandleKickoutProcess() []uuid.UUID {
 			}
 
 			// new elections if needed
-			if leaderKickedOut && len(bike.GetAgents()) != 0 && bike.GetGovernance() == utils.Leadership {
-				ruler := s.RulerElection(bike.GetAgents(), utils.Leadership)
-				bike.SetRuler(ruler)
+			if leaderKickedOut && len(bike.GetAgents()) != 0 && bike.GetGovernance() != utils.Dictatorship {
+				s.holdRulerElection(bike, bike.GetGovernance())
 			}
 		}
 
@@ -170,33 +196,40 @@ func (s *Server) HandleKickoutProcess() []uuid.UUID {
 
 // get list of agents that want to leave their bike in current round
 func (s *Server) GetLeavingDecisions() []uuid.UUID {
+	s.refreshGameStateView()
 	leavingAgents := make([]uuid.UUID, 0)
-	for agentId, agent := range s.GetAgentMap() {
+	riders := make([]objects.IBaseBiker, 0)
+	for _, agent := range s.sortedAgents() {
 		if agent.GetBikeStatus() {
-			agent.UpdateAgentInternalState()
-			switch agent.DecideAction() {
-			case objects.Pedal:
-				continue
-			case objects.ChangeBike:
-				// the bike id is set to be the desired bike and onbike is set to false
-				// so by looking at the values of onBike and megaBikeID it will be known
-				// whether the agent is trying to join a bike (and which one)
-
-				// the request is handled at the beginning of the next round, so the moving
-				// will only be finalised then
-				leavingAgents = append(leavingAgents, agentId)
-				s.RemoveAgentFromBike(agent)
-			default:
-				panic("agent decided invalid action")
-			}
+			riders = append(riders, agent)
+		}
+	}
+	actAll(s, riders, "UpdateAgentInternalState", objects.IBaseBiker.UpdateAgentInternalState)
+	actions := decideAll(s, riders, "DecideAction", objects.IBaseBiker.DecideAction, validAction, func(objects.IBaseBiker) objects.BikerAction {
+		return objects.Pedal
+	})
+	for i, agent := range riders {
+		agentId := agent.GetID()
+		switch actions[i] {
+		case objects.Pedal:
+			continue
+		case objects.ChangeBike:
+			// the bike id is set to be the desired bike and onbike is set to false
+			// so by looking at the values of onBike and megaBikeID it will be known
+			// whether the agent is trying to join a bike (and which one)
+
+			// the request is handled at the beginning of the next round, so the moving
+			// will only be finalised then
+			leavingAgents = append(leavingAgents, agentId)
+			s.recordBikeEvent(LeftBike, agentId, agent.GetBike())
+			s.RemoveAgentFromBike(agent)
 		}
 	}
 
-	// if ruler has left the bike will need to run elections
-	for _, bike := range s.GetMegaBikes() {
+	// if ruler has left the bike will need to run elections (under the bike's own governance)
+	for _, bike := range s.sortedMegaBikes() {
 		if slices.Contains(leavingAgents, bike.GetRuler()) && len(bike.GetAgents()) != 0 {
-			ruler := s.RulerElection(bike.GetAgents(), utils.Leadership)
-			bike.SetRuler(ruler)
+			s.holdRulerElection(bike, bike.GetGovernance())
 		}
 	}
 	return leavingAgents
@@ -205,6 +238,7 @@ func (s *Server) GetLeavingDecisions() []uuid.UUID {
 // dispatch joining requests to the bikes of competence and move bikers from limbo to their desired bike subject to the
 // acceptance process outcome
 func (s *Server) ProcessJoiningRequests(inLimbo []uuid.UUID) {
+	s.refreshGameStateView()
 
 	// -------------------------- PROCESS JOINING REQUESTS -------------------------
 	// 1. group agents that have onBike = false by the bike they are trying to join
@@ -213,30 +247,37 @@ func (s *Server) ProcessJoiningRequests(inLimbo []uuid.UUID) {
 	// panic(s.megaBikes)
 
 	// 2. pass to agents on each of the desired bikes a list of all agents trying to join
-	for bikeID, pendingAgents := range bikeRequests {
-		agents := s.megaBikes[bikeID].GetAgents()
+	for _, bikeID := range utils.SortedIDs(bikeRequests) {
+		bike := s.megaBikes[bikeID]
+		agents := bike.GetAgents()
+		accepted := make(map[uuid.UUID]bool, len(bikeRequests[bikeID]))
+		// agents kicked off the bike too recently are refused straight away
+		pendingAgents := make([]uuid.UUID, 0, len(bikeRequests[bikeID]))
+		for _, pendingAgent := range bikeRequests[bikeID] {
+			if !s.coolingDown(pendingAgent, bikeID) {
+				pendingAgents = append(pendingAgents, pendingAgent)
+			}
+		}
 		// if there are no agents on the target bike accept all of them (until all seats are filled)
 		if len(agents) == 0 {
-			// as iterating over a map is pseudo-random it's enough to stop whrn the capacity is reached
-			// to ensure a fair (= random) selection in the case of an empty target bike
+			// pending agents are in ID order (which is unrelated to their behaviour), so it's enough to stop
+			// when the capacity is reached to ensure a fair selection in the case of an empty target bike
 			for i, pendingAgent := range pendingAgents {
-				if i <= utils.BikersOnBike {
+				if i <= s.cfg.Environment.BikersOnBike {
 					acceptedAgent := s.GetAgentMap()[pendingAgent]
 					s.AddAgentToBike(acceptedAgent)
+					accepted[pendingAgent] = true
 				} else {
 					break
 				}
 			}
-			// if the governance of the bike is ruler led an election needs to be held
-			gov := s.megaBikes[bikeID].GetGovernance()
-			if gov == utils.Dictatorship || gov == utils.Leadership {
+			// if the governance of the bike is ruler led an election needs to be held (unless nobody could join it)
+			gov := bike.GetGovernance()
+			if rulerLed(gov) && len(bike.GetAgents()) != 0 {
 				// run election process
-				agents := s.megaBikes[bikeID].GetAgents()
-				ruler := s.RulerElection(agents, gov)
-				s.megaBikes[bikeID].SetRuler(ruler)
+				s.holdRulerElection(bike, gov)
 			}
 		} else {
-			bike := s.GetMegaBikes()[bikeID]
 			acceptedRanked := make([]uuid.UUID, 0)
 
 			// the acceptance process is different for each governance type
@@ -249,32 +290,41 @@ func (s *Server) ProcessJoiningRequests(inLimbo []uuid.UUID) {
 				}
 
 				// get approval votes from each agent
-				responses := make(map[uuid.UUID]map[uuid.UUID]bool, len(agents)) // list containing all the agents' ranking
-				for _, agent := range agents {
-					responses[agent.GetID()] = agent.DecideJoining(pendingAgents)
-				}
+				responses := s.decideJoining(agents, pendingAgents) // list containing all the agents' ranking
 
 				// accept agents based on the response outcome (only capacity-n bikers can be accepted)
 				acceptedRanked = voting.GetAcceptanceRanking(responses, weights)
-			case utils.Leadership:
-				// get the map of weights from the leader
-				leader := s.GetAgentMap()[bike.GetRuler()]
-				weights := leader.DecideWeights(utils.Joining)
+			case utils.Leadership, utils.Rotation:
+				// get the map of weights from the leader (nobody is accepted without one)
+				leader, ok := s.GetAgentMap()[bike.GetRuler()]
+				if !ok {
+					break
+				}
+				weights := s.decideWeights(leader, agents, utils.Joining)
 
 				// get approval votes from each agent
-				responses := make(map[uuid.UUID](map[uuid.UUID]bool), len(agents)) // list containing all the agents' ranking
-				for _, agent := range agents {
-					responses[agent.GetID()] = agent.DecideJoining(pendingAgents)
-				}
+				responses := s.decideJoining(agents, pendingAgents) // list containing all the agents' ranking
 
 				// accept agents based on the response outcome (only capacity-n bikers can be accepted)
 				// so the ranking is sorted based on how many people voted positively for each agent
 				acceptedRanked = voting.GetAcceptanceRanking(responses, weights)
-			case utils.Dictatorship:
-				dictator := s.GetAgentMap()[bike.GetRuler()]
-				acceptedRankedMap := dictator.DecideJoining(pendingAgents)
-				for agentID, accepted := range acceptedRankedMap {
-					if accepted {
+			case utils.Council:
+				// only the council members vote on who joins
+				members := s.councilMembers(bike)
+				weights := make(map[uuid.UUID]float64, len(members))
+				for _, member := range members {
+					weights[member.GetID()] = 1.0
+				}
+				responses := s.decideJoining(members, pendingAgents)
+				acceptedRanked = voting.GetAcceptanceRanking(responses, weights)
+			case utils.Dictatorship, utils.Sortition:
+				dictator, ok := s.GetAgentMap()[bike.GetRuler()]
+				if !ok {
+					break
+				}
+				acceptedRankedMap := s.decideJoining([]objects.IBaseBiker{dictator}, pendingAgents)[dictator.GetID()]
+				for _, agentID := range utils.SortedIDs(acceptedRankedMap) {
+					if acceptedRankedMap[agentID] {
 						acceptedRanked = append(acceptedRanked, agentID)
 					}
 				}
@@ -282,22 +332,36 @@ func (s *Server) ProcessJoiningRequests(inLimbo []uuid.UUID) {
 
 			// run acceptance process
 			totalSeatsFilled := len(agents)
-			emptySpaces := utils.BikersOnBike - totalSeatsFilled
+			emptySpaces := s.cfg.Environment.BikersOnBike - totalSeatsFilled
 
 			// accept up to capacity
 			for i := 0; i < min(emptySpaces, len(acceptedRanked)); i++ {
-				accepted := acceptedRanked[i]
-				acceptedAgent := s.GetAgentMap()[accepted]
+				acceptedAgent := s.GetAgentMap()[acceptedRanked[i]]
 				s.AddAgentToBike(acceptedAgent)
+				accepted[acceptedRanked[i]] = true
+			}
+		}
+
+		for _, pendingAgent := range bikeRequests[bikeID] {
+			event := Event{Type: JoinRejected, BikeID: bikeID, Governance: bike.GetGovernance(), AgentID: pendingAgent}
+			if accepted[pendingAgent] {
+				event.Type = JoinAccepted
+			} else if s.coolingDown(pendingAgent, bikeID) {
+				event.Cause = InCooldown
 			}
+			s.publishEvent(event)
 		}
 	}
 }
 
 // run the process on deciding this round's direction according to each governance's rules and on deciding the forces
 func (s *Server) RunActionProcess() {
+	s.refreshGameStateView()
 
-	for _, bike := range s.GetMegaBikes() {
+	// the riders of every bike decide their forces together, once the direction of each bike has been chosen
+	riders := make([]objects.IBaseBiker, 0)
+	directions := make(map[uuid.UUID]uuid.UUID)
+	for _, bike := range s.sortedMegaBikes() {
 
 		agents := bike.GetAgents()
 		if len(agents) == 0 {
@@ -307,6 +371,7 @@ func (s *Server) RunActionProcess() {
 		// get the direction for this round (either the voted on or what's decided by the leader/ dictator)
 		var direction uuid.UUID
 		electedGovernance := bike.GetGovernance()
+		event := Event{Type: DirectionChosen, BikeID: bike.GetID(), Governance: electedGovernance}
 		switch electedGovernance {
 		case utils.Democracy:
 			// make map of weights of 1 for all agents on bike
@@ -315,34 +380,77 @@ func (s *Server) RunActionProcess() {
 				weights[agent.GetID()] = 1.0
 			}
 
-			direction = s.RunDemocraticAction(bike, weights)
+			var proposals map[uuid.UUID]uuid.UUID
+			var votes map[uuid.UUID]voting.LootboxVoteMap
+			direction, proposals, votes = s.runDemocraticAction(bike, weights, nil)
+			event.Proposals, event.Votes, event.Weights = proposals, votesOf(votes), weights
 			// agetns incur in an energetic penalty for partecipating in a vote
 			for _, agent := range agents {
-				agent.UpdateEnergyLevel(-utils.DeliberativeDemocracyPenalty)
+				agent.UpdateEnergyLevel(-s.cfg.Resources.DeliberativeDemocracyPenalty)
 			}
-		case utils.Leadership:
+		case utils.Leadership, utils.Rotation:
 			// get weights from leader
 			leader, ok := s.GetAgentMap()[bike.GetRuler()]
 			if !ok {
 				break
 			}
-			weights := leader.DecideWeights(utils.Direction)
-			direction = s.RunDemocraticAction(bike, weights)
+			weights := s.decideWeights(leader, agents, utils.Direction)
+			var proposals map[uuid.UUID]uuid.UUID
+			var votes map[uuid.UUID]voting.LootboxVoteMap
+			direction, proposals, votes = s.runDemocraticAction(bike, weights, leader)
+			event.Proposals, event.Votes, event.Weights = proposals, votesOf(votes), maps.Clone(weights)
+			penalty := s.cfg.Resources.LeadershipDemocracyPenalty
+			if electedGovernance == utils.Rotation {
+				penalty = s.cfg.Resources.RotationPenalty
+			}
 			for _, agent := range agents {
-				agent.UpdateEnergyLevel(-utils.LeadershipDemocracyPenalty)
+				agent.UpdateEnergyLevel(-penalty)
 			}
-		case utils.Dictatorship:
-			// the dictator is solely responsible for choosing the direction
+		case utils.Council:
+			// only the votes of the council members count, and only they incur in the penalty for deliberating
+			weights := s.councilWeights(bike)
+			var proposals map[uuid.UUID]uuid.UUID
+			var votes map[uuid.UUID]voting.LootboxVoteMap
+			direction, proposals, votes = s.runDemocraticAction(bike, weights, nil)
+			event.Proposals, event.Votes, event.Weights = proposals, votesOf(votes), maps.Clone(weights)
+			for _, agent := range agents {
+				if weights[agent.GetID()] > 0 {
+					agent.UpdateEnergyLevel(-s.cfg.Resources.CouncilPenalty)
+				}
+			}
+		case utils.Dictatorship, utils.Sortition:
+			// the dictator is solely responsible for choosing the direction (as is the one drawn by lot, at a cost)
 			direction = s.RunRulerAction(bike)
+			event.AgentID = bike.GetRuler()
+			if ruler, ok := s.GetAgentMap()[bike.GetRuler()]; ok && electedGovernance == utils.Sortition {
+				ruler.UpdateEnergyLevel(-s.cfg.Resources.SortitionPenalty)
+			}
 		}
+		event.LootBoxID = direction
+		event.Riders = riderIDs(agents)
+		s.publishEvent(event)
 
 		for _, agent := range agents {
-			agent.DecideForce(direction)
-			// deplete energy
-			energyLost := agent.GetForces().Pedal * utils.MovingDepletion
-			agent.UpdateEnergyLevel(-energyLost)
+			riders = append(riders, agent)
+			directions[agent.GetID()] = direction
 		}
 	}
+
+	forces := decideAll(s, riders, "DecideForce", func(agent objects.IBaseBiker) utils.Forces {
+		agent.DecideForce(directions[agent.GetID()])
+		return agent.GetForces()
+	}, validForces(s.cfg.Physics.BikerMaxForce), func(agent objects.IBaseBiker) utils.Forces {
+		// the forces are cleared by the agent itself, which may well misbehave again
+		callAgentAction(s, agent, "SetForces", func() {
+			agent.SetForces(utils.Forces{})
+		})
+		return utils.Forces{}
+	})
+	for i, agent := range riders {
+		// deplete energy
+		energyLost := forces[i].Pedal * s.cfg.Resources.MovingDepletion
+		agent.UpdateEnergyLevel(-energyLost)
+	}
 }
 
 // move the physics objects (i.e. mega bikes and awdi) according to the forces and orientations
@@ -351,16 +459,34 @@ func (s *Server) MovePhysicsObject(po objects.IPhysicsObject) {
 	// Server requests to update their force and orientation based on agents pedaling
 	po.UpdateForce()
 	force := po.GetForce()
-	po.UpdateOrientation()
 	orientation := po.GetOrientation()
+	po.UpdateOrientation()
+	targetOrientation := po.GetOrientation()
 	// Obtains the current state (i.e. velocity, acceleration, position, mass)
 	initialState := po.GetPhysicalState()
 
-	// Generates a new state based on the force and orientation
-	finalState := physics.GenerateNewState(initialState, force, orientation)
-
-	// Sets the new physical state (i.e. updates gamestate)
-	po.SetPhysicalState(finalState)
+	// Generates a new state based on the force and orientation (turning as far as the physics model allows),
+	// kept within the grid by its boundary
+	params := s.cfg.Physics
+	if s.drafting[po.GetID()] {
+		params.DragCoefficient *= s.cfg.Physics.DraftingDrag
+	}
+	finalState, wall := physics.GenerateNewState(s.motion, initialState, force, orientation, targetOrientation, params, s.cfg.Environment)
+
+	// Sets the new physical state (i.e. updates gamestate), the object remembering where it set off from
+	po.MoveTo(finalState)
+	po.SetOrientation(wall.Orientation)
+	bike, ok := s.megaBikes[po.GetID()]
+	if !ok {
+		return
+	}
+	s.index.megaBikes.Insert(po.GetID(), finalState.Position)
+	// running into an absorbing wall costs the riders of a bike energy
+	if wall.Hit && s.cfg.Environment.Boundary == config.AbsorbBoundary {
+		for _, agent := range bike.GetAgents() {
+			agent.UpdateEnergyLevel(-s.cfg.Resources.WallEnergyPenalty)
+		}
+	}
 }
 
 func (s *Server) GetWinningDirection(finalVotes map[uuid.UUID]voting.LootboxVoteMap, weights map[uuid.UUID]float64) uuid.UUID {
@@ -374,20 +500,37 @@ func (s *Server) GetWinningDirection(finalVotes map[uuid.UUID]voting.LootboxVote
 		IfinalVotes[i] = v
 	}
 
-	return voting.WinnerFromDist(IfinalVotes, weights)
+	// votes that can't be counted choose no direction
+	direction, err := voting.WinnerFromDist(IfinalVotes, weights, s.cfg.Voting.Method)
+	if err != nil {
+		return uuid.Nil
+	}
+	return direction
 }
 
-// check for deadly collisions
+// check for deadly collisions (the awdis in the order they spawned)
 func (s *Server) AwdiCollisionCheck() {
-	// Check collision for awdi with any megaBike
-	for _, megabike := range s.GetMegaBikes() {
-		if s.awdi.CheckForCollision(megabike) {
+	for _, awdi := range s.awdis {
+		s.awdiCollisionCheck(awdi)
+	}
+}
+
+func (s *Server) awdiCollisionCheck(awdi objects.IAwdi) {
+	// Check collision for awdi with any megaBike (near enough to its path to have met it on the way)
+	nearbyBikes := s.GetMegaBikesInRadius(s.pathCentre(awdi), s.cfg.Environment.CollisionThreshold+s.pathLength(awdi)/2+s.cfg.Physics.MaxSpeed)
+	for _, bikeID := range utils.SortedIDs(nearbyBikes) {
+		megabike := nearbyBikes[bikeID]
+		if impactTime, ok := awdi.CheckForSweptCollision(megabike); ok {
 			// Collision detected
-			for _, agentToDelete := range megabike.GetAgents() {
+			riders := megabike.GetAgents()
+			s.publishEvent(Event{Type: AwdiCollision, BikeID: megabike.GetID(), Governance: megabike.GetGovernance(), AwdiID: awdi.GetID(), Riders: riderIDs(riders), ImpactTime: impactTime})
+			for _, agentToDelete := range riders {
+				s.publishEvent(Event{Type: AgentDied, BikeID: megabike.GetID(), AgentID: agentToDelete.GetID(), Cause: DiedInCollision})
 				s.RemoveAgent(agentToDelete)
 			}
-			if utils.AwdiRemovesMegaBike {
+			if s.cfg.Awdi.RemovesMegaBike {
 				delete(s.megaBikes, megabike.GetID())
+				s.index.megaBikes.Remove(megabike.GetID())
 			}
 		}
 	}
@@ -395,98 +538,111 @@ func (s *Server) AwdiCollisionCheck() {
 
 // if a bike has looted a box run the distribution process according to the governance type
 func (s *Server) LootboxCheckAndDistributions() {
-
-	// checks how many bikes have looted one lootbox to split it between them
+	s.refreshGameStateView()
+
+	// finds when each bike reached the lootboxes it ran into, and which bikes got to each lootbox first
+	collisions := make(map[uuid.UUID]map[uuid.UUID]float64)
+	firstArrival := make(map[uuid.UUID]float64)
+	for _, megabike := range s.sortedMegaBikes() {
+		collisions[megabike.GetID()] = s.collidingLootBoxes(megabike)
+		for lootid, impactTime := range collisions[megabike.GetID()] {
+			if first, ok := firstArrival[lootid]; !ok || impactTime < first {
+				firstArrival[lootid] = impactTime
+			}
+		}
+	}
+	// checks how many bikes have looted one lootbox to split it between them (only the first to get there, if it goes to them)
 	looted := make(map[uuid.UUID]int)
-	for _, megabike := range s.GetMegaBikes() {
-		for lootid, lootbox := range s.GetLootBoxes() {
-			if megabike.CheckForCollision(lootbox) { // && len(megabike.GetAgents()) != 0
-				megabike.UpdateCurrentPool(lootbox.GetTotalResources())
-				if value, ok := looted[lootid]; ok {
-					looted[lootid] = value + 1
-				} else {
-					looted[lootid] = 1
-				}
+	for _, megabike := range s.sortedMegaBikes() {
+		for _, lootid := range utils.SortedIDs(collisions[megabike.GetID()]) {
+			if s.cfg.Environment.FirstArrivalTakesLoot && collisions[megabike.GetID()][lootid] > firstArrival[lootid] {
+				delete(collisions[megabike.GetID()], lootid)
+				continue
 			}
+			lootbox := s.GetLootBoxes()[lootid]
+			megabike.UpdateCurrentPool(lootbox.GetTotalResources())
+			looted[lootid]++
 		}
 	}
-	for bikeid, megabike := range s.GetMegaBikes() {
-		for lootid, lootbox := range s.GetLootBoxes() {
-			if megabike.CheckForCollision(lootbox) {
-				// Collision detected
-				agents := megabike.GetAgents()
-				totAgents := len(agents)
-
-				if totAgents > 0 {
-					gov := s.GetMegaBikes()[bikeid].GetGovernance()
-					var winningAllocation voting.IdVoteMap
-					switch gov {
-					case utils.Democracy:
-						allAllocations := make(map[uuid.UUID]voting.IdVoteMap)
-						for _, agent := range agents {
-							// the agents return their ideal lootbox split by assigning a number between 0 and 1 to
-							// each biker on their bike (including themselves) ensuring they sum to 1
-							allAllocations[agent.GetID()] = agent.DecideAllocation()
-						}
-
-						Iallocations := make(map[uuid.UUID]voting.IVoter)
-						for i, v := range allAllocations {
-							Iallocations[i] = v
-						}
-						// make weights of 1 for all agents
-						weights := make(map[uuid.UUID]float64)
-						for _, agent := range agents {
-							weights[agent.GetID()] = 1.0
-						}
-						winningAllocation = voting.CumulativeDist(Iallocations, weights)
-
-					case utils.Leadership:
-						// get the map of weights from the leader
-						leader, ok := s.GetAgentMap()[megabike.GetRuler()]
-						if !ok {
-							break
-						}
-						weights := leader.DecideWeights(utils.Allocation)
-					outer:
-						for id := range weights {
-							for _, agent := range agents {
-								if agent.GetID() == id {
-									continue outer
-								}
-							}
-							panic("leader gave weight to an agent that isn't on the bike")
-						}
-						// get allocation votes from each agent
-						allAllocations := make(map[uuid.UUID]voting.IdVoteMap)
-						for _, agent := range agents {
-							allAllocations[agent.GetID()] = agent.DecideAllocation()
-						}
-
-						Iallocations := make(map[uuid.UUID]voting.IVoter)
-						for i, v := range allAllocations {
-							Iallocations[i] = v
-						}
-						winningAllocation = voting.CumulativeDist(Iallocations, weights)
-
-					case utils.Dictatorship:
-						// dictator decides the allocation
-						leader := s.GetAgentMap()[megabike.GetRuler()]
-						winningAllocation = leader.DecideDictatorAllocation()
+	for _, megabike := range s.sortedMegaBikes() {
+		bikeid := megabike.GetID()
+		for _, lootid := range utils.SortedIDs(collisions[bikeid]) {
+			lootbox := s.GetLootBoxes()[lootid]
+			// Collision detected
+			agents := megabike.GetAgents()
+			totAgents := len(agents)
+
+			if totAgents > 0 {
+				gov := s.GetMegaBikes()[bikeid].GetGovernance()
+				var winningAllocation voting.IdVoteMap
+				switch gov {
+				case utils.Democracy:
+					// the agents return their ideal lootbox split by assigning a number between 0 and 1 to
+					// each biker on their bike (including themselves) ensuring they sum to 1
+					allAllocations := s.decideAllocations(agents)
+
+					// make weights of 1 for all agents
+					weights := make(map[uuid.UUID]float64)
+					for _, agent := range agents {
+						weights[agent.GetID()] = 1.0
+					}
+					winningAllocation = s.tallyAllocations(agents, allAllocations, weights, nil)
+
+				case utils.Leadership, utils.Rotation:
+					// get the map of weights from the leader
+					leader, ok := s.GetAgentMap()[megabike.GetRuler()]
+					if !ok {
+						break
 					}
+					weights := s.decideWeights(leader, agents, utils.Allocation)
+					// get allocation votes from each agent
+					allAllocations := s.decideAllocations(agents)
+					winningAllocation = s.tallyAllocations(agents, allAllocations, weights, leader)
+
+				case utils.Council:
+					// the allocations proposed by the council members are the only ones that count
+					weights := s.councilWeights(megabike)
+					allAllocations := s.decideAllocations(agents)
+					winningAllocation = s.tallyAllocations(agents, allAllocations, weights, nil)
+
+				case utils.Dictatorship, utils.Sortition:
+					// dictator decides the allocation (an even split without one)
+					evenAllocation := func() voting.IdVoteMap {
+						return evenSplit[voting.IdVoteMap](riderIDs(agents), 1.0)
+					}
+					leader, ok := s.GetAgentMap()[megabike.GetRuler()]
+					if !ok {
+						winningAllocation = evenAllocation()
+						break
+					}
+					winningAllocation = callAgent(s, leader, "DecideDictatorAllocation", leader.DecideDictatorAllocation, validAllocation[voting.IdVoteMap](idSet(agents)), evenAllocation)
+					s.checkAllocationPromise(leader, winningAllocation)
+				}
 
-					bikeShare := float64(looted[lootid]) // how many other bikes have looted this box
-
-					for agentID, allocation := range winningAllocation {
-						lootShare := allocation * (lootbox.GetTotalResources() / bikeShare)
-						agent := s.GetAgentMap()[agentID]
-						// Allocate loot based on the calculated utility share
-						agent.UpdateEnergyLevel(lootShare)
-						// Allocate points if the box is of the right colour
-						if agent.GetColour() == lootbox.GetColour() {
-							agent.UpdatePoints(utils.PointsFromSameColouredLootBox)
-						}
+				bikeShare := float64(looted[lootid]) // how many other bikes have looted this box
+
+				allocated := make(map[uuid.UUID]float64, len(winningAllocation))
+				for agentID, allocation := range winningAllocation {
+					lootShare := allocation * (lootbox.GetTotalResources() / bikeShare)
+					allocated[agentID] = lootShare
+					agent := s.GetAgentMap()[agentID]
+					// Allocate loot based on the calculated utility share
+					agent.UpdateEnergyLevel(lootShare)
+					// Allocate points if the box is of the right colour
+					if agent.GetColour() == lootbox.GetColour() {
+						agent.UpdatePoints(s.cfg.Resources.PointsFromSameColouredLootBox)
 					}
 				}
+				s.publishEvent(Event{
+					Type:       LootAllocated,
+					BikeID:     bikeid,
+					Governance: gov,
+					LootBoxID:  lootid,
+					Riders:     riderIDs(agents),
+					Resources:  lootbox.GetTotalResources() / bikeShare,
+					Allocation: allocated,
+					ImpactTime: collisions[bikeid][lootid],
+				})
 			}
 		}
 	}
@@ -495,29 +651,59 @@ func (s *Server) LootboxCheckAndDistributions() {
 	for id, loot := range looted {
 		if loot > 0 {
 			delete(s.lootBoxes, id)
+			s.index.lootBoxes.Remove(id)
 		}
 	}
 }
 
+// the lootboxes a bike is colliding with, in ID order (only the lootboxes near enough to it are checked)
+func (s *Server) collidingLootBoxes(bike objects.IMegaBike) map[uuid.UUID]float64 {
+	colliding := make(map[uuid.UUID]float64)
+	// only the lootboxes near enough to the bike's path can have been met on the way
+	for _, id := range s.index.lootBoxes.InRadius(s.pathCentre(bike), s.cfg.Environment.CollisionThreshold+s.pathLength(bike)/2) {
+		if impactTime, ok := bike.CheckForSweptCollision(s.lootBoxes[id]); ok {
+			colliding[id] = impactTime
+		}
+	}
+	return colliding
+}
+
+// the middle of the path an object took in its last move
+func (s *Server) pathCentre(po objects.IPhysicsObject) utils.Coordinates {
+	from := po.GetPreviousPosition()
+	dx, dy := physics.Displacement(from, po.GetPosition(), s.cfg.Environment)
+	return utils.Coordinates{X: from.X + dx/2, Y: from.Y + dy/2}
+}
+
+// the length of the path an object took in its last move
+func (s *Server) pathLength(po objects.IPhysicsObject) float64 {
+	return math.Sqrt(physics.ComputeDistance(po.GetPreviousPosition(), po.GetPosition(), s.cfg.Environment))
+}
+
 func (s *Server) SetDestinationBikes() {
-	for _, agent := range s.GetAgentMap() {
+	s.refreshGameStateView()
+	bikeless := make([]objects.IBaseBiker, 0)
+	for _, agent := range s.sortedAgents() {
 		if !agent.GetBikeStatus() {
-			targetBike := agent.ChangeBike()
-			if targetBike == uuid.Nil { // agent didn't specify bike
-				continue
-			}
-			if _, ok := s.megaBikes[targetBike]; !ok {
-				panic("agent requested a bike that doesn't exist")
-			}
-			agent.SetBike(targetBike)
+			bikeless = append(bikeless, agent)
+		}
+	}
+	targetBikes := decideAll(s, bikeless, "ChangeBike", objects.IBaseBiker.ChangeBike, validChoice(s.megaBikes), func(objects.IBaseBiker) uuid.UUID {
+		return uuid.Nil
+	})
+	for i, agent := range bikeless {
+		if targetBikes[i] == uuid.Nil { // agent didn't specify bike
+			continue
 		}
+		agent.SetBike(targetBikes[i])
 	}
 }
 
 func (s *Server) unaliveAgents() {
-	for _, agent := range s.GetAgentMap() {
+	for _, agent := range s.sortedAgents() {
 		if agent.GetEnergyLevel() < 0 {
 			// fmt.Printf("Agent %s got game ended\n", id)
+			s.publishEvent(Event{Type: AgentDied, BikeID: agent.GetBike(), AgentID: agent.GetID(), Cause: DiedOfExhaustion})
 			s.RemoveAgent(agent)
 		}
 	}
@@ -527,7 +713,51 @@ func (s *Server) punishBikelessAgents() {
 	for id, agent := range s.GetAgentMap() {
 		if _, ok := s.megaBikeRiders[id]; !ok {
 			// Agent is not on a bike
-			agent.UpdateEnergyLevel(utils.LimboEnergyPenalty)
+			agent.UpdateEnergyLevel(s.cfg.Resources.LimboEnergyPenalty)
 		}
 	}
 }
+
+// asks a ruler for the weights of the votes of the riders of its bike (equal weights if its answer can't be used)
+func (s *Server) decideWeights(ruler objects.IBaseBiker, agents []objects.IBaseBiker, action utils.Action) map[uuid.UUID]float64 {
+	weights := callAgent(s, ruler, "DecideWeights", func() map[uuid.UUID]float64 {
+		return ruler.DecideWeights(action)
+	}, validDistribution[map[uuid.UUID]float64](idSet(agents)), func() map[uuid.UUID]float64 {
+		return evenSplit[map[uuid.UUID]float64](riderIDs(agents), float64(len(agents)))
+	})
+	s.checkWeightsPromise(ruler, weights)
+	return weights
+}
+
+// asks agents which of the agents asking to join their bike they accept (none for an agent whose answer can't be used)
+func (s *Server) decideJoining(agents []objects.IBaseBiker, pendingAgents []uuid.UUID) map[uuid.UUID]map[uuid.UUID]bool {
+	responses := decideAll(s, agents, "DecideJoining", func(agent objects.IBaseBiker) map[uuid.UUID]bool {
+		return agent.DecideJoining(slices.Clone(pendingAgents))
+	}, validJoining(pendingAgents), func(objects.IBaseBiker) map[uuid.UUID]bool {
+		return make(map[uuid.UUID]bool)
+	})
+	return byAgent(agents, responses)
+}
+
+// asks the riders of a bike how a lootbox should be split between them (evenly for an agent whose answer can't be used)
+func (s *Server) decideAllocations(agents []objects.IBaseBiker) map[uuid.UUID]voting.IdVoteMap {
+	allocations := decideAll(s, agents, "DecideAllocation", objects.IBaseBiker.DecideAllocation, validDistribution[voting.IdVoteMap](idSet(agents)), func(objects.IBaseBiker) voting.IdVoteMap {
+		return evenSplit[voting.IdVoteMap](riderIDs(agents), 1.0)
+	})
+	return byAgent(agents, allocations)
+}
+
+// the allocation the votes of the riders of a bike add up to (weighed by weigher, nil if they were weighed by the
+// server), or an even split between them if the votes can't be tallied
+func (s *Server) tallyAllocations(agents []objects.IBaseBiker, allocations map[uuid.UUID]voting.IdVoteMap, weights map[uuid.UUID]float64, weigher objects.IBaseBiker) voting.IdVoteMap {
+	voters := make(map[uuid.UUID]voting.IVoter, len(allocations))
+	for id, allocation := range allocations {
+		voters[id] = allocation
+	}
+	allocation, err := voting.CumulativeDist(voters, weights)
+	if err != nil {
+		s.recordUntallied(agents, weigher, "DecideAllocation", err)
+		return evenSplit[voting.IdVoteMap](riderIDs(agents), 1.0)
+	}
+	return allocation
+}
@@ -1,14 +1,17 @@
 package server
 
 import (
-	"SOMAS2023/internal/common/globals"
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/common/voting"
-	"encoding/json"
-	"os"
+	"math/rand"
+	"slices"
+	"time"
 
 	baseserver "github.com/MattSScott/basePlatformSOMAS/BaseServer"
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
 	"github.com/google/uuid"
 )
 
@@ -32,43 +35,143 @@ type IBaseBikerServer interface {
 	ProcessJoiningRequests(inLimbo []uuid.UUID)                                                                  // processes the joining requests
 	RunActionProcess()                                                                                           // runs the action (direction choice + pedalling) process for each bike
 	AwdiCollisionCheck()                                                                                         // checks for collisions between awdi and bikes
+	BikeCollisionCheck()                                                                                         // bounces the bikes that ran into each other as they moved
 	AddAgentToBike(agent objects.IBaseBiker)                                                                     // adds an agent to a bike (which also has some side effects on some server data structures)
 	FoundingInstitutions()                                                                                       // runs the founding institutions process
+	RunAmendments()                                                                                              // lets the riders of each bike vote to switch it to another governance
+	CheckRulerMandates()                                                                                         // holds no-confidence votes and the elections of rulers whose term is over
 	GetWinningDirection(finalVotes map[uuid.UUID]voting.LootboxVoteMap, weights map[uuid.UUID]float64) uuid.UUID // gets the winning direction according to the selected voting process
 	LootboxCheckAndDistributions()                                                                               // checks for collision between bike and lootbox and runs the distribution process
 	ResetGameState()                                                                                             // resets game state (at the beginning of a new round)
 	GetDeadAgents() map[uuid.UUID]objects.IBaseBiker                                                             // returns the map of dead agents
+	Simulate() GameStatistics                                                                                    // runs the whole simulation without any output, returning its statistics
+	Run(sink DumpSink) (GameStatistics, error)                                                                   // runs the whole simulation, streaming its game dump to the sink
+	RunWithOutput() error                                                                                        // runs the whole simulation, writing its game dump and statistics report to the scenario's output directory
+	Subscribe(subscriber EventSubscriber)                                                                        // subscribes to the institutional decisions taken from now on
+	Offences() []Offence                                                                                         // lists the agent callbacks that panicked or returned an invalid output
 }
 
 type Server struct {
 	baseserver.BaseServer[objects.IBaseBiker]
 	lootBoxes map[uuid.UUID]objects.ILootBox
 	megaBikes map[uuid.UUID]objects.IMegaBike
+	index     spatialIndex       // where the lootboxes and bikes are, for collisions and proximity queries
+	motion    physics.Model      // how bikes and the awdi move (physics.model)
+	drafting  map[uuid.UUID]bool // the bikes drafting behind another one as the bikes move
 	// megaBikeRiders is a mapping from Agent ID -> ID of the bike that they are riding
 	// helps with efficiently managing ridership status
-	megaBikeRiders  map[uuid.UUID]uuid.UUID // maps riders to their bike
-	awdi            objects.IAwdi
+	megaBikeRiders  map[uuid.UUID]uuid.UUID          // maps riders to their bike
+	awdis           []objects.IAwdi                  // the awdis of the scenario (awdi.fleet), in the order they spawned
 	deadAgents      map[uuid.UUID]objects.IBaseBiker // map of dead agents (used for respawning at the end of a round )
 	foundingChoices map[uuid.UUID]utils.Governance
 	globalRuleCache *objects.GlobalRuleCache
+	cfg             *config.Config
+	rng             *rand.Rand // every random draw of the run comes from here (agents get sources seeded from it)
+	// the teams agents are split between (a nil function spawns base bikers)
+	agentInitFunctions []AgentInitFunction
+	bikeEvents         []BikeEventDump                 // agents joining, leaving or kicked off bikes since the last game state dump
+	regimes            map[uuid.UUID][]RegimeDump      // the governances each bike has had this round
+	terms              map[uuid.UUID]rulerTerm         // the term the ruler of each bike is serving
+	councils           map[uuid.UUID][]uuid.UUID       // the council of each bike run by one, its chair (the ruler) first
+	manifestos         map[uuid.UUID]objects.Manifesto // the manifesto the ruler of each bike was elected on (with nominations)
+	kickedOff          map[uuid.UUID]map[uuid.UUID]int // the iteration each agent was last kicked off each bike in
+	events             EventBus                        // institutional decisions, as they're taken
+	gameLoop           int                             // the game loop (and iteration within it) events are stamped with
+	iteration          int
+	view               *GameStateView // the (read-only) game state agents are given
+	offences           []Offence      // agent callbacks that panicked or returned an invalid output
 }
 
+// ServerOption sets up part of a server as it's created by NewServer
+type ServerOption func(s *Server)
+
+// WithConfig runs the server with the parameters of a (possibly loaded from file) scenario.
+// The server works on its own copy, so the same scenario can be shared between servers.
+func WithConfig(cfg *config.Config) ServerOption {
+	return func(s *Server) {
+		s.cfg = cfg.Clone()
+	}
+}
+
+// WithAgentInitFunctions sets the teams the agents are split between (passing nil, or no
+// functions at all, spawns base bikers) when the scenario has no agent mix
+func WithAgentInitFunctions(initFunctions ...AgentInitFunction) ServerOption {
+	return func(s *Server) {
+		if len(initFunctions) == 0 {
+			initFunctions = []AgentInitFunction{nil}
+		}
+		s.agentInitFunctions = slices.Clone(initFunctions)
+	}
+}
+
+// WithEventSubscribers subscribes to the events of the server from its very first game loop
+func WithEventSubscribers(subscribers ...EventSubscriber) ServerOption {
+	return func(s *Server) {
+		for _, subscriber := range subscribers {
+			s.events.Subscribe(subscriber)
+		}
+	}
+}
+
+// NewServer creates a server running with the default simulation parameters and teams, unless
+// the options say otherwise. Servers share no state, so any number of them can run at once.
+func NewServer(options ...ServerOption) IBaseBikerServer {
+	s := &Server{
+		cfg:                config.DefaultConfig(),
+		agentInitFunctions: DefaultAgentInitFunctions(),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// generates a server running with the default simulation parameters
 func GenerateServer() IBaseBikerServer {
-	return &Server{}
+	return NewServer()
+}
+
+// generates a server running with the parameters of a (possibly loaded from file) scenario
+func GenerateServerWithConfig(cfg *config.Config) IBaseBikerServer {
+	return NewServer(WithConfig(cfg))
 }
 
 func (s *Server) Initialize(iterations int) {
+	// a zero-valued server runs with the default parameters
+	if s.cfg == nil {
+		s.cfg = config.DefaultConfig()
+	}
+	if s.agentInitFunctions == nil {
+		s.agentInitFunctions = DefaultAgentInitFunctions()
+	}
+	// an unseeded run records the seed it picked in the server's copy of the scenario, so that it can be replayed
+	if s.cfg.Simulation.Seed == nil {
+		s.cfg.Simulation.Seed = config.Seed(time.Now().UnixNano())
+	}
+	s.rng = rand.New(rand.NewSource(*s.cfg.Simulation.Seed))
+	s.view = &GameStateView{}
 	s.BaseServer = *baseserver.CreateServer[objects.IBaseBiker](s.GetAgentGenerators(), iterations)
 	s.lootBoxes = make(map[uuid.UUID]objects.ILootBox)
 	s.megaBikes = make(map[uuid.UUID]objects.IMegaBike)
+	s.index = newSpatialIndex(s.cfg.Environment)
+	s.motion = physics.NewModel(s.cfg.Physics.Model)
 	s.megaBikeRiders = make(map[uuid.UUID]uuid.UUID)
 	s.deadAgents = make(map[uuid.UUID]objects.IBaseBiker)
-	s.awdi = objects.GetIAwdi()
+	s.regimes = make(map[uuid.UUID][]RegimeDump)
+	s.terms = make(map[uuid.UUID]rulerTerm)
+	s.councils = make(map[uuid.UUID][]uuid.UUID)
+	s.manifestos = make(map[uuid.UUID]objects.Manifesto)
+	s.kickedOff = make(map[uuid.UUID]map[uuid.UUID]int)
+	s.gameLoop, s.iteration = -1, -1
+	s.spawnAwdis()
 	s.globalRuleCache = objects.GenerateGlobalRuleCache()
 	s.PopulateGlobalRuleCache()
 	s.replenishLootBoxes()
 	s.replenishMegaBikes()
-	s.awdi.InjectGameState(s)
+	for _, awdi := range s.awdis {
+		awdi.InjectGameState(s)
+	}
+	s.refreshGameStateView()
 }
 
 // func (s *Server) Initialize(iterations int) IBaseBikerServer {
@@ -89,7 +192,7 @@ func (s *Server) Initialize(iterations int) {
 func (s *Server) PopulateGlobalRuleCache() {
 	// generate 100 rules split across N actions
 	nActions := int(objects.MAX_ACTIONS)
-	rulesPerAction := int(*globals.GlobalRuleCount / nActions)
+	rulesPerAction := int(s.cfg.Simulation.GlobalRuleCount / nActions)
 
 	for i := 0; i < nActions; i++ {
 		for j := 0; j < rulesPerAction; j++ {
@@ -98,6 +201,10 @@ func (s *Server) PopulateGlobalRuleCache() {
 	}
 }
 
+func (s *Server) GetConfig() config.Config {
+	return *s.cfg
+}
+
 func (s *Server) ViewGlobalRuleCache() map[uuid.UUID]*objects.Rule {
 	return s.globalRuleCache.ViewGlobalRuleSet()
 }
@@ -127,15 +234,21 @@ func (s *Server) AddAgentToBike(agent objects.IBaseBiker) {
 		agent.ToggleOnBike()
 	}
 
-	// set agent on desired bike
-	bikeId := agent.ChangeBike()
+	// set agent on desired bike (the one the server assigned it, should its choice be unusable, and none should
+	// the agent not even be able to tell which that is)
+	bikeId := callAgent(s, agent, "ChangeBike", agent.ChangeBike, validChoice(s.megaBikes), func() uuid.UUID {
+		return callAgent(s, agent, "GetBike", agent.GetBike, validChoice(s.megaBikes), func() uuid.UUID {
+			return uuid.Nil
+		})
+	})
 	allBikes := s.GetMegaBikes()
 	requestedBike := allBikes[bikeId]
-	if bikeId == uuid.Nil || len(requestedBike.GetAgents()) == 8 {
+	if bikeId == uuid.Nil || len(requestedBike.GetAgents()) == s.cfg.Environment.BikersOnBike {
 		return
 	}
 	s.megaBikes[bikeId].AddAgent(agent)
 	s.megaBikeRiders[agent.GetID()] = bikeId
+	s.recordBikeEvent(JoinedBike, agent.GetID(), bikeId)
 	if !agent.GetBikeStatus() {
 		agent.ToggleOnBike()
 	}
@@ -147,119 +260,58 @@ func (s *Server) RemoveAgentFromBike(agent objects.IBaseBiker) {
 	agent.ToggleOnBike()
 
 	// get new destination for agent
-	targetBike := agent.ChangeBike()
-	if _, ok := s.megaBikes[targetBike]; !ok {
-		panic("agent requested a bike that doesn't exist")
-	}
+	targetBike := callAgent(s, agent, "ChangeBike", agent.ChangeBike, validChoice(s.megaBikes), func() uuid.UUID {
+		return uuid.Nil
+	})
 	agent.SetBike(targetBike)
 
 	delete(s.megaBikeRiders, agent.GetID())
 }
 
-func (s *Server) GetDeadAgents() map[uuid.UUID]objects.IBaseBiker {
-	return s.deadAgents
+func (s *Server) Subscribe(subscriber EventSubscriber) {
+	s.events.Subscribe(subscriber)
 }
 
-// func (s *Server) outputResults(gameStates [][]GameStateDump) {
-// 	stats := CalculateStatistics(gameStates)
-
-// 	lifeSpans := stats.Average.AgentLifetime
-
-// 	avg := 0.0
-// 	size := float64(len(lifeSpans))
-
-// 	for _, val := range lifeSpans {
-// 		avg += val
-// 	}
-
-// 	avg /= size
-// 	fmt.Println(avg)
-
-// 	f, err := os.Create("output.txt") // creating...
-// 	if err != nil {
-// 		fmt.Printf("error creating file: %v", err)
-// 		return
-// 	}
-// 	defer f.Close()
-// 	_, err = f.WriteString(fmt.Sprintf("%f", avg))
-// 	if err != nil {
-// 		fmt.Printf("error writing string: %v", err)
-// 	}
-
-// 	// statisticsJson, _ := json.MarshalIndent(stats.Average.AgentLifetime, "", "    ")
-// 	// fmt.Println("Average Statistics:\n" + string(statisticsJson))
-// }
-
-// func (s *Server) outputResults(gameStates [][]GameStateDump) {
-// 	statistics := CalculateStatistics(gameStates)
-
-// 	statisticsJson, err := json.MarshalIndent(statistics.Average, "", "    ")
-// 	if err != nil {
-// 		panic(err)
-// 	}
-// 	fmt.Println("Average Statistics:\n" + string(statisticsJson))
-
-// 	file, err := os.Create("statistics.xlsx")
-// 	if err != nil {
-// 		panic(err)
-// 	}
-// 	defer file.Close()
-// 	if err := statistics.ToSpreadsheet().Write(file); err != nil {
-// 		panic(err)
-// 	}
-
-// 	file, err = os.Create("game_dump.json")
-// 	if err != nil {
-// 		panic(err)
-// 	}
-// 	defer file.Close()
-// 	encoder := json.NewEncoder(file)
-// 	encoder.SetIndent("", "    ")
-// 	if err := encoder.Encode(gameStates); err != nil {
-// 		panic(err)
-// 	}
-// }
-
-func (s *Server) outputSimulationResult(dump SimplifiedGameStateDump) {
-
-	relativePath, _ := os.Getwd()
-	gameDumpPath := "/gameDumps/debug/"
-	gameDumpHash := uuid.New().String()
-
-	gameDumpFile := relativePath + gameDumpPath + gameDumpHash + ".json"
-
-	file, err := os.Create(gameDumpFile)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "    ")
-	if err := encoder.Encode(dump); err != nil {
-		panic(err)
-	}
+func (s *Server) GetDeadAgents() map[uuid.UUID]objects.IBaseBiker {
+	return s.deadAgents
 }
 
 // had to override to address the fact that agents only have access to the game dump
 // version of agents, so if the recipients are set to be those it will panic as they
 // can't call the handler functions
 func (s *Server) RunMessagingSession() {
-	agentArray := s.GenerateAgentArrayFromMap()
+	s.refreshGameStateView()
+	agentArray := s.sortedAgents()
+	// agents are shown the others as they are in the view, not the live agents
+	viewedAgents := make([]objects.IBaseBiker, len(agentArray))
+	for i, agent := range agentArray {
+		viewedAgents[i] = s.view.Agents[agent.GetID()]
+	}
 
-	for _, agent := range s.GetAgentMap() {
-		allMessages := agent.GetAllMessages(agentArray)
+	for _, agent := range agentArray {
+		allMessages := callAgent(s, agent, "GetAllMessages", func() []messaging.IMessage[objects.IBaseBiker] {
+			return agent.GetAllMessages(viewedAgents)
+		}, nil, func() []messaging.IMessage[objects.IBaseBiker] {
+			return nil
+		})
 		for _, msg := range allMessages {
-			recipients := msg.GetRecipients()
-			// make recipient list with actual agents
-			usableRecipients := make([]objects.IBaseBiker, len(recipients))
-			for i, recipient := range recipients {
-				usableRecipients[i] = s.GetAgentMap()[recipient.GetID()]
+			recipients := callAgent(s, agent, "GetRecipients", msg.GetRecipients, validRecipients, func() []objects.IBaseBiker {
+				return nil
+			})
+			// make recipient list with actual agents (messages to agents that have since died are dropped)
+			usableRecipients := make([]objects.IBaseBiker, 0, len(recipients))
+			for _, recipient := range recipients {
+				if recip, ok := s.GetAgentMap()[recipient.GetID()]; ok {
+					usableRecipients = append(usableRecipients, recip)
+				}
 			}
 			for _, recip := range usableRecipients {
 				if agent.GetID() == recip.GetID() {
 					continue
 				}
-				msg.InvokeMessageHandler(recip)
+				callAgentAction(s, recip, "HandleMessage", func() {
+					msg.InvokeMessageHandler(recip)
+				})
 			}
 		}
 	}
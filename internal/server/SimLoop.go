@@ -1,12 +1,15 @@
 package server
 
 import (
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/common/voting"
 	"cmp"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"slices"
 
 	"github.com/google/uuid"
@@ -14,17 +17,40 @@ import (
 
 // the simulation loop represents a round
 func (s *Server) RunSimLoop(iterations int, gameState *SimplifiedGameStateDump) {
+	iterationDump := s.runSimLoop(iterations, nil)
+	gameState.AddIterationToGameState(iterationDump)
+}
 
+// runs a round, returning its dump. The full game state before its first iteration, then after each of them, is handed
+// to record (if it isn't nil) as soon as it's taken.
+func (s *Server) runSimLoop(iterations int, record func(gameState GameStateDump)) *SimplifiedIterationDump {
+
+	s.bikeEvents = nil
+	clear(s.regimes)
+	clear(s.terms)
+	clear(s.councils)
+	clear(s.manifestos)
+	clear(s.kickedOff)
+	s.gameLoop++
+	s.iteration = -1
 	s.ResetGameState()
 	s.FoundingInstitutions()
 
 	iterationDump := s.GenerateIterationDump()
 
 	// run this for n iterations
-	// gameStates := []GameStateDump{s.NewGameStateDump(-1)}
+	if record != nil {
+		record(s.NewGameStateDump(-1))
+	}
+	s.bikeEvents = nil
 	for i := 0; i < iterations; i++ {
+		s.iteration = i
 		s.RunRoundLoop(iterationDump)
-		// gameStates = append(gameStates, s.NewGameStateDump(i))
+		if record != nil {
+			record(s.NewGameStateDump(i))
+		}
+		// events are only kept until the next dump
+		s.bikeEvents = nil
 	}
 
 	avgKicks := 0.0
@@ -39,18 +65,17 @@ func (s *Server) RunSimLoop(iterations int, gameState *SimplifiedGameStateDump)
 
 	iterationDump.AverageKickOffs = avgKicks / nBikes
 
-	gameState.AddIterationToGameState(iterationDump)
-
 	for _, bike := range s.GetMegaBikes() {
 		bike.ResetKickedOutCount()
 	}
+	return iterationDump
 }
 
 // remove all agents from bikes, respawn dead agents (if required), replenish energy (if required), reset points (if required)
 // replenish environment objects
 func (s *Server) ResetGameState() {
 	// kick everyone off bikes
-	for _, agent := range s.GetAgentMap() {
+	for _, agent := range s.sortedAgents() {
 		if agent.GetBike() != uuid.Nil {
 			s.RemoveAgentFromBike(agent)
 		} else if agent.GetBikeStatus() {
@@ -59,14 +84,14 @@ func (s *Server) ResetGameState() {
 	}
 
 	// respawn people who died in previous round (conditional)
-	if utils.RespawnEveryRound && utils.ReplenishEnergyEveryRound {
+	if s.cfg.Environment.RespawnEveryRound && s.cfg.Environment.ReplenishEnergyEveryRound {
 		for _, agent := range s.deadAgents {
 			s.AddAgent(agent)
 		}
 	}
 
 	// replenish energy (conditional)
-	if utils.ReplenishEnergyEveryRound {
+	if s.cfg.Environment.ReplenishEnergyEveryRound {
 		for _, agent := range s.GetAgentMap() {
 			agent.UpdateEnergyLevel(1.0)
 		}
@@ -76,7 +101,7 @@ func (s *Server) ResetGameState() {
 	clear(s.deadAgents)
 
 	// zero the points (conditional)
-	if utils.ResetPointsEveryRound {
+	if s.cfg.Environment.ResetPointsEveryRound {
 		for _, agent := range s.GetAgentMap() {
 			agent.ResetPoints()
 		}
@@ -101,11 +126,15 @@ func (s *Server) FoundingInstitutions() {
 	s.RunMessagingSession()
 
 	// check which governance method is chosen for each biker
-	s.foundingChoices = make(map[uuid.UUID]utils.Governance)
-	for id, agent := range s.GetAgentMap() {
+	if s.cfg.Governance.FoundingMix.IsSet() {
+		s.foundingChoices = s.dealFoundingChoices()
+	} else {
 		// collect choice from each agent
-		choice := agent.DecideGovernance()
-		s.foundingChoices[id] = choice
+		agents := s.sortedAgents()
+		choices := decideAll(s, agents, "DecideGovernance", objects.IBaseBiker.DecideGovernance, validGovernance, func(objects.IBaseBiker) utils.Governance {
+			return utils.Democracy
+		})
+		s.foundingChoices = byAgent(agents, choices)
 	}
 
 	// tally the choices
@@ -116,8 +145,13 @@ func (s *Server) FoundingInstitutions() {
 	govBikes := make(map[utils.Governance][]uuid.UUID)
 	bikesUsed := make([]uuid.UUID, 0)
 
-	for governanceMethod, numBikers := range foundingTotals {
-		megaBikesNeeded := int(math.Ceil(float64(numBikers) / float64(utils.BikersOnBike)))
+	// governances are visited in a fixed order, as each of them draws random bikes
+	for governanceMethod := utils.Governance(0); governanceMethod < utils.Invalid; governanceMethod++ {
+		numBikers, ok := foundingTotals[governanceMethod]
+		if !ok {
+			continue
+		}
+		megaBikesNeeded := int(math.Ceil(float64(numBikers) / float64(s.cfg.Environment.BikersOnBike)))
 		govBikes[governanceMethod] = make([]uuid.UUID, 0, megaBikesNeeded)
 		// get bikes for this governance (enough to accommodate all bikers who chose this governance method)
 		for i := 0; i < megaBikesNeeded; i++ {
@@ -135,12 +169,14 @@ func (s *Server) FoundingInstitutions() {
 					// set the governance
 					bikeObj := s.GetMegaBikes()[bike]
 					bikeObj.SetGovernance(governanceMethod)
+					s.recordRegime(bikeObj)
 				}
 			}
 		}
 	}
 
-	for agent, governance := range s.foundingChoices {
+	for _, agent := range utils.SortedIDs(s.foundingChoices) {
+		governance := s.foundingChoices[agent]
 		// randomly select a biker from the bikers who chose this governance method
 		// add that biker to a megabike
 		// if there are more bikers for a governance method than there are seats, then evenly distribute them across megabikes
@@ -164,31 +200,193 @@ func (s *Server) FoundingInstitutions() {
 		agentInt.ToggleOnBike()
 		s.AddAgentToBike(agentInt)
 	}
-	// run election process for Leadership and Dictatorship bikes
-	for _, bike := range s.GetMegaBikes() {
+	// run election process for ruler-led bikes
+	s.refreshGameStateView()
+	for _, bike := range s.sortedMegaBikes() {
 		gov := bike.GetGovernance()
 		agents := bike.GetAgents()
-		if (gov == utils.Leadership || gov == utils.Dictatorship) && len(agents) != 0 {
-			ruler := s.RulerElection(agents, gov)
-			bike.SetRuler(ruler)
+		if rulerLed(gov) && len(agents) != 0 {
+			s.holdRulerElection(bike, gov)
 		}
 	}
 
 }
 
+// assigns founding governances to randomly chosen agents in the proportions imposed by the scenario
+// (the largest remainders get the agents left over by rounding down)
+func (s *Server) dealFoundingChoices() map[uuid.UUID]utils.Governance {
+	agents := s.sortedAgents()
+	s.rng.Shuffle(len(agents), func(i, j int) {
+		agents[i], agents[j] = agents[j], agents[i]
+	})
+
+	proportions := s.cfg.Governance.FoundingMix.Proportions()
+	governances := make([]utils.Governance, 0, utils.Invalid)
+	for governance := utils.Democracy; governance < utils.Invalid; governance++ {
+		governances = append(governances, governance)
+	}
+	counts := make(map[utils.Governance]int, len(governances))
+	assigned := 0
+	for _, governance := range governances {
+		counts[governance] = int(proportions[governance] * float64(len(agents)))
+		assigned += counts[governance]
+	}
+	remainder := func(governance utils.Governance) float64 {
+		exact := proportions[governance] * float64(len(agents))
+		return exact - math.Floor(exact)
+	}
+	byRemainder := slices.Clone(governances)
+	slices.SortStableFunc(byRemainder, func(a, b utils.Governance) int {
+		return cmp.Compare(remainder(b), remainder(a))
+	})
+	for i := 0; assigned < len(agents); i++ {
+		counts[byRemainder[i%len(byRemainder)]]++
+		assigned++
+	}
+
+	choices := make(map[uuid.UUID]utils.Governance, len(agents))
+	next := 0
+	for _, governance := range governances {
+		for i := 0; i < counts[governance]; i++ {
+			choices[agents[next].GetID()] = governance
+			next++
+		}
+	}
+	return choices
+}
+
+// Start runs the simulation with RunWithOutput, only reporting errors (as the platform's Start can't return them)
 func (s *Server) Start() {
-	fmt.Printf("Server initialised with %d agents \n\n", len(s.GetAgentMap()))
-	// gameStates := make([][]GameStateDump, 0, s.GetIterations())
+	if err := s.RunWithOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "error running simulation: %v\n", err)
+	}
+}
+
+// RunWithOutput runs the simulation, streaming its game dump to a new file in the output directory
+// given by the scenario (if any), then writing its statistics report next to it. The scenario the run
+// was played with (its seed included) is written there first, so that the run can be replayed from it.
+func (s *Server) RunWithOutput() (err error) {
+	output := s.cfg.Output
+	if output.Directory == "" {
+		_, err := s.Run(nil)
+		return err
+	}
+	// the scenario, dump, event log and report of a run share its name
+	path := filepath.Join(output.Directory, uuid.New().String())
+	if err := config.SaveConfig(path+"_scenario.yaml", s.cfg); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote scenario (seed %d) to %s_scenario.yaml \n", *s.cfg.Simulation.Seed, path)
+
+	eventCounter := NewEventCounter()
+	s.Subscribe(eventCounter)
+	if output.EventLog {
+		eventLog, createErr := createEventWriter(path + "_events.ndjson")
+		if createErr != nil {
+			return createErr
+		}
+		s.Subscribe(eventLog)
+		defer func() {
+			if closeErr := eventLog.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		fmt.Printf("Writing event log to %s_events.ndjson \n", path)
+	}
+
+	var sink DumpSink
+	if output.DumpLevel != config.NoDump {
+		ndjsonSink, err := CreateNDJSONSink(path + ".ndjson")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Writing game dump to %s.ndjson \n", path)
+		sink = ndjsonSink
+	}
+	statistics, err := s.Run(sink)
+	if err != nil {
+		return err
+	}
+
+	report := NewStatisticsReport(statistics, *s.cfg.Simulation.Seed, output.Statistics)
+	report.Events = eventCounter.Counts()
+	written, err := report.WriteFiles(path, output.Statistics)
+	for _, reportPath := range written {
+		fmt.Printf("Wrote statistics report to %s \n", reportPath)
+	}
+	return err
+}
+
+// Run runs the simulation, writing each game loop to the sink (if there is one) as soon as it's over,
+// at the dump level given by the scenario. The sink is closed once the run ends, even if writing to it failed.
+// The statistics of the run are only calculated when the scenario asks for a statistics report.
+func (s *Server) Run(sink DumpSink) (statistics GameStatistics, err error) {
+	if sink != nil {
+		defer func() {
+			if closeErr := sink.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	fmt.Printf("Server initialised with %d agents (seed %d) \n\n", len(s.GetAgentMap()), *s.cfg.Simulation.Seed)
+	return s.playGameLoops(sink, s.cfg.Output.Statistics.IsSet(), false)
+}
+
+// Simulate runs the whole simulation in-process, without printing its progress or writing any output,
+// and returns its statistics. Game states aren't held in memory, their statistics being added as they're taken.
+func (s *Server) Simulate() GameStatistics {
+	// without a sink, nothing can fail
+	statistics, _ := s.playGameLoops(nil, true, true)
+	return statistics
+}
 
-	gameState := NewSimplifiedGameStateDump()
+// plays every game loop of the run, writing each one to the sink (if there is one) as soon as it's over, at the
+// dump level given by the scenario, and calculating the statistics of the run if asked to. A quiet run doesn't
+// print its progress. The statistics are added one game state at a time, so the game states of a game loop are
+// only held together when they're written to a full dump.
+func (s *Server) playGameLoops(sink DumpSink, calculateStatistics bool, quiet bool) (GameStatistics, error) {
+	accumulator := NewStatisticsAccumulator()
+	fullDump := sink != nil && s.cfg.Output.DumpLevel == config.FullDump
+
+	var gameStates []GameStateDump
+	var record func(gameState GameStateDump)
+	if fullDump || calculateStatistics {
+		record = func(gameState GameStateDump) {
+			if calculateStatistics {
+				accumulator.AddGameState(gameState)
+			}
+			if fullDump {
+				gameStates = append(gameStates, gameState)
+			}
+		}
+	}
 
 	s.deadAgents = make(map[uuid.UUID]objects.IBaseBiker)
 	for i := 0; i < s.GetIterations(); i++ {
-		fmt.Printf("Game Loop %d running... \n \n", i)
-		// gameStates = append(gameStates, s.RunSimLoop(utils.RoundIterations))
-		s.RunSimLoop(utils.RoundIterations, gameState)
+		if !quiet {
+			fmt.Printf("Game Loop %d running... \n \n", i)
+		}
+		gameStates = nil
+		iterationDump := s.runSimLoop(s.cfg.Simulation.RoundIterations, record)
+		if calculateStatistics {
+			accumulator.EndRound()
+		}
+		if sink != nil {
+			var err error
+			if fullDump {
+				err = sink.WriteGameStates(gameStates)
+			} else {
+				err = sink.WriteIteration(iterationDump)
+			}
+			if err != nil {
+				return GameStatistics{}, fmt.Errorf("game loop %d: %w", i, err)
+			}
+		}
 		s.RunMessagingSession()
-		fmt.Printf("Game Loop %d completed.\n", i)
+		if !quiet {
+			fmt.Printf("Game Loop %d completed.\n", i)
+		}
 	}
-	s.outputSimulationResult(*gameState)
+	return accumulator.Statistics(), nil
 }
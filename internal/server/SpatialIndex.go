@@ -0,0 +1,89 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/spatial"
+	"SOMAS2023/internal/common/utils"
+
+	"github.com/google/uuid"
+)
+
+// spatialIndex indexes the positions of the lootboxes and bikes of the world (environment.spatial_cell_size), so that
+// collisions and proximity queries only visit the objects near a position. The server files objects as they spawn,
+// moves bikes after MovePhysicsObject and takes objects out as they're removed (lootboxes never move). On a toroidal
+// world (environment.boundary: wrap) distances are measured across the edges of the grid, as the physics engine does.
+type spatialIndex struct {
+	lootBoxes *spatial.Grid
+	megaBikes *spatial.Grid
+}
+
+func newSpatialIndex(world config.EnvironmentConfig) spatialIndex {
+	if world.Boundary == config.WrapBoundary {
+		return spatialIndex{
+			lootBoxes: spatial.NewWrappingGrid(world.SpatialCellSize, world.GridWidth, world.GridHeight),
+			megaBikes: spatial.NewWrappingGrid(world.SpatialCellSize, world.GridWidth, world.GridHeight),
+		}
+	}
+	return spatialIndex{lootBoxes: spatial.NewGrid(world.SpatialCellSize), megaBikes: spatial.NewGrid(world.SpatialCellSize)}
+}
+
+func (i spatialIndex) clone() spatialIndex {
+	return spatialIndex{lootBoxes: i.lootBoxes.Clone(), megaBikes: i.megaBikes.Clone()}
+}
+
+// the nearest of the indexed objects accepted (the zero value if there's none)
+func nearest[T any](grid *spatial.Grid, objectMap map[uuid.UUID]T, position utils.Coordinates, accept func(T) bool) T {
+	var acceptID func(uuid.UUID) bool
+	if accept != nil {
+		acceptID = func(id uuid.UUID) bool {
+			return accept(objectMap[id])
+		}
+	}
+	id, _ := grid.Nearest(position, acceptID)
+	return objectMap[id]
+}
+
+// the indexed objects at most radius away from a position
+func inRadius[T any](grid *spatial.Grid, objectMap map[uuid.UUID]T, position utils.Coordinates, radius float64) map[uuid.UUID]T {
+	ids := grid.InRadius(position, radius)
+	found := make(map[uuid.UUID]T, len(ids))
+	for _, id := range ids {
+		found[id] = objectMap[id]
+	}
+	return found
+}
+
+func (s *Server) GetNearestLootBox(position utils.Coordinates, accept func(objects.ILootBox) bool) objects.ILootBox {
+	return nearest(s.index.lootBoxes, s.lootBoxes, position, accept)
+}
+
+func (s *Server) GetLootBoxesInRadius(position utils.Coordinates, radius float64) map[uuid.UUID]objects.ILootBox {
+	return inRadius(s.index.lootBoxes, s.lootBoxes, position, radius)
+}
+
+func (s *Server) GetNearestMegaBike(position utils.Coordinates, accept func(objects.IMegaBike) bool) objects.IMegaBike {
+	return nearest(s.index.megaBikes, s.megaBikes, position, accept)
+}
+
+func (s *Server) GetMegaBikesInRadius(position utils.Coordinates, radius float64) map[uuid.UUID]objects.IMegaBike {
+	return inRadius(s.index.megaBikes, s.megaBikes, position, radius)
+}
+
+// agents query the index as it was when their view was refreshed, and are given the dumps of what it finds
+
+func (v *GameStateView) GetNearestLootBox(position utils.Coordinates, accept func(objects.ILootBox) bool) objects.ILootBox {
+	return nearest(v.index.lootBoxes, v.lootBoxes, position, accept)
+}
+
+func (v *GameStateView) GetLootBoxesInRadius(position utils.Coordinates, radius float64) map[uuid.UUID]objects.ILootBox {
+	return inRadius(v.index.lootBoxes, v.lootBoxes, position, radius)
+}
+
+func (v *GameStateView) GetNearestMegaBike(position utils.Coordinates, accept func(objects.IMegaBike) bool) objects.IMegaBike {
+	return nearest(v.index.megaBikes, v.megaBikes, position, accept)
+}
+
+func (v *GameStateView) GetMegaBikesInRadius(position utils.Coordinates, radius float64) map[uuid.UUID]objects.IMegaBike {
+	return inRadius(v.index.megaBikes, v.megaBikes, position, radius)
+}
@@ -2,40 +2,57 @@ package server
 
 import (
 	"SOMAS2023/internal/clients/teamSOSA"
-	"SOMAS2023/internal/common/globals"
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/registry"
 	"SOMAS2023/internal/common/utils"
 	"fmt"
+	"math/rand"
+	"os"
 
 	baseserver "github.com/MattSScott/basePlatformSOMAS/BaseServer"
 	"github.com/google/uuid"
 )
 
-type AgentInitFunction func(baseBiker *objects.BaseBiker) objects.IBaseBiker
+type AgentInitFunction = registry.InitFunction
 
-// COHORT EXPERIMENTS
-var AgentInitFunctions = []AgentInitFunction{
-	teamSOSA.GetBiker, // Team SOSA
-}
+// DefaultAgentInitFunctions returns the teams a server spawns unless it's given others through WithAgentInitFunctions
+func DefaultAgentInitFunctions() []AgentInitFunction {
+	// COHORT EXPERIMENTS
+	return []AgentInitFunction{
+		teamSOSA.GetBiker, // Team SOSA
+	}
 
-// BASEBIKER EXPERIMENTS (uncomment this and comment out the above to run base biker experiments)
-// var AgentInitFunctions = []AgentInitFunction{
-// 	nil,
-// }
+	// BASEBIKER EXPERIMENTS (uncomment this and comment out the above to run base biker experiments)
+	// return []AgentInitFunction{
+	// 	nil,
+	// }
+}
 
+// GetAgentGenerators spawns the agent mix of the scenario if it has one, and otherwise splits
+// the agents evenly between the server's teams (the remainder being base bikers)
 func (s *Server) GetAgentGenerators() []baseserver.AgentGeneratorCountPair[objects.IBaseBiker] {
+	if mix := s.cfg.Simulation.AgentMix; mix.IsSet() {
+		agentGenerators := make([]baseserver.AgentGeneratorCountPair[objects.IBaseBiker], 0, len(mix))
+		for _, team := range mix {
+			// the mix is checked as the scenario is loaded, so this only happens to servers set up by hand
+			initFunction, err := registry.Lookup(team.Team)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v: spawning base bikers instead\n", err)
+			}
+			agentGenerators = append(agentGenerators, baseserver.MakeAgentGeneratorCountPair(s.BikerAgentGenerator(initFunction), team.Count))
+		}
+		return agentGenerators
+	}
 
-	bikersPerTeam := *globals.BikerAgentCount / (len(AgentInitFunctions))
-	extraBaseBikers := *globals.BikerAgentCount % (len(AgentInitFunctions))
-
-	fmt.Println(bikersPerTeam, extraBaseBikers)
+	bikersPerTeam := s.cfg.Simulation.BikerAgentCount / (len(s.agentInitFunctions))
+	extraBaseBikers := s.cfg.Simulation.BikerAgentCount % (len(s.agentInitFunctions))
 
 	agentGenerators := []baseserver.AgentGeneratorCountPair[objects.IBaseBiker]{
 		// Spawn base bikers
 		baseserver.MakeAgentGeneratorCountPair(s.BikerAgentGenerator(nil), extraBaseBikers),
 	}
 
-	for _, initFunction := range AgentInitFunctions {
+	for _, initFunction := range s.agentInitFunctions {
 		agentGenerators = append(agentGenerators, baseserver.MakeAgentGeneratorCountPair(s.BikerAgentGenerator(initFunction), bikersPerTeam))
 	}
 	return agentGenerators
@@ -43,7 +60,11 @@ func (s *Server) GetAgentGenerators() []baseserver.AgentGeneratorCountPair[objec
 
 func (s *Server) BikerAgentGenerator(initFunc func(baseBiker *objects.BaseBiker) objects.IBaseBiker) func() objects.IBaseBiker {
 	return func() objects.IBaseBiker {
-		baseBiker := objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), s)
+		// each agent gets its own random source, seeded from the server's, so that its draws don't
+		// depend on the order in which agents are asked to act. Agents only get a read-only view of the
+		// game, so that the server makes every change to it.
+		rng := rand.New(rand.NewSource(s.rng.Int63()))
+		baseBiker := objects.GetBaseBiker(utils.GenerateRandomColour(rng), uuid.Nil, s.view, rng)
 		if initFunc == nil {
 			return baseBiker
 		} else {
@@ -53,27 +74,47 @@ func (s *Server) BikerAgentGenerator(initFunc func(baseBiker *objects.BaseBiker)
 }
 
 func (s *Server) spawnLootBox() {
-	lootBox := objects.GetLootBox()
+	lootBox := objects.GetLootBox(s.cfg, s.rng)
 	s.lootBoxes[lootBox.GetID()] = lootBox
+	s.index.lootBoxes.Insert(lootBox.GetID(), lootBox.GetPosition())
 }
 
 // replenishes lootboxes up to the externally set count
 func (s *Server) replenishLootBoxes() {
-	count := globals.LootBoxCount - len(s.lootBoxes)
+	count := s.cfg.LootBoxCount() - len(s.lootBoxes)
 	for i := 0; i < count; i++ {
 		s.spawnLootBox()
 	}
 }
 
 func (s *Server) spawnMegaBike() {
-	megaBike := objects.GetMegaBike(s)
+	megaBike := objects.GetMegaBike(s, s.cfg, s.rng)
 	s.megaBikes[megaBike.GetID()] = megaBike
+	s.index.megaBikes.Insert(megaBike.GetID(), megaBike.GetPosition())
 	megaBike.InitialiseRuleMap()
 	// megaBike.ActivateAllGlobalRules()
 }
 
+// spawns the awdis of the scenario, each with its own strategy, mass and (if it's given one) position
+func (s *Server) spawnAwdis() {
+	s.awdis = make([]objects.IAwdi, 0, len(s.cfg.Awdi.Fleet))
+	for _, spec := range s.cfg.Awdi.Fleet {
+		mass := spec.Mass
+		if mass == 0 {
+			mass = s.cfg.Physics.MassAwdi
+		}
+		awdi := objects.GetAwdiWithStrategy(mass, objects.NewAwdiStrategy(spec.Strategy, s.cfg, s.rng), s.cfg, s.rng)
+		if spec.Position != nil {
+			state := awdi.GetPhysicalState()
+			state.Position = *spec.Position
+			awdi.SetPhysicalState(state)
+		}
+		s.awdis = append(s.awdis, awdi)
+	}
+}
+
 func (s *Server) replenishMegaBikes() {
-	neededBikes := globals.MegaBikeCount - len(s.megaBikes)
+	neededBikes := s.cfg.MegaBikeCount() - len(s.megaBikes)
 	for i := 0; i < neededBikes; i++ {
 		s.spawnMegaBike()
 	}
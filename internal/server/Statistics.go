@@ -1,6 +1,7 @@
 package server
 
 import (
+	"SOMAS2023/internal/common/utils"
 	"fmt"
 	"math"
 
@@ -9,9 +10,18 @@ import (
 )
 
 type GameStatistics struct {
-	PerRound         []AgentStatistics `json:"per_round"`
-	Average          AgentStatistics   `json:"average"`
-	AgentIDToGroupID map[uuid.UUID]int `json:"agent_id_to_group_id"`
+	PerRound           []AgentStatistics     `json:"per_round"`
+	Average            AgentStatistics       `json:"average"`
+	AgentIDToGroupID   map[uuid.UUID]int     `json:"agent_id_to_group_id"`
+	PerRoundAggregates []AggregateStatistics `json:"per_round_aggregates"`
+	Aggregates         AggregateStatistics   `json:"aggregates"` // over the whole run
+}
+
+// AggregateStatistics describe the game as a whole rather than individual agents
+type AggregateStatistics struct {
+	Governance map[string]GovernanceStatistics `json:"governance"`
+	Bikes      BikeStatistics                  `json:"bikes"`
+	LootBoxes  LootBoxStatistics               `json:"loot_boxes"`
 }
 
 type AgentStatistics struct {
@@ -51,29 +61,67 @@ func averageStatisticsOverRounds(statisticsPerRound []AgentStatistics, accessor
 }
 
 func CalculateStatistics(gameStates [][]GameStateDump) GameStatistics {
-	getAgentEnergy := func(agent *AgentDump) float64 { return agent.EnergyLevel }
-	getAgentPoints := func(agent *AgentDump) float64 { return float64(agent.Points) }
-
-	agentIDToGroupID := make(map[uuid.UUID]int)
-	for _, roundStates := range gameStates {
-		for _, gameState := range roundStates {
-			for id, agent := range gameState.Agents {
-				agentIDToGroupID[id] = agent.GroupID
-			}
-		}
+	statistics := NewStatisticsAccumulator()
+	for _, round := range gameStates {
+		statistics.AddRound(round)
 	}
+	return statistics.Statistics()
+}
 
-	statisticsPerRound := make([]AgentStatistics, 0, len(gameStates))
-	for _, round := range gameStates {
-		statisticsPerRound = append(statisticsPerRound, AgentStatistics{
-			AgentLifetime:       agentLifetime(round),
-			AgentEnergyAverage:  agentAverage(round, getAgentEnergy),
-			AgentEnergyVariance: agentVariance(round, getAgentEnergy),
-			AgentPointsAverage:  agentAverage(round, getAgentPoints),
-			AgentPointsVariance: agentVariance(round, getAgentPoints),
-		})
+// StatisticsAccumulator builds the statistics of a run one game state at a time, so that no game
+// state has to be kept once it's been added
+type StatisticsAccumulator struct {
+	statisticsPerRound []AgentStatistics
+	agentIDToGroupID   map[uuid.UUID]int
+	aggregatesPerRound []AggregateStatistics
+	aggregateTotals    aggregateTotals
+	roundAgents        *roundAgentSums  // the round being added (nil between rounds)
+	roundAggregates    *roundAggregates // the round being added (nil between rounds)
+}
+
+func NewStatisticsAccumulator() *StatisticsAccumulator {
+	return &StatisticsAccumulator{
+		statisticsPerRound: make([]AgentStatistics, 0),
+		agentIDToGroupID:   make(map[uuid.UUID]int),
+		aggregatesPerRound: make([]AggregateStatistics, 0),
+		aggregateTotals:    newAggregateTotals(),
+	}
+}
+
+// AddRound adds the statistics of a round, given the game state after each of its iterations
+func (sa *StatisticsAccumulator) AddRound(round []GameStateDump) {
+	for _, gameState := range round {
+		sa.AddGameState(gameState)
+	}
+	sa.EndRound()
+}
+
+// AddGameState adds the game state after the next iteration of the current round (the first game
+// state of a round being that before its first iteration)
+func (sa *StatisticsAccumulator) AddGameState(gameState GameStateDump) {
+	if sa.roundAgents == nil {
+		sa.roundAgents, sa.roundAggregates = newRoundAgentSums(), newRoundAggregates()
 	}
+	for id, agent := range gameState.Agents {
+		sa.agentIDToGroupID[id] = agent.GroupID
+	}
+	sa.roundAgents.add(gameState)
+	sa.roundAggregates.add(gameState)
+}
+
+// EndRound adds the statistics of the current round, once all of its game states have been added
+func (sa *StatisticsAccumulator) EndRound() {
+	if sa.roundAgents == nil {
+		sa.roundAgents, sa.roundAggregates = newRoundAgentSums(), newRoundAggregates()
+	}
+	sa.statisticsPerRound = append(sa.statisticsPerRound, sa.roundAgents.statistics())
+	sa.aggregatesPerRound = append(sa.aggregatesPerRound, sa.roundAggregates.totals.statistics())
+	sa.aggregateTotals.add(sa.roundAggregates.totals)
+	sa.roundAgents, sa.roundAggregates = nil, nil
+}
 
+func (sa *StatisticsAccumulator) Statistics() GameStatistics {
+	statisticsPerRound := sa.statisticsPerRound
 	return GameStatistics{
 		PerRound: statisticsPerRound,
 		Average: AgentStatistics{
@@ -83,100 +131,167 @@ func CalculateStatistics(gameStates [][]GameStateDump) GameStatistics {
 			AgentPointsAverage:  averageStatisticsOverRounds(statisticsPerRound, getPointsAverage),
 			AgentPointsVariance: averageStatisticsOverRounds(statisticsPerRound, getPointsVariance),
 		},
-		AgentIDToGroupID: agentIDToGroupID,
+		AgentIDToGroupID:   sa.agentIDToGroupID,
+		PerRoundAggregates: sa.aggregatesPerRound,
+		Aggregates:         sa.aggregateTotals.statistics(),
 	}
 }
 
-func agentLifetime(gameStates []GameStateDump) map[uuid.UUID]float64 {
-	result := make(map[uuid.UUID]float64)
-	for i, gameState := range gameStates {
-		for id := range gameState.Agents {
-			result[id] = float64(i)
+// StatisticsSummary condenses the average statistics of a run into means over all of its agents,
+// so that runs with different agents can be compared (and aggregated)
+type StatisticsSummary struct {
+	Agents              int     `json:"agents"`
+	AgentLifetime       float64 `json:"agent_lifetime"`
+	AgentEnergyAverage  float64 `json:"agent_energy_average"`
+	AgentEnergyVariance float64 `json:"agent_energy_variance"`
+	AgentPointsAverage  float64 `json:"agent_points_average"`
+	AgentPointsVariance float64 `json:"agent_points_variance"`
+}
+
+func (gs *GameStatistics) Summary() StatisticsSummary {
+	return gs.summarise(func(uuid.UUID) bool { return true })
+}
+
+// summarises the average statistics of the agents that are included
+func (gs *GameStatistics) summarise(include func(agentID uuid.UUID) bool) StatisticsSummary {
+	agents := 0
+	for _, id := range utils.SortedIDs(gs.Average.AgentLifetime) {
+		if include(id) {
+			agents++
 		}
 	}
-	return result
+	mean := func(accessor AgentStatisticAccessor) float64 {
+		values := accessor(&gs.Average)
+		sum, n := 0.0, 0
+		for _, id := range utils.SortedIDs(values) {
+			if include(id) {
+				sum += values[id]
+				n++
+			}
+		}
+		if n == 0 {
+			return 0.0
+		}
+		return sum / float64(n)
+	}
+
+	return StatisticsSummary{
+		Agents:              agents,
+		AgentLifetime:       mean(getLifetime),
+		AgentEnergyAverage:  mean(getEnergyAverage),
+		AgentEnergyVariance: mean(getEnergyVariance),
+		AgentPointsAverage:  mean(getPointsAverage),
+		AgentPointsVariance: mean(getPointsVariance),
+	}
 }
 
-func agentAverage(gameStates []GameStateDump, agentProperty func(agentDump *AgentDump) float64) map[uuid.UUID]float64 {
-	agentLifetime := agentLifetime(gameStates)
+// the running sums of the agent statistics of a round, built one game state at a time
+type roundAgentSums struct {
+	gameStates    int
+	lifetime      map[uuid.UUID]float64 // the index of the last game state each agent was in
+	energy        map[uuid.UUID]float64 // Σx
+	energySquares map[uuid.UUID]float64 // Σ(x^2)
+	points        map[uuid.UUID]float64
+	pointsSquares map[uuid.UUID]float64
+}
 
-	result := make(map[uuid.UUID]float64)
-	// result[id] := Σx
-	for _, gameState := range gameStates {
-		for id, agent := range gameState.Agents {
-			result[id] += agentProperty(&agent)
-		}
+func newRoundAgentSums() *roundAgentSums {
+	return &roundAgentSums{
+		lifetime:      make(map[uuid.UUID]float64),
+		energy:        make(map[uuid.UUID]float64),
+		energySquares: make(map[uuid.UUID]float64),
+		points:        make(map[uuid.UUID]float64),
+		pointsSquares: make(map[uuid.UUID]float64),
 	}
-	// result[id] := Σx/n == E(x)
-	for id := range result {
-		result[id] /= agentLifetime[id] + 1
+}
+
+func (ras *roundAgentSums) add(gameState GameStateDump) {
+	for id, agent := range gameState.Agents {
+		ras.lifetime[id] = float64(ras.gameStates)
+		ras.energy[id] += agent.EnergyLevel
+		ras.energySquares[id] += math.Pow(agent.EnergyLevel, 2)
+		ras.points[id] += float64(agent.Points)
+		ras.pointsSquares[id] += math.Pow(float64(agent.Points), 2)
 	}
-	return result
+	ras.gameStates++
 }
 
-func agentVariance(gameStates []GameStateDump, agentProperty func(agentDump *AgentDump) float64) map[uuid.UUID]float64 {
-	agentLifetime := agentLifetime(gameStates)
-	agentAverage := agentAverage(gameStates, agentProperty)
+func (ras *roundAgentSums) statistics() AgentStatistics {
+	energyAverage := ras.average(ras.energy)
+	pointsAverage := ras.average(ras.points)
+	return AgentStatistics{
+		AgentLifetime:       ras.lifetime,
+		AgentEnergyAverage:  energyAverage,
+		AgentEnergyVariance: ras.variance(ras.energySquares, energyAverage),
+		AgentPointsAverage:  pointsAverage,
+		AgentPointsVariance: ras.variance(ras.pointsSquares, pointsAverage),
+	}
+}
 
-	result := make(map[uuid.UUID]float64)
-	// result[id] := Σ(x^2)
-	for _, gameState := range gameStates {
-		for id, agent := range gameState.Agents {
-			result[id] += math.Pow(agentProperty(&agent), 2)
-		}
+// Σx/n == E(x)
+func (ras *roundAgentSums) average(sums map[uuid.UUID]float64) map[uuid.UUID]float64 {
+	result := make(map[uuid.UUID]float64, len(sums))
+	for id, sum := range sums {
+		result[id] = sum / (ras.lifetime[id] + 1)
 	}
-	for id := range result {
-		// result[id] := Σ(x^2)/n == E(x^2)
-		result[id] /= agentLifetime[id] + 1
-		// result[id] := E(x^2) - E(x)^2 == Var(x)
-		result[id] -= math.Pow(agentAverage[id]+1, 2)
+	return result
+}
+
+func (ras *roundAgentSums) variance(squares map[uuid.UUID]float64, average map[uuid.UUID]float64) map[uuid.UUID]float64 {
+	result := make(map[uuid.UUID]float64, len(squares))
+	for id, sum := range squares {
+		// Σ(x^2)/n == E(x^2), then E(x^2) - E(x)^2 == Var(x)
+		result[id] = sum / (ras.lifetime[id] + 1)
+		result[id] -= math.Pow(average[id]+1, 2)
 	}
 	return result
 }
 
-func (gs *GameStatistics) ToSpreadsheet() *xlsx.File {
-	// Create the map from AgentID to GroupID
+// ToSpreadsheet gives a sheet per agent statistic, with a row per round and a column per agent
+func (gs *GameStatistics) ToSpreadsheet() (*xlsx.File, error) {
 	workbook := xlsx.NewFile()
 
 	columnIndexes := make(map[uuid.UUID]int)
-	nextIndex := 1
-	getColumnIndex := func(agentID uuid.UUID) int {
-		if idx, ok := columnIndexes[agentID]; ok {
-			return idx
-		} else {
-			idx = nextIndex
-			nextIndex++
-			columnIndexes[agentID] = idx
-			return idx
-		}
+	for i, agentID := range utils.SortedIDs(gs.AgentIDToGroupID) {
+		columnIndexes[agentID] = i + 1
 	}
 
-	writeSheet := func(sheetName string, accessor AgentStatisticAccessor) {
+	writeSheet := func(sheetName string, accessor AgentStatisticAccessor) error {
 		sheet, err := workbook.AddSheet(sheetName)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("adding %s sheet: %w", sheetName, err)
 		}
 
 		headerRow := sheet.AddRow()
 		headerRow.GetCell(0).SetString("Round")
+		for agentID, columnIndex := range columnIndexes {
+			headerRow.GetCell(columnIndex).SetString(fmt.Sprintf("(Group: %d)", gs.AgentIDToGroupID[agentID]))
+		}
 
 		for i, round := range gs.PerRound {
 			row := sheet.AddRow()
 			row.GetCell(0).SetValue(i + 1)
 			for id, value := range accessor(&round) {
-				columnIndex := getColumnIndex(id)
-				groupID := gs.AgentIDToGroupID[id]
-				headerRow.GetCell(columnIndex).SetString(fmt.Sprintf("(Group: %d)", groupID))
-				row.GetCell(columnIndex).SetValue(value)
+				row.GetCell(columnIndexes[id]).SetValue(value)
 			}
 		}
+		return nil
 	}
 
-	writeSheet("Lifetime", getLifetime)
-	writeSheet("Energy Average", getEnergyAverage)
-	writeSheet("Energy Variance", getEnergyVariance)
-	writeSheet("Points Average", getPointsAverage)
-	writeSheet("Points Variance", getPointsVariance)
-
-	return workbook
+	sheets := []struct {
+		name     string
+		accessor AgentStatisticAccessor
+	}{
+		{"Lifetime", getLifetime},
+		{"Energy Average", getEnergyAverage},
+		{"Energy Variance", getEnergyVariance},
+		{"Points Average", getPointsAverage},
+		{"Points Variance", getPointsVariance},
+	}
+	for _, sheet := range sheets {
+		if err := writeSheet(sheet.name, sheet.accessor); err != nil {
+			return nil, err
+		}
+	}
+	return workbook, nil
 }
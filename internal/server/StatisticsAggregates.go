@@ -0,0 +1,208 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/utils"
+
+	"github.com/google/uuid"
+)
+
+// GovernanceStatistics describes how the agents riding bikes of one governance fared
+type GovernanceStatistics struct {
+	Bikes         float64 `json:"bikes"`          // average number of occupied bikes per iteration
+	Riders        float64 `json:"riders"`         // average number of riders per iteration
+	EnergyAverage float64 `json:"energy_average"` // average energy of the riders
+	PointsAverage float64 `json:"points_average"` // average points of the riders
+}
+
+// BikeStatistics describes how long bikes lasted (bikes are only lost to the Awdi when it removes them)
+type BikeStatistics struct {
+	Bikes           int     `json:"bikes"`            // bikes that were on the map at some point
+	Survived        int     `json:"survived"`         // bikes that were still on the map at the end of their round
+	AverageLifetime float64 `json:"average_lifetime"` // average number of game states a bike was on the map in
+	AverageRiders   float64 `json:"average_riders"`   // average number of agents on a bike
+}
+
+// LootBoxStatistics describes how quickly lootboxes were collected
+type LootBoxStatistics struct {
+	Collected             int     `json:"collected"`
+	ResourcesCollected    float64 `json:"resources_collected"`
+	CollectedPerIteration float64 `json:"collected_per_iteration"`
+	ResourcesPerIteration float64 `json:"resources_per_iteration"`
+}
+
+// the aggregates are kept as running totals, so that those of a whole run are simply the sums of those of its rounds
+
+type governanceTotals struct {
+	gameStates      int
+	bikeIterations  int
+	riderIterations int
+	energy          float64
+	points          float64
+}
+
+type bikeTotals struct {
+	bikes           int
+	survived        int
+	lifetime        int
+	riderIterations int
+}
+
+type lootBoxTotals struct {
+	iterations int
+	collected  int
+	resources  float64
+}
+
+type aggregateTotals struct {
+	governance map[utils.Governance]governanceTotals
+	bikes      bikeTotals
+	lootBoxes  lootBoxTotals
+}
+
+func newAggregateTotals() aggregateTotals {
+	totals := aggregateTotals{governance: make(map[utils.Governance]governanceTotals)}
+	for governance := utils.Democracy; governance < utils.Invalid; governance++ {
+		totals.governance[governance] = governanceTotals{}
+	}
+	return totals
+}
+
+func (at *aggregateTotals) add(other aggregateTotals) {
+	for governance, otherTotals := range other.governance {
+		totals := at.governance[governance]
+		totals.gameStates += otherTotals.gameStates
+		totals.bikeIterations += otherTotals.bikeIterations
+		totals.riderIterations += otherTotals.riderIterations
+		totals.energy += otherTotals.energy
+		totals.points += otherTotals.points
+		at.governance[governance] = totals
+	}
+	at.bikes.bikes += other.bikes.bikes
+	at.bikes.survived += other.bikes.survived
+	at.bikes.lifetime += other.bikes.lifetime
+	at.bikes.riderIterations += other.bikes.riderIterations
+	at.lootBoxes.iterations += other.lootBoxes.iterations
+	at.lootBoxes.collected += other.lootBoxes.collected
+	at.lootBoxes.resources += other.lootBoxes.resources
+}
+
+// the aggregate totals of a round, built one game state at a time
+type roundAggregates struct {
+	totals     aggregateTotals
+	gameStates int
+	bikesSeen  map[uuid.UUID]struct{}
+	lootBoxes  map[uuid.UUID]LootBoxDump // those of the last game state added
+}
+
+func newRoundAggregates() *roundAggregates {
+	return &roundAggregates{totals: newAggregateTotals(), bikesSeen: make(map[uuid.UUID]struct{})}
+}
+
+// adds the game state after the next iteration of the round (the first one being that before its first iteration)
+func (ra *roundAggregates) add(gameState GameStateDump) {
+	// (in ID order, so that the sums come out the same in every replay of a seeded run)
+	for _, id := range utils.SortedIDs(gameState.Bikes) {
+		bike := gameState.Bikes[id]
+		ra.bikesSeen[id] = struct{}{}
+		ra.totals.bikes.lifetime++
+		ra.totals.bikes.riderIterations += len(bike.AgentIDs)
+
+		governanceTotals, ok := ra.totals.governance[bike.Governance]
+		if !ok || len(bike.AgentIDs) == 0 {
+			continue
+		}
+		governanceTotals.bikeIterations++
+		for _, agentID := range bike.AgentIDs {
+			if agent, ok := gameState.Agents[agentID]; ok {
+				governanceTotals.riderIterations++
+				governanceTotals.energy += agent.EnergyLevel
+				governanceTotals.points += float64(agent.Points)
+			}
+		}
+		ra.totals.governance[bike.Governance] = governanceTotals
+	}
+	ra.totals.bikes.bikes = len(ra.bikesSeen)
+	ra.totals.bikes.survived = len(gameState.Bikes)
+
+	// lootboxes are despawned as soon as they're looted, so the ones missing from the next game state were collected
+	if ra.gameStates > 0 {
+		ra.totals.lootBoxes.iterations++
+		for _, id := range utils.SortedIDs(ra.lootBoxes) {
+			if _, ok := gameState.LootBoxes[id]; !ok {
+				ra.totals.lootBoxes.collected++
+				ra.totals.lootBoxes.resources += ra.lootBoxes[id].TotalResources
+			}
+		}
+	}
+	ra.lootBoxes = gameState.LootBoxes
+	ra.gameStates++
+	for governance, governanceTotals := range ra.totals.governance {
+		governanceTotals.gameStates = ra.gameStates
+		ra.totals.governance[governance] = governanceTotals
+	}
+}
+
+func (at *aggregateTotals) statistics() AggregateStatistics {
+	return AggregateStatistics{
+		Governance: at.governanceStatistics(),
+		Bikes:      at.bikeStatistics(),
+		LootBoxes:  at.lootBoxStatistics(),
+	}
+}
+
+func (at *aggregateTotals) governanceStatistics() map[string]GovernanceStatistics {
+	statistics := make(map[string]GovernanceStatistics, len(at.governance))
+	for governance, totals := range at.governance {
+		governanceStatistics := GovernanceStatistics{}
+		if totals.gameStates > 0 {
+			governanceStatistics.Bikes = float64(totals.bikeIterations) / float64(totals.gameStates)
+			governanceStatistics.Riders = float64(totals.riderIterations) / float64(totals.gameStates)
+		}
+		if totals.riderIterations > 0 {
+			governanceStatistics.EnergyAverage = totals.energy / float64(totals.riderIterations)
+			governanceStatistics.PointsAverage = totals.points / float64(totals.riderIterations)
+		}
+		statistics[governance.String()] = governanceStatistics
+	}
+	return statistics
+}
+
+func (at *aggregateTotals) bikeStatistics() BikeStatistics {
+	statistics := BikeStatistics{
+		Bikes:    at.bikes.bikes,
+		Survived: at.bikes.survived,
+	}
+	if at.bikes.bikes > 0 {
+		statistics.AverageLifetime = float64(at.bikes.lifetime) / float64(at.bikes.bikes)
+	}
+	if at.bikes.lifetime > 0 {
+		statistics.AverageRiders = float64(at.bikes.riderIterations) / float64(at.bikes.lifetime)
+	}
+	return statistics
+}
+
+func (at *aggregateTotals) lootBoxStatistics() LootBoxStatistics {
+	statistics := LootBoxStatistics{
+		Collected:          at.lootBoxes.collected,
+		ResourcesCollected: at.lootBoxes.resources,
+	}
+	if at.lootBoxes.iterations > 0 {
+		statistics.CollectedPerIteration = float64(at.lootBoxes.collected) / float64(at.lootBoxes.iterations)
+		statistics.ResourcesPerIteration = at.lootBoxes.resources / float64(at.lootBoxes.iterations)
+	}
+	return statistics
+}
+
+// GroupStatistics summarises the average statistics of the agents of each group (see IBaseBiker.GetGroupID)
+func (gs *GameStatistics) GroupStatistics() map[int]StatisticsSummary {
+	groups := make(map[int]StatisticsSummary)
+	for _, groupID := range gs.AgentIDToGroupID {
+		if _, ok := groups[groupID]; ok {
+			continue
+		}
+		groups[groupID] = gs.summarise(func(agentID uuid.UUID) bool {
+			return gs.AgentIDToGroupID[agentID] == groupID
+		})
+	}
+	return groups
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/tealeg/xlsx/v3"
+)
+
+// StatisticsReport is what's written at the end of a run: the statistics of its agents along with
+// whichever of the aggregates were selected by the scenario (the others are left out)
+type StatisticsReport struct {
+	Seed       int64                                             `json:"seed"`
+	Summary    StatisticsSummary                                 `json:"summary"`
+	Agents     AgentStatisticsReport                             `json:"agents"`
+	Groups     map[int]StatisticsSummary                         `json:"groups,omitempty"`
+	Governance *AggregateReport[map[string]GovernanceStatistics] `json:"governance,omitempty"`
+	Bikes      *AggregateReport[BikeStatistics]                  `json:"bikes,omitempty"`
+	LootBoxes  *AggregateReport[LootBoxStatistics]               `json:"loot_boxes,omitempty"`
+	Events     map[string]int                                    `json:"events,omitempty"` // number of events of each type
+}
+
+type AgentStatisticsReport struct {
+	PerRound         []AgentStatistics `json:"per_round"`
+	Average          AgentStatistics   `json:"average"`
+	AgentIDToGroupID map[uuid.UUID]int `json:"agent_id_to_group_id"`
+}
+
+// AggregateReport holds an aggregate over the whole run and over each of its rounds
+type AggregateReport[T any] struct {
+	Total    T   `json:"total"`
+	PerRound []T `json:"per_round"`
+}
+
+func newAggregateReport[T any](statistics *GameStatistics, accessor func(aggregates *AggregateStatistics) T) *AggregateReport[T] {
+	report := &AggregateReport[T]{
+		Total:    accessor(&statistics.Aggregates),
+		PerRound: make([]T, len(statistics.PerRoundAggregates)),
+	}
+	for i := range statistics.PerRoundAggregates {
+		report.PerRound[i] = accessor(&statistics.PerRoundAggregates[i])
+	}
+	return report
+}
+
+func NewStatisticsReport(statistics GameStatistics, seed int64, statisticsConfig config.StatisticsConfig) StatisticsReport {
+	report := StatisticsReport{
+		Seed:    seed,
+		Summary: statistics.Summary(),
+		Agents: AgentStatisticsReport{
+			PerRound:         statistics.PerRound,
+			Average:          statistics.Average,
+			AgentIDToGroupID: statistics.AgentIDToGroupID,
+		},
+	}
+	if statisticsConfig.Groups {
+		report.Groups = statistics.GroupStatistics()
+	}
+	if statisticsConfig.Governance {
+		report.Governance = newAggregateReport(&statistics, func(aggregates *AggregateStatistics) map[string]GovernanceStatistics { return aggregates.Governance })
+	}
+	if statisticsConfig.Bikes {
+		report.Bikes = newAggregateReport(&statistics, func(aggregates *AggregateStatistics) BikeStatistics { return aggregates.Bikes })
+	}
+	if statisticsConfig.LootBoxes {
+		report.LootBoxes = newAggregateReport(&statistics, func(aggregates *AggregateStatistics) LootBoxStatistics { return aggregates.LootBoxes })
+	}
+	return report
+}
+
+func (report *StatisticsReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("encoding statistics report: %w", err)
+	}
+	return nil
+}
+
+// ToSpreadsheet gives the agent sheets of GameStatistics.ToSpreadsheet, followed by a sheet per aggregate in the report
+func (report *StatisticsReport) ToSpreadsheet() (*xlsx.File, error) {
+	agents := GameStatistics{
+		PerRound:         report.Agents.PerRound,
+		Average:          report.Agents.Average,
+		AgentIDToGroupID: report.Agents.AgentIDToGroupID,
+	}
+	workbook, err := agents.ToSpreadsheet()
+	if err != nil {
+		return nil, err
+	}
+
+	summaryHeader := []string{"Agents", "Lifetime", "Energy Average", "Energy Variance", "Points Average", "Points Variance"}
+	summaryRow := func(summary StatisticsSummary) []any {
+		return []any{summary.Agents, summary.AgentLifetime, summary.AgentEnergyAverage, summary.AgentEnergyVariance, summary.AgentPointsAverage, summary.AgentPointsVariance}
+	}
+
+	if report.Groups != nil {
+		groupIDs := make([]int, 0, len(report.Groups))
+		for groupID := range report.Groups {
+			groupIDs = append(groupIDs, groupID)
+		}
+		sort.Ints(groupIDs)
+		rows := make([][]any, len(groupIDs))
+		for i, groupID := range groupIDs {
+			rows[i] = append([]any{groupID}, summaryRow(report.Groups[groupID])...)
+		}
+		if err := addSheet(workbook, "Groups", append([]string{"Group"}, summaryHeader...), rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.Governance != nil {
+		rows := make([][]any, 0)
+		addRows := func(round string, governanceStatistics map[string]GovernanceStatistics) {
+			for governance := utils.Democracy; governance < utils.Invalid; governance++ {
+				statistics := governanceStatistics[governance.String()]
+				rows = append(rows, []any{round, governance.String(), statistics.Bikes, statistics.Riders, statistics.EnergyAverage, statistics.PointsAverage})
+			}
+		}
+		addRows("Total", report.Governance.Total)
+		for i, round := range report.Governance.PerRound {
+			addRows(fmt.Sprint(i+1), round)
+		}
+		if err := addSheet(workbook, "Governance", []string{"Round", "Governance", "Bikes", "Riders", "Energy Average", "Points Average"}, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.Bikes != nil {
+		bikeRow := func(round string, statistics BikeStatistics) []any {
+			return []any{round, statistics.Bikes, statistics.Survived, statistics.AverageLifetime, statistics.AverageRiders}
+		}
+		rows := [][]any{bikeRow("Total", report.Bikes.Total)}
+		for i, round := range report.Bikes.PerRound {
+			rows = append(rows, bikeRow(fmt.Sprint(i+1), round))
+		}
+		if err := addSheet(workbook, "Bikes", []string{"Round", "Bikes", "Survived", "Average Lifetime", "Average Riders"}, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.LootBoxes != nil {
+		lootBoxRow := func(round string, statistics LootBoxStatistics) []any {
+			return []any{round, statistics.Collected, statistics.ResourcesCollected, statistics.CollectedPerIteration, statistics.ResourcesPerIteration}
+		}
+		rows := [][]any{lootBoxRow("Total", report.LootBoxes.Total)}
+		for i, round := range report.LootBoxes.PerRound {
+			rows = append(rows, lootBoxRow(fmt.Sprint(i+1), round))
+		}
+		if err := addSheet(workbook, "Loot Boxes", []string{"Round", "Collected", "Resources Collected", "Collected Per Iteration", "Resources Per Iteration"}, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	return workbook, nil
+}
+
+func addSheet(workbook *xlsx.File, sheetName string, header []string, rows [][]any) error {
+	sheet, err := workbook.AddSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("adding %s sheet: %w", sheetName, err)
+	}
+	headerRow := sheet.AddRow()
+	for i, title := range header {
+		headerRow.GetCell(i).SetString(title)
+	}
+	for _, values := range rows {
+		row := sheet.AddRow()
+		for i, value := range values {
+			row.GetCell(i).SetValue(value)
+		}
+	}
+	return nil
+}
+
+// WriteFiles writes the report in each of the formats selected by the scenario, as <path>_statistics.json
+// and <path>_statistics.xlsx (creating the directory if needed), returning the paths of the files written
+func (report *StatisticsReport) WriteFiles(path string, statisticsConfig config.StatisticsConfig) ([]string, error) {
+	written := make([]string, 0)
+	if !statisticsConfig.IsSet() {
+		return written, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return written, fmt.Errorf("creating statistics report directory: %w", err)
+	}
+
+	if statisticsConfig.JSON {
+		jsonPath := path + "_statistics.json"
+		file, err := os.Create(jsonPath)
+		if err != nil {
+			return written, fmt.Errorf("creating statistics report: %w", err)
+		}
+		err = report.WriteJSON(file)
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("closing statistics report: %w", closeErr)
+		}
+		if err != nil {
+			return written, err
+		}
+		written = append(written, jsonPath)
+	}
+
+	if statisticsConfig.XLSX {
+		workbook, err := report.ToSpreadsheet()
+		if err != nil {
+			return written, err
+		}
+		xlsxPath := path + "_statistics.xlsx"
+		if err := workbook.Save(xlsxPath); err != nil {
+			return written, fmt.Errorf("writing statistics spreadsheet: %w", err)
+		}
+		written = append(written, xlsxPath)
+	}
+	return written, nil
+}
@@ -0,0 +1,122 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// an agent that wants every bike it rides to become a dictatorship
+type RevolutionaryAgent struct {
+	*objects.BaseBiker
+}
+
+func NewRevolutionaryAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &RevolutionaryAgent{BaseBiker: baseBiker}
+}
+
+func (a *RevolutionaryAgent) DecideGovernance() utils.Governance {
+	return utils.Dictatorship
+}
+
+func (a *RevolutionaryAgent) VoteAmendment(proposed utils.Governance) bool {
+	return proposed == utils.Dictatorship
+}
+
+// a server whose bikes were all founded with the given governance, by agents of the given teams
+func serverFoundedAs(t *testing.T, governance config.GovernanceMix, recorder *server.EventRecorder, initFunctions ...server.AgentInitFunction) *server.Server {
+	cfg := smallRunConfig(t)
+	cfg.Governance.FoundingMix = governance
+	cfg.Governance.AmendmentInterval = 1
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(initFunctions...), server.WithEventSubscribers(recorder)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+	return s
+}
+
+func TestUnanimousAmendmentSwitchesGovernance(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := serverFoundedAs(t, config.GovernanceMix{Democracy: 1.0}, recorder, NewRevolutionaryAgent)
+	s.RunAmendments()
+
+	bikes := ridden(s)
+	assert.NotEmpty(t, bikes)
+	assert.Len(t, recorder.OfType(server.AmendmentPassed), len(bikes))
+	assert.Empty(t, recorder.OfType(server.AmendmentRejected))
+	dump := s.NewGameStateDump(0)
+	for id, bike := range bikes {
+		assert.Equal(t, utils.Dictatorship, bike.GetGovernance())
+		// the new dictator is one of the riders
+		assert.Contains(t, riderIDs(bike), bike.GetRuler())
+		// the bike's regime history shows it was founded as a democracy
+		assert.Equal(t, []server.RegimeDump{
+			{Iteration: -1, Governance: utils.Democracy},
+			{Iteration: -1, Governance: utils.Dictatorship},
+		}, dump.Bikes[id].Regimes)
+	}
+	for _, event := range recorder.OfType(server.AmendmentPassed) {
+		assert.Equal(t, utils.Democracy, event.Governance)
+		assert.Equal(t, utils.Dictatorship, event.Motion)
+		assert.ElementsMatch(t, event.Riders, riderIDs(bikes[event.BikeID]))
+	}
+	fmt.Printf("\nUnanimous amendment switches governance passed \n")
+}
+
+func TestAmendmentToDemocracyDismissesTheRuler(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	// base bikers would rather ride democracies
+	s := serverFoundedAs(t, config.GovernanceMix{Dictatorship: 1.0}, recorder)
+	for _, bike := range ridden(s) {
+		assert.NotEqual(t, uuid.Nil, bike.GetRuler())
+	}
+	s.RunAmendments()
+
+	for _, bike := range ridden(s) {
+		assert.Equal(t, utils.Democracy, bike.GetGovernance())
+		assert.Equal(t, uuid.Nil, bike.GetRuler())
+	}
+	fmt.Printf("\nAmendment to democracy dismisses the ruler passed \n")
+}
+
+func TestAmendmentNeedsASupermajority(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	// base bikers vote against switching to a dictatorship
+	s := serverFoundedAs(t, config.GovernanceMix{Democracy: 1.0}, recorder, NewRevolutionaryAgent, nil)
+	s.RunAmendments()
+
+	supermajority := s.GetConfig().Governance.AmendmentSupermajority
+	events := append(recorder.OfType(server.AmendmentPassed), recorder.OfType(server.AmendmentRejected)...)
+	assert.NotEmpty(t, events)
+	for _, event := range events {
+		ayes := 0
+		for _, ballot := range event.Ballots {
+			if ballot {
+				ayes++
+			}
+		}
+		passed := float64(ayes)/float64(len(event.Ballots)) >= supermajority
+		assert.Equal(t, passed, event.Type == server.AmendmentPassed)
+		governance := s.GetMegaBikes()[event.BikeID].GetGovernance()
+		if passed {
+			assert.Equal(t, utils.Dictatorship, governance)
+		} else {
+			assert.Equal(t, utils.Democracy, governance)
+		}
+	}
+	fmt.Printf("\nAmendment needs a supermajority passed \n")
+}
+
+// the IDs of the agents on a bike
+func riderIDs(bike objects.IMegaBike) []uuid.UUID {
+	ids := make([]uuid.UUID, 0)
+	for _, agent := range bike.GetAgents() {
+		ids = append(ids, agent.GetID())
+	}
+	return ids
+}
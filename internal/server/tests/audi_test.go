@@ -40,7 +40,7 @@ func TestAwdiCollisionProcess(t *testing.T) {
 		t.Error("Awdi didnt remove agents correctly")
 	}
 
-	if utils.AwdiRemovesMegaBike {
+	if s.GetConfig().Awdi.RemovesMegaBike {
 		// check if remove megaBike correctly
 		if nMegaBikesBefore-nMegaBikesAfter != 1 {
 			fmt.Printf("Before awdi collision, number of megaBikes = %d \n", nMegaBikesBefore)
@@ -89,12 +89,12 @@ func TestAwdiTargeting(t *testing.T) {
 	s.GetAwdi().UpdateForce()
 	targetId := s.GetAwdi().GetTargetID()
 	fmt.Printf("Awdi is targeting {%s}\n", targetId)
-	if utils.AwdiTargetsEmptyMegaBike {
+	if s.GetConfig().Awdi.TargetsEmptyMegaBike {
 		if targetId != emptyBikeId {
 			t.Error("Awdi didnt target empty megaBike!")
 		}
 	}
-	if utils.AwdiOnlyTargetsStationaryMegaBike {
+	if s.GetConfig().Awdi.OnlyTargetsStationaryMegaBike {
 		if targetId == slowBikeId {
 			t.Error("Awdi didnt ignore moving megaBike!")
 		}
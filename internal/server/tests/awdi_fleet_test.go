@@ -0,0 +1,165 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// a server whose awdis are those given, with agents on their bikes
+func serverWithFleet(t *testing.T, fleet config.AwdiFleet, subscribers ...server.EventSubscriber) *server.Server {
+	cfg := smallRunConfig(t)
+	cfg.Awdi.Fleet = fleet
+	s := server.NewServer(server.WithConfig(cfg), server.WithEventSubscribers(subscribers...)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+	return s
+}
+
+// the bikes with riders, which the awdis go after by default
+func ridden(s *server.Server) map[uuid.UUID]objects.IMegaBike {
+	bikes := make(map[uuid.UUID]objects.IMegaBike)
+	for id, bike := range s.GetMegaBikes() {
+		if len(bike.GetAgents()) > 0 {
+			bikes[id] = bike
+		}
+	}
+	return bikes
+}
+
+func TestAwdiFleetSpawnsAsConfigured(t *testing.T) {
+	position := utils.Coordinates{X: 10.0, Y: 20.0}
+	s := serverWithFleet(t, config.AwdiFleet{
+		{Strategy: config.SlowestTargeting},
+		{Strategy: config.InterceptTargeting, Mass: 12.0},
+		{Strategy: config.PatrolTargeting, Position: &position},
+	})
+
+	awdis := s.GetAwdis()
+	assert.Len(t, awdis, 3)
+	assert.Equal(t, awdis[0].GetID(), s.GetAwdi().GetID())
+	assert.Equal(t, s.GetConfig().Physics.MassAwdi, awdis[0].GetPhysicalState().Mass)
+	assert.Equal(t, 12.0, awdis[1].GetPhysicalState().Mass)
+	assert.Equal(t, position, awdis[2].GetPosition())
+
+	// every awdi is reported in the game dumps, with its strategy
+	dump := s.NewGameStateDump(0)
+	assert.Len(t, dump.Awdis, 3)
+	for i, strategy := range []string{"slowest", "intercept", "patrol"} {
+		assert.Equal(t, awdis[i].GetID(), dump.Awdis[i].ID)
+		assert.Equal(t, strategy, dump.Awdis[i].GetStrategyName())
+		assert.Equal(t, awdis[i].GetPosition(), dump.Awdis[i].GetPosition())
+	}
+
+	fmt.Printf("\nAwdi fleet spawns as configured passed \n")
+}
+
+func TestAwdiStrategiesChooseTheirTargets(t *testing.T) {
+	s := serverWithFleet(t, config.AwdiFleet{
+		{Strategy: config.NearestTargeting},
+		{Strategy: config.RichestTargeting},
+		{Strategy: config.PopulousTargeting},
+		{Strategy: config.InterceptTargeting},
+	})
+	bikes := ridden(s)
+	for _, awdi := range s.GetAwdis() {
+		awdi.UpdateForce()
+		assert.Contains(t, bikes, awdi.GetTargetID())
+	}
+	awdis := s.GetAwdis()
+	nearest, richest, populous, intercept := awdis[0], bikes[awdis[1].GetTargetID()], bikes[awdis[2].GetTargetID()], awdis[3]
+
+	assert.Equal(t, bruteForceNearestTo(bikes, nearest.GetPosition()), nearest.GetTargetID())
+	for _, bike := range bikes {
+		assert.LessOrEqual(t, bike.GetCurrentPool(), richest.GetCurrentPool())
+		assert.LessOrEqual(t, len(bike.GetAgents()), len(populous.GetAgents()))
+	}
+	// with every bike standing still, the one caught up with the soonest is the nearest one
+	assert.Equal(t, bruteForceNearestTo(bikes, intercept.GetPosition()), intercept.GetTargetID())
+
+	fmt.Printf("\nAwdi strategies choose their targets passed \n")
+}
+
+func TestPatrollingAwdiRoams(t *testing.T) {
+	s := serverWithFleet(t, config.AwdiFleet{{Strategy: config.PatrolTargeting}})
+	awdi := s.GetAwdi()
+	start := awdi.GetPosition()
+	for i := 0; i < 3; i++ {
+		s.MovePhysicsObject(awdi)
+		// it goes after no bike, but keeps moving
+		assert.Equal(t, uuid.Nil, awdi.GetTargetID())
+		assert.Greater(t, awdi.GetVelocity(), 0.0)
+	}
+	assert.NotEqual(t, start, awdi.GetPosition())
+
+	fmt.Printf("\nPatrolling awdi roams passed \n")
+}
+
+func TestEveryAwdiHitsBikes(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := serverWithFleet(t, config.AwdiFleet{{Strategy: config.SlowestTargeting}, {Strategy: config.NearestTargeting}}, recorder)
+	bikeIDs := utils.SortedIDs(ridden(s))
+	// each awdi lands on a different bike
+	for i, awdi := range s.GetAwdis() {
+		awdi.SetPhysicalState(utils.PhysicalState{Position: s.GetMegaBikes()[bikeIDs[i]].GetPosition(), Mass: 1.0})
+	}
+
+	s.AwdiCollisionCheck()
+	hitBy := make(map[uuid.UUID]uuid.UUID)
+	for _, collision := range recorder.OfType(server.AwdiCollision) {
+		hitBy[collision.BikeID] = collision.AwdiID
+	}
+	for i, awdi := range s.GetAwdis() {
+		assert.Equal(t, awdi.GetID(), hitBy[bikeIDs[i]])
+		assert.Empty(t, s.GetMegaBikes()[bikeIDs[i]].GetAgents())
+	}
+
+	fmt.Printf("\nEvery awdi hits bikes passed \n")
+}
+
+func TestInterceptPursuitLeadsItsTarget(t *testing.T) {
+	orientations := make(map[config.AwdiPursuit]float64)
+	for _, pursuit := range []config.AwdiPursuit{config.DirectPursuit, config.InterceptPursuit} {
+		cfg := smallRunConfig(t)
+		cfg.Awdi.Fleet = config.AwdiFleet{{Strategy: config.NearestTargeting}}
+		cfg.Awdi.Pursuit = pursuit
+		s := server.NewServer(server.WithConfig(cfg)).(*server.Server)
+		s.Initialize(cfg.Simulation.Iterations)
+		s.FoundingInstitutions()
+
+		// the nearest bike crosses the awdi's path, heading up the map
+		awdi := s.GetAwdi()
+		bikes := ridden(s)
+		bike := bikes[bruteForceNearestTo(bikes, awdi.GetPosition())]
+		state := bike.GetPhysicalState()
+		state.Velocity = 1.0
+		bike.SetPhysicalState(state)
+		bike.SetOrientation(0.5)
+
+		awdi.UpdateForce()
+		awdi.UpdateOrientation()
+		assert.Equal(t, bike.GetID(), awdi.GetTargetID())
+		orientations[pursuit] = awdi.GetOrientation()
+
+		direct := physics.ComputeOrientation(awdi.GetPosition(), bike.GetPosition(), cfg.Environment)
+		meeting := physics.InterceptPoint(awdi.GetPosition(), physics.TopSpeed(cfg.Physics.AwdiMaxForce, cfg.Physics), bike.GetPosition(), utils.Coordinates{X: 0.0, Y: 1.0}, cfg.Awdi.LookaheadHorizon, cfg.Environment)
+		switch pursuit {
+		case config.DirectPursuit:
+			assert.InDelta(t, direct, awdi.GetOrientation(), 1e-9)
+		case config.InterceptPursuit:
+			// it steers ahead of the bike, to where it will meet it
+			assert.Greater(t, meeting.Y, bike.GetPosition().Y)
+			assert.InDelta(t, physics.ComputeOrientation(awdi.GetPosition(), meeting, cfg.Environment), awdi.GetOrientation(), 1e-9)
+		}
+	}
+	assert.NotEqual(t, orientations[config.DirectPursuit], orientations[config.InterceptPursuit])
+
+	fmt.Printf("\nIntercept pursuit leads its target passed \n")
+}
@@ -0,0 +1,106 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// drives two bikes with riders (pedalling hard, not steering) head on into each other, well away from the other bikes
+func headOnBikes(t *testing.T, bikeCollisions bool) (*server.Server, objects.IMegaBike, objects.IMegaBike) {
+	cfg := smallRunConfig(t)
+	cfg.Physics.BikeCollisions = bikeCollisions
+	cfg.Resources.BikeCollisionDamage = 0.01
+	s := server.NewServer(server.WithConfig(cfg)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	bikes := make([]objects.IMegaBike, 0, 2)
+	for _, id := range utils.SortedIDs(s.GetMegaBikes()) {
+		if bike := s.GetMegaBikes()[id]; len(bike.GetAgents()) > 0 && len(bikes) < 2 {
+			bikes = append(bikes, bike)
+		}
+	}
+	if len(bikes) < 2 {
+		t.Fatal("fewer than two bikes have riders")
+	}
+	// a clear stretch of the grid
+	var meeting utils.Coordinates
+	for x := 20.0; x < cfg.Environment.GridWidth; x += 10.0 {
+		meeting = utils.Coordinates{X: x, Y: 125.0}
+		clear := true
+		for id := range s.GetMegaBikesInRadius(meeting, 30.0) {
+			clear = clear && (id == bikes[0].GetID() || id == bikes[1].GetID())
+		}
+		if clear {
+			break
+		}
+	}
+	for i, bike := range bikes {
+		for _, agent := range bike.GetAgents() {
+			agent.SetForces(utils.Forces{Pedal: 1.0})
+		}
+		bike.SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: meeting.X + float64(2*i-1)*4.5, Y: meeting.Y}, Velocity: 3.0, Mass: 1.0})
+		bike.SetOrientation(float64(i))
+		bike.UpdateMass()
+		s.MovePhysicsObject(bike)
+	}
+	return s, bikes[0], bikes[1]
+}
+
+func TestBikesBumpIntoEachOther(t *testing.T) {
+	// bikes drive into each other unless they collide
+	s, left, right := headOnBikes(t, false)
+	s.BikeCollisionCheck()
+	assert.Less(t, right.GetPosition().X-left.GetPosition().X, s.GetConfig().Environment.CollisionThreshold)
+
+	s, left, right = headOnBikes(t, true)
+	energy := make(map[uuid.UUID]float64)
+	for _, bike := range []objects.IMegaBike{left, right} {
+		for _, agent := range bike.GetAgents() {
+			energy[agent.GetID()] = agent.GetEnergyLevel()
+		}
+	}
+	speed := left.GetVelocity() + right.GetVelocity()
+	s.BikeCollisionCheck()
+
+	// they bounce back the way they came, losing speed
+	assert.Less(t, left.GetPosition().X, right.GetPosition().X)
+	assert.InDelta(t, 1.0, math.Abs(left.GetOrientation()), 1e-9)
+	assert.InDelta(t, 0.0, right.GetOrientation(), 1e-9)
+	assert.Less(t, left.GetVelocity()+right.GetVelocity(), speed)
+	// the index follows them
+	assert.Contains(t, s.GetMegaBikesInRadius(left.GetPosition(), 0.0), left.GetID())
+	assert.Contains(t, s.GetMegaBikesInRadius(right.GetPosition(), 0.0), right.GetID())
+	// and every rider is hurt by how hard they hit
+	for _, bike := range []objects.IMegaBike{left, right} {
+		for _, agent := range bike.GetAgents() {
+			assert.InDelta(t, energy[agent.GetID()]-s.GetConfig().Resources.BikeCollisionDamage*speed, agent.GetEnergyLevel(), 1e-9)
+		}
+	}
+
+	fmt.Printf("\nBikes bump into each other passed \n")
+}
+
+func TestRunWithBikeCollisionsAndDrafting(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Physics.BikeCollisions = true
+	cfg.Physics.DraftingRange = 15.0
+	cfg.Resources.BikeCollisionDamage = 0.05
+	s := server.NewServer(server.WithConfig(cfg)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	s.RunSimLoop(cfg.Simulation.RoundIterations, server.NewSimplifiedGameStateDump())
+	for _, bike := range s.GetMegaBikes() {
+		assert.Contains(t, s.GetMegaBikesInRadius(bike.GetPosition(), 0.0), bike.GetID())
+	}
+
+	fmt.Printf("\nRun with bike collisions and drafting passed \n")
+}
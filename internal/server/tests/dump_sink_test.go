@@ -0,0 +1,252 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/server"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func smallRunConfig(t *testing.T) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.BikerAgentCount = 16
+	cfg.Simulation.Iterations = 3
+	cfg.Simulation.RoundIterations = 5
+	cfg.Simulation.Seed = config.Seed(9)
+	cfg.Output.Directory = filepath.Join(t.TempDir(), "nested", "dumps")
+	return cfg
+}
+
+func TestRunWithOutputStreamsGameLoops(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	assert.NoError(t, s.RunWithOutput())
+
+	// the missing directory is created, and holds a single dump with one line per game loop
+	dumps, err := filepath.Glob(filepath.Join(cfg.Output.Directory, "*.ndjson"))
+	assert.NoError(t, err)
+	assert.Len(t, dumps, 1)
+	file, err := os.Open(dumps[0])
+	assert.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		iteration := server.SimplifiedIterationDump{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &iteration))
+		assert.Len(t, iteration.Rounds, cfg.Simulation.RoundIterations)
+		lines++
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Equal(t, cfg.Simulation.Iterations, lines)
+
+	fmt.Printf("\nRun with output streams game loops passed \n")
+}
+
+type failingSink struct {
+	written int
+	closed  bool
+}
+
+func (sink *failingSink) WriteIteration(iteration *server.SimplifiedIterationDump) error {
+	if sink.written == 1 {
+		return errors.New("disk full")
+	}
+	sink.written++
+	return nil
+}
+
+func (sink *failingSink) WriteGameStates(gameStates []server.GameStateDump) error {
+	return sink.WriteIteration(nil)
+}
+
+func (sink *failingSink) Close() error {
+	sink.closed = true
+	return nil
+}
+
+func TestRunReturnsSinkErrors(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	sink := &failingSink{}
+	_, err := s.Run(sink)
+
+	assert.ErrorContains(t, err, "disk full")
+	assert.Equal(t, 1, sink.written)
+	assert.True(t, sink.closed)
+
+	fmt.Printf("\nRun returns sink errors passed \n")
+}
+
+func TestUncreatableOutputDirectoryIsAnError(t *testing.T) {
+	cfg := smallRunConfig(t)
+	blocker := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(blocker, nil, 0o644))
+	cfg.Output.Directory = filepath.Join(blocker, "dumps")
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	assert.Error(t, s.RunWithOutput())
+
+	fmt.Printf("\nUncreatable output directory is an error passed \n")
+}
+
+func TestFullDumpGivesRunStatistics(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Output.DumpLevel = config.FullDump
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	dump := new(bytes.Buffer)
+	statistics, err := s.Run(server.NewNDJSONSink(dump))
+	assert.NoError(t, err)
+
+	gameStates, err := server.ReadGameStateDumps(dump)
+	assert.NoError(t, err)
+	assert.Len(t, gameStates, cfg.Simulation.Iterations)
+	events := 0
+	for _, gameLoop := range gameStates {
+		// the state before the first iteration is dumped too
+		assert.Len(t, gameLoop, cfg.Simulation.RoundIterations+1)
+		assert.NotEmpty(t, gameLoop[0].LootBoxes)
+		assert.Len(t, gameLoop[0].Awdis, 1)
+		for _, gameState := range gameLoop {
+			events += len(gameState.Events)
+			for _, bike := range gameState.Bikes {
+				assert.NotEmpty(t, bike.Rules)
+			}
+		}
+		// agents found their bikes before the first iteration
+		assert.Equal(t, server.JoinedBike, gameLoop[0].Events[0].Type)
+	}
+	assert.Positive(t, events)
+
+	// the statistics calculated from the dump are those of the run itself
+	rerun := server.NewServer(server.WithConfig(cfg))
+	rerun.Initialize(cfg.Simulation.Iterations)
+	assert.Equal(t, rerun.Simulate(), server.CalculateStatistics(gameStates))
+	assert.Equal(t, statistics, server.CalculateStatistics(gameStates))
+
+	fmt.Printf("\nFull dump gives run statistics passed \n")
+}
+
+func TestTruncatedFullDumpIsReadUpToTheCrash(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Output.DumpLevel = config.FullDump
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	dump := new(bytes.Buffer)
+	_, err := s.Run(server.NewNDJSONSink(dump))
+	assert.NoError(t, err)
+	truncated := dump.Bytes()[:dump.Len()-100]
+
+	gameStates, err := server.ReadGameStateDumps(bytes.NewReader(truncated))
+	assert.NoError(t, err)
+	assert.Len(t, gameStates, cfg.Simulation.Iterations-1)
+
+	fmt.Printf("\nTruncated full dump is read up to the crash passed \n")
+}
+
+func TestRunWithOutputWritesStatisticsReport(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Output.DumpLevel = config.NoDump
+	cfg.Output.Statistics.Bikes = false
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	assert.NoError(t, s.RunWithOutput())
+
+	// only the report is written, in both formats
+	dumps, err := filepath.Glob(filepath.Join(cfg.Output.Directory, "*.ndjson"))
+	assert.NoError(t, err)
+	assert.Empty(t, dumps)
+	spreadsheets, err := filepath.Glob(filepath.Join(cfg.Output.Directory, "*_statistics.xlsx"))
+	assert.NoError(t, err)
+	assert.Len(t, spreadsheets, 1)
+	reports, err := filepath.Glob(filepath.Join(cfg.Output.Directory, "*_statistics.json"))
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+
+	contents, err := os.ReadFile(reports[0])
+	assert.NoError(t, err)
+	report := server.StatisticsReport{}
+	assert.NoError(t, json.Unmarshal(contents, &report))
+	assert.Equal(t, *cfg.Simulation.Seed, report.Seed)
+	assert.Equal(t, cfg.Simulation.BikerAgentCount, report.Summary.Agents)
+	assert.Len(t, report.Agents.PerRound, cfg.Simulation.Iterations)
+	assert.NotEmpty(t, report.Groups)
+	assert.Nil(t, report.Bikes)
+	assert.Len(t, report.LootBoxes.PerRound, cfg.Simulation.Iterations)
+	assert.Contains(t, report.Governance.Total, "democracy")
+
+	fmt.Printf("\nRun with output writes statistics report passed \n")
+}
+
+func TestRunWithOutputWritesItsScenario(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Simulation.Seed = nil
+	cfg.Output.DumpLevel = config.NoDump
+	cfg.Output.Statistics = config.StatisticsConfig{}
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	assert.NoError(t, s.RunWithOutput())
+
+	// the scenario is written with the seed the server picked, so that the run can be replayed from it
+	scenarios, err := filepath.Glob(filepath.Join(cfg.Output.Directory, "*_scenario.yaml"))
+	assert.NoError(t, err)
+	assert.Len(t, scenarios, 1)
+	scenario, err := config.LoadConfig(scenarios[0])
+	assert.NoError(t, err)
+	assert.NotNil(t, scenario.Simulation.Seed)
+	assert.Equal(t, s.GetConfig(), *scenario)
+
+	fmt.Printf("\nRun with output writes its scenario passed \n")
+}
+
+func TestStatisticsAggregates(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	statistics := s.Simulate()
+
+	assert.Len(t, statistics.PerRoundAggregates, cfg.Simulation.Iterations)
+	collected := 0
+	for _, round := range statistics.PerRoundAggregates {
+		collected += round.LootBoxes.Collected
+		assert.LessOrEqual(t, round.Bikes.Survived, round.Bikes.Bikes)
+	}
+	// the aggregates of the run are those of its rounds put together
+	assert.Equal(t, collected, statistics.Aggregates.LootBoxes.Collected)
+	assert.Positive(t, statistics.Aggregates.Bikes.AverageLifetime)
+
+	riders := 0.0
+	for _, governance := range statistics.Aggregates.Governance {
+		riders += governance.Riders
+	}
+	assert.Positive(t, riders)
+
+	agents := 0
+	for _, group := range statistics.GroupStatistics() {
+		agents += group.Agents
+	}
+	assert.Equal(t, statistics.Summary().Agents, agents)
+
+	fmt.Printf("\nStatistics aggregates passed \n")
+}
@@ -0,0 +1,126 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstitutionalDecisionsArePublished(t *testing.T) {
+	cfg := smallRunConfig(t)
+	recorder := &server.EventRecorder{}
+	s := server.NewServer(server.WithConfig(cfg), server.WithEventSubscribers(recorder))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	s.Simulate()
+
+	elections := recorder.OfType(server.ElectionHeld)
+	assert.NotEmpty(t, elections)
+	for _, election := range elections {
+		assert.Contains(t, election.Riders, election.AgentID)
+		assert.Len(t, election.Votes, len(election.Riders))
+		assert.Contains(t, []utils.Governance{utils.Leadership, utils.Dictatorship}, election.Governance)
+	}
+	// the founding elections come before the first iteration
+	assert.Equal(t, -1, elections[0].Iteration)
+
+	directions := recorder.OfType(server.DirectionChosen)
+	assert.NotEmpty(t, directions)
+	for _, direction := range directions {
+		assert.NotEmpty(t, direction.Riders)
+		for voter := range direction.Votes {
+			assert.Contains(t, direction.Riders, voter)
+		}
+		if direction.Governance == utils.Dictatorship {
+			assert.Contains(t, direction.Riders, direction.AgentID)
+		}
+	}
+
+	for _, allocation := range recorder.OfType(server.LootAllocated) {
+		allocated := 0.0
+		for agentID, share := range allocation.Allocation {
+			assert.Contains(t, allocation.Riders, agentID)
+			allocated += share
+		}
+		assert.InDelta(t, allocation.Resources, allocated, 1e-6)
+	}
+
+	for _, death := range recorder.OfType(server.AgentDied) {
+		assert.Contains(t, []string{server.DiedOfExhaustion, server.DiedInCollision}, death.Cause)
+	}
+
+	// events are in the order they happened
+	assert.True(t, slices.IsSortedFunc(recorder.Events(), func(a, b server.Event) int {
+		if a.GameLoop != b.GameLoop {
+			return a.GameLoop - b.GameLoop
+		}
+		return a.Iteration - b.Iteration
+	}))
+
+	fmt.Printf("\nInstitutional decisions are published passed \n")
+}
+
+func TestEventsAreReproducible(t *testing.T) {
+	cfg := smallRunConfig(t)
+	record := func() []server.Event {
+		recorder := &server.EventRecorder{}
+		s := server.NewServer(server.WithConfig(cfg))
+		s.Initialize(cfg.Simulation.Iterations)
+		s.Subscribe(recorder)
+		s.Simulate()
+		return recorder.Events()
+	}
+
+	assert.Equal(t, record(), record())
+
+	fmt.Printf("\nEvents are reproducible passed \n")
+}
+
+func TestRunWithOutputWritesEventLog(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Output.EventLog = true
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	assert.NoError(t, s.RunWithOutput())
+
+	logs, err := filepath.Glob(filepath.Join(cfg.Output.Directory, "*_events.ndjson"))
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	file, err := os.Open(logs[0])
+	assert.NoError(t, err)
+	defer file.Close()
+
+	logged := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		event := server.Event{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		logged[event.Type.String()]++
+	}
+	assert.NoError(t, scanner.Err())
+
+	// the statistics report counts the events that were logged
+	reports, err := filepath.Glob(filepath.Join(cfg.Output.Directory, "*_statistics.json"))
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	contents, err := os.ReadFile(reports[0])
+	assert.NoError(t, err)
+	report := server.StatisticsReport{}
+	assert.NoError(t, json.Unmarshal(contents, &report))
+	for eventType, count := range report.Events {
+		assert.Equal(t, count, logged[eventType], eventType)
+	}
+	assert.Positive(t, report.Events[server.DirectionChosen.String()])
+
+	fmt.Printf("\nRun with output writes event log passed \n")
+}
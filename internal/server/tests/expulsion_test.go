@@ -0,0 +1,134 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/server"
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// an agent that wants the rider with the lowest ID on its bike (but itself) kicked out, and only votes to expel that one
+type GrudgeAgent struct {
+	*objects.BaseBiker
+}
+
+func NewGrudgeAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &GrudgeAgent{BaseBiker: baseBiker}
+}
+
+func (a *GrudgeAgent) grudge() uuid.UUID {
+	grudge := uuid.Nil
+	for _, agent := range a.GetFellowBikers() {
+		if id := agent.GetID(); id != a.GetID() && (grudge == uuid.Nil || bytes.Compare(id[:], grudge[:]) < 0) {
+			grudge = id
+		}
+	}
+	return grudge
+}
+
+func (a *GrudgeAgent) VoteForKickout() map[uuid.UUID]int {
+	return map[uuid.UUID]int{a.grudge(): 1}
+}
+
+func (a *GrudgeAgent) VoteExpulsion(accused uuid.UUID) bool {
+	return accused == a.grudge()
+}
+
+// a server whose bikes were all founded as democracies of agents bearing a grudge
+func democraticServer(t *testing.T, recorder *server.EventRecorder, configure func(*config.Config)) *server.Server {
+	cfg := smallRunConfig(t)
+	cfg.Governance.FoundingMix = config.GovernanceMix{Democracy: 1.0}
+	configure(cfg)
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(NewGrudgeAgent), server.WithEventSubscribers(recorder)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+	return s
+}
+
+func TestDemocraciesVoteOnExpulsions(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := democraticServer(t, recorder, func(*config.Config) {})
+	threshold := s.GetConfig().Governance.ExpulsionThreshold
+	kicked := s.HandleKickoutProcess()
+
+	// the riders expel the one with the lowest ID, nobody backing its own grudge against the next lowest
+	passed := recorder.OfType(server.ExpulsionPassed)
+	assert.NotEmpty(t, passed)
+	assert.NotEmpty(t, recorder.OfType(server.ExpulsionRejected))
+	expelled := make([]uuid.UUID, 0)
+	for _, event := range append(passed, recorder.OfType(server.ExpulsionRejected)...) {
+		assert.NotEmpty(t, event.Riders)
+		assert.NotContains(t, event.Ballots, event.AgentID)
+		ayes := 0
+		for _, ballot := range event.Ballots {
+			if ballot {
+				ayes++
+			}
+		}
+		assert.Equal(t, float64(ayes)/float64(len(event.Ballots)) >= threshold, event.Type == server.ExpulsionPassed)
+		if event.Type == server.ExpulsionPassed {
+			expelled = append(expelled, event.AgentID)
+		}
+	}
+	assert.ElementsMatch(t, expelled, kicked)
+
+	// the expulsions are counted as kick offs, and the expelled riders are off their bike
+	kickedOut := 0
+	for _, bike := range s.GetMegaBikes() {
+		kickedOut += bike.GetKickedOutCount()
+		for _, agentID := range kicked {
+			assert.NotContains(t, riderIDs(bike), agentID)
+		}
+	}
+	assert.Equal(t, len(kicked), kickedOut)
+	fmt.Printf("\nDemocracies vote on expulsions passed \n")
+}
+
+func TestExpulsionMotionsNeedSeconding(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := democraticServer(t, recorder, func(cfg *config.Config) {
+		cfg.Governance.ExpulsionSeconding = true
+	})
+	s.HandleKickoutProcess()
+
+	motions := append(recorder.OfType(server.ExpulsionPassed), recorder.OfType(server.ExpulsionRejected)...)
+	assert.NotEmpty(t, motions)
+	for _, event := range motions {
+		// the rider tabling the motion and at least one seconding it
+		assert.GreaterOrEqual(t, len(event.Riders), 2)
+	}
+	// the grudge of the rider with the lowest ID is shared by nobody
+	assert.Empty(t, recorder.OfType(server.ExpulsionRejected))
+	fmt.Printf("\nExpulsion motions need seconding passed \n")
+}
+
+func TestExpelledRidersWaitBeforeRejoining(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := democraticServer(t, recorder, func(cfg *config.Config) {
+		cfg.Governance.RejoinCooldown = 2
+	})
+	s.HandleKickoutProcess()
+
+	expulsions := recorder.OfType(server.ExpulsionPassed)
+	assert.NotEmpty(t, expulsions)
+	// every expelled rider asks to rejoin the bike it was expelled from
+	for _, event := range expulsions {
+		s.GetAgentMap()[event.AgentID].SetBike(event.BikeID)
+	}
+	s.ProcessJoiningRequests(nil)
+
+	assert.Empty(t, recorder.OfType(server.JoinAccepted))
+	refused := make(map[uuid.UUID]string)
+	for _, event := range recorder.OfType(server.JoinRejected) {
+		refused[event.AgentID] = event.Cause
+	}
+	for _, event := range expulsions {
+		assert.Equal(t, server.InCooldown, refused[event.AgentID])
+	}
+	fmt.Printf("\nExpelled riders wait before rejoining passed \n")
+}
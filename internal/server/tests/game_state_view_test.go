@@ -0,0 +1,97 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// the game state an agent was given by the server
+func agentGameState(t *testing.T, agent objects.IBaseBiker) objects.IGameState {
+	withGameState, ok := agent.(interface{ GetGameState() objects.IGameState })
+	if !ok {
+		t.Fatalf("agent %v has no game state", agent.GetID())
+	}
+	return withGameState.GetGameState()
+}
+
+func TestAgentsAreGivenReadOnlyGameState(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	agentID := utils.SortedIDs(s.GetAgentMap())[0]
+	gameState := agentGameState(t, s.GetAgentMap()[agentID])
+	assert.NotSame(t, s, gameState)
+
+	// the bikes agents see match the live ones, but can't be altered through the view
+	viewedBikes := gameState.GetMegaBikes()
+	assert.Len(t, viewedBikes, len(s.GetMegaBikes()))
+	for id, bike := range s.GetMegaBikes() {
+		viewedBike := viewedBikes[id]
+		assert.IsType(t, server.BikeDump{}, viewedBike)
+		assert.Equal(t, bike.GetPosition(), viewedBike.GetPosition())
+		assert.Equal(t, bike.GetGovernance(), viewedBike.GetGovernance())
+		assert.Len(t, viewedBike.GetAgents(), len(bike.GetAgents()))
+		assert.Panics(t, func() { viewedBike.SetRuler(agentID) })
+		assert.Panics(t, func() { viewedBike.SetGovernance(utils.Dictatorship) })
+		assert.Panics(t, func() { viewedBike.AddAgent(s.GetAgentMap()[agentID]) })
+	}
+
+	// as do the other agents
+	for id, agent := range gameState.GetAgentMap() {
+		assert.IsType(t, server.AgentDump{}, agent)
+		assert.Equal(t, s.GetAgentMap()[id].GetBike(), agent.GetBike())
+		assert.Panics(t, func() { agent.UpdateEnergyLevel(-1.0) })
+		assert.Panics(t, func() { agent.SetBike(uuid.Nil) })
+	}
+
+	// altering what an agent was handed doesn't alter what the others see
+	delete(viewedBikes, utils.SortedIDs(s.GetMegaBikes())[0])
+	assert.Len(t, gameState.GetMegaBikes(), len(s.GetMegaBikes()))
+
+	// agents are given copies of the rules, and can't add to them
+	for id, rule := range gameState.ViewGlobalRuleCache() {
+		rule.GetRuleMatrix()[0][0] = 42
+		assert.NotEqual(t, 42.0, s.ViewGlobalRuleCache()[id].GetRuleMatrix()[0][0])
+	}
+	assert.Panics(t, func() { gameState.AddToGlobalRuleCache(objects.GenerateNullPassingRule()) })
+
+	fmt.Printf("\nAgents are given read-only game state passed \n")
+}
+
+func TestGameStateViewIsRefreshed(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+
+	agent := s.GetAgentMap()[utils.SortedIDs(s.GetAgentMap())[0]]
+	gameState := agentGameState(t, agent)
+	// no agent is on a bike before the founding stage
+	assert.Equal(t, uuid.Nil, gameState.GetAgentMap()[agent.GetID()].GetBike())
+
+	s.FoundingInstitutions()
+	assert.NotEqual(t, uuid.Nil, agent.GetBike())
+	assert.Equal(t, agent.GetBike(), gameState.GetAgentMap()[agent.GetID()].GetBike())
+
+	// agents keep the same view for the whole run, which follows the world as it changes
+	s.RunActionProcess()
+	for id, bike := range s.GetMegaBikes() {
+		position := bike.GetPosition()
+		s.MovePhysicsObject(bike)
+		// moving isn't a phase in which agents decide, so the view is only refreshed by the next one
+		assert.Equal(t, position, gameState.GetMegaBikes()[id].GetPosition())
+	}
+	s.RunActionProcess()
+	for id, bike := range s.GetMegaBikes() {
+		assert.Equal(t, bike.GetPosition(), gameState.GetMegaBikes()[id].GetPosition())
+	}
+
+	fmt.Printf("\nGame state view is refreshed passed \n")
+}
@@ -0,0 +1,135 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// an agent that never stands for ruler
+type ShyAgent struct {
+	*objects.BaseBiker
+}
+
+func NewShyAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &ShyAgent{BaseBiker: baseBiker}
+}
+
+func (a *ShyAgent) DecideCandidacy() *objects.Manifesto {
+	return nil
+}
+
+// an agent that keeps the manifestos it's handed, by who sent them
+type ListeningAgent struct {
+	*objects.BaseBiker
+	manifestos map[uuid.UUID]objects.ManifestoMessage
+}
+
+func NewListeningAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &ListeningAgent{BaseBiker: baseBiker, manifestos: make(map[uuid.UUID]objects.ManifestoMessage)}
+}
+
+func (a *ListeningAgent) HandleManifestoMessage(msg objects.ManifestoMessage) {
+	a.manifestos[msg.GetSender().GetID()] = msg
+}
+
+// an agent that promises to rule alone, then weighs every rider's vote the same
+type TurncoatAgent struct {
+	*objects.BaseBiker
+}
+
+func NewTurncoatAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &TurncoatAgent{BaseBiker: baseBiker}
+}
+
+func (a *TurncoatAgent) DecideCandidacy() *objects.Manifesto {
+	return &objects.Manifesto{Weights: map[uuid.UUID]float64{a.GetID(): 1.0}}
+}
+
+// a server whose bikes were all founded with the given governance, holding nominations before every election
+func serverWithNominations(t *testing.T, governance config.GovernanceMix, recorder *server.EventRecorder, initFunctions ...server.AgentInitFunction) *server.Server {
+	cfg := smallRunConfig(t)
+	cfg.Governance.FoundingMix = governance
+	cfg.Governance.Nominations = true
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(initFunctions...), server.WithEventSubscribers(recorder)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+	return s
+}
+
+func TestOnlyCandidatesCanBeElected(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := serverWithNominations(t, config.GovernanceMix{Leadership: 1.0}, recorder, NewShyAgent, nil)
+
+	elections := recorder.OfType(server.ElectionHeld)
+	assert.NotEmpty(t, elections)
+	for _, election := range elections {
+		// every rider but the shy ones stands for ruler
+		candidates := make([]uuid.UUID, 0)
+		for _, rider := range election.Riders {
+			if _, shy := s.GetAgentMap()[rider].(*ShyAgent); !shy {
+				candidates = append(candidates, rider)
+			}
+		}
+		if len(candidates) == 0 {
+			// anybody can be elected when nobody stands
+			assert.Empty(t, election.Candidates)
+			continue
+		}
+		assert.ElementsMatch(t, candidates, election.Candidates)
+		assert.Contains(t, election.Candidates, election.AgentID)
+		assert.NotNil(t, election.Manifesto)
+	}
+	fmt.Printf("\nOnly candidates can be elected passed \n")
+}
+
+func TestManifestosReachTheOtherRiders(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := serverWithNominations(t, config.GovernanceMix{Dictatorship: 1.0}, recorder, NewListeningAgent)
+
+	bikes := ridden(s)
+	assert.NotEmpty(t, bikes)
+	for id, bike := range bikes {
+		for _, agent := range bike.GetAgents() {
+			listener := agent.(*ListeningAgent)
+			// a manifesto from every other candidate on the bike
+			assert.Len(t, listener.manifestos, len(bike.GetAgents())-1)
+			for sender, msg := range listener.manifestos {
+				assert.NotEqual(t, listener.GetID(), sender)
+				assert.Contains(t, riderIDs(bike), sender)
+				assert.Equal(t, id, msg.BikeId)
+			}
+		}
+	}
+	fmt.Printf("\nManifestos reach the other riders passed \n")
+}
+
+func TestBrokenPromisesAreDetected(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := serverWithNominations(t, config.GovernanceMix{Leadership: 1.0}, recorder, NewTurncoatAgent)
+	s.RunActionProcess()
+
+	// every leader with anybody else to weigh goes back on its promise
+	broken := make(map[uuid.UUID]server.Event)
+	for _, event := range recorder.OfType(server.ManifestoBroken) {
+		broken[event.BikeID] = event
+	}
+	assert.NotEmpty(t, broken)
+	for id, bike := range ridden(s) {
+		event, ok := broken[id]
+		assert.Equal(t, len(bike.GetAgents()) > 1, ok)
+		if !ok {
+			continue
+		}
+		assert.Equal(t, bike.GetRuler(), event.AgentID)
+		assert.Equal(t, server.WeightsPromise, event.Cause)
+		assert.Equal(t, map[uuid.UUID]float64{event.AgentID: 1.0}, event.Manifesto.Weights)
+		assert.Len(t, event.Weights, len(bike.GetAgents()))
+	}
+	fmt.Printf("\nBroken promises are detected passed \n")
+}
@@ -0,0 +1,214 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/common/voting"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// an agent whose every decision is either a panic or an output the server can't use
+type MisbehavingAgent struct {
+	*objects.BaseBiker
+}
+
+func NewMisbehavingAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &MisbehavingAgent{BaseBiker: baseBiker}
+}
+
+func (a *MisbehavingAgent) GetAllMessages([]objects.IBaseBiker) []messaging.IMessage[objects.IBaseBiker] {
+	panic("can't decide what to say")
+}
+
+func (a *MisbehavingAgent) DecideGovernance() utils.Governance {
+	return utils.Invalid
+}
+
+func (a *MisbehavingAgent) DecideAction() objects.BikerAction {
+	return objects.BikerAction(7)
+}
+
+func (a *MisbehavingAgent) ChangeBike() uuid.UUID {
+	return uuid.New()
+}
+
+func (a *MisbehavingAgent) VoteLeader() voting.IdVoteMap {
+	return voting.IdVoteMap{uuid.New(): 1.0}
+}
+
+func (a *MisbehavingAgent) VoteDictator() voting.IdVoteMap {
+	var votes voting.IdVoteMap
+	votes[a.GetID()] = 1.0
+	return votes
+}
+
+func (a *MisbehavingAgent) DecideWeights(utils.Action) map[uuid.UUID]float64 {
+	return map[uuid.UUID]float64{uuid.New(): 1.0}
+}
+
+func (a *MisbehavingAgent) ProposeDirectionFromSubset(map[uuid.UUID]objects.ILootBox) uuid.UUID {
+	return uuid.New()
+}
+
+func (a *MisbehavingAgent) DecideForce(uuid.UUID) {
+	panic("forgot how to pedal")
+}
+
+func (a *MisbehavingAgent) DecideAllocation() voting.IdVoteMap {
+	allocation := make(voting.IdVoteMap)
+	for _, agent := range a.GetFellowBikers() {
+		allocation[agent.GetID()] = 0.0
+	}
+	return allocation
+}
+
+func (a *MisbehavingAgent) DecideDictatorAllocation() voting.IdVoteMap {
+	allocation := make(voting.IdVoteMap)
+	for _, agent := range a.GetFellowBikers() {
+		allocation[agent.GetID()] = 1.0
+	}
+	return allocation
+}
+
+func TestMisbehavingAgentsDontCrashTheRun(t *testing.T) {
+	cfg := smallRunConfig(t)
+	recorder := &server.EventRecorder{}
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(NewMisbehavingAgent, nil), server.WithEventSubscribers(recorder))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	assert.NotPanics(t, func() { s.Simulate() })
+
+	offences := s.Offences()
+	assert.NotEmpty(t, offences)
+	callbacks := make(map[string]bool)
+	for _, offence := range offences {
+		callbacks[offence.Callback] = true
+		agent, ok := s.GetAgentMap()[offence.AgentID]
+		if !ok {
+			agent = s.GetDeadAgents()[offence.AgentID]
+		}
+		assert.IsType(t, &MisbehavingAgent{}, agent, offence.String())
+	}
+	for _, callback := range []string{"GetAllMessages", "DecideGovernance", "DecideAction", "ChangeBike", "DecideForce"} {
+		assert.True(t, callbacks[callback], "no offence recorded for %s", callback)
+	}
+	// every offence is published
+	assert.Len(t, recorder.OfType(server.AgentMisbehaved), len(offences))
+
+	fmt.Printf("\nMisbehaving agents don't crash the run passed \n")
+}
+
+func TestOffencesAreReplacedByDefaultsAndPenalised(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Resources.OffencePenalty = 0.1
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(NewMisbehavingAgent))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	s.FoundingInstitutions()
+
+	// an invalid governance choice counts as choosing Democracy
+	for _, bike := range s.GetMegaBikes() {
+		if len(bike.GetAgents()) != 0 {
+			assert.Equal(t, utils.Democracy, bike.GetGovernance())
+		}
+	}
+	// each agent lost energy for each of its offences (not being able to send messages, its choice of governance
+	// and the bike it asked to be put on, which made it stay on the one it was assigned)
+	offenceCount := make(map[uuid.UUID]int)
+	for _, offence := range s.Offences() {
+		offenceCount[offence.AgentID]++
+	}
+	for id, agent := range s.GetAgentMap() {
+		assert.Contains(t, s.GetMegaBikes(), agent.GetBike())
+		assert.Equal(t, 3, offenceCount[id])
+		assert.InDelta(t, 1.0-3*cfg.Resources.OffencePenalty, agent.GetEnergyLevel(), 1e-9)
+	}
+
+	fmt.Printf("\nOffences are replaced by defaults and penalised passed \n")
+}
+
+func TestVotesThatCantBeTalliedChooseNoDirection(t *testing.T) {
+	s := server.GenerateServer()
+	s.Initialize(1)
+
+	// nobody voted
+	assert.NotPanics(t, func() {
+		assert.Equal(t, uuid.Nil, s.GetWinningDirection(make(map[uuid.UUID]voting.LootboxVoteMap), make(map[uuid.UUID]float64)))
+	})
+	// the only vote is for no lootbox at all
+	voter := uuid.New()
+	votes := map[uuid.UUID]voting.LootboxVoteMap{voter: {uuid.Nil: 1.0}}
+	assert.NotPanics(t, func() {
+		assert.Equal(t, uuid.Nil, s.GetWinningDirection(votes, map[uuid.UUID]float64{voter: 1.0}))
+	})
+
+	fmt.Printf("\nVotes that can't be tallied choose no direction passed \n")
+}
+
+func TestBikesWithoutTheirRulerCarryOn(t *testing.T) {
+	for _, governance := range []config.GovernanceMix{{Dictatorship: 1.0}, {Leadership: 1.0}} {
+		s := serverFoundedAs(t, governance, &server.EventRecorder{})
+		bikes := ridden(s)
+		assert.NotEmpty(t, bikes)
+		// every ruler dies, each bike still thinking it's ruled by them
+		for _, bike := range bikes {
+			ruler := bike.GetRuler()
+			s.RemoveAgent(s.GetAgentMap()[ruler])
+			bike.SetRuler(ruler)
+		}
+
+		assert.NotPanics(t, func() {
+			for _, bike := range bikes {
+				assert.Equal(t, uuid.Nil, s.RunRulerAction(bike))
+			}
+			s.HandleKickoutProcess()
+			s.RunActionProcess()
+			s.LootboxCheckAndDistributions()
+		})
+	}
+
+	fmt.Printf("\nBikes without their ruler carry on passed \n")
+}
+
+// a misbehaving agent that can't even have its forces cleared
+type UnrulyAgent struct {
+	*MisbehavingAgent
+}
+
+func NewUnrulyAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &UnrulyAgent{MisbehavingAgent: &MisbehavingAgent{BaseBiker: baseBiker}}
+}
+
+func (a *UnrulyAgent) SetForces(utils.Forces) {
+	panic("won't stop pedalling")
+}
+
+func TestDefaultsAppliedByMisbehavingAgentsDontCrashTheRun(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(NewUnrulyAgent))
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	assert.NotPanics(t, func() { s.RunActionProcess() })
+
+	// each rider misbehaved twice: deciding its forces, then having them cleared
+	offences := make(map[uuid.UUID][]string)
+	for _, offence := range s.Offences() {
+		if offence.Callback == "DecideForce" || offence.Callback == "SetForces" {
+			offences[offence.AgentID] = append(offences[offence.AgentID], offence.Callback)
+		}
+	}
+	assert.NotEmpty(t, offences)
+	for _, callbacks := range offences {
+		assert.Equal(t, []string{"DecideForce", "SetForces"}, callbacks)
+	}
+
+	fmt.Printf("\nDefaults applied by misbehaving agents don't crash the run passed \n")
+}
@@ -0,0 +1,53 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runs a small simulation, with agents deciding concurrently (on the given number of workers) or sequentially
+func runWithWorkers(t *testing.T, workers int, sequential bool, options ...server.ServerOption) (server.IBaseBikerServer, server.GameStatistics, []server.Event) {
+	cfg := smallRunConfig(t)
+	cfg.Simulation.AgentWorkers = workers
+	cfg.Simulation.Sequential = sequential
+	recorder := &server.EventRecorder{}
+	s := server.NewServer(append(options, server.WithConfig(cfg), server.WithEventSubscribers(recorder))...)
+	s.Initialize(cfg.Simulation.Iterations)
+	statistics := s.Simulate()
+	return s, statistics, recorder.Events()
+}
+
+func TestConcurrentRunsMatchSequentialRuns(t *testing.T) {
+	_, sequentialStatistics, sequentialEvents := runWithWorkers(t, 0, true)
+	for _, workers := range []int{2, 5} {
+		_, statistics, events := runWithWorkers(t, workers, false)
+		assert.Equal(t, sequentialStatistics, statistics, "%d workers", workers)
+		assert.Equal(t, sequentialEvents, events, "%d workers", workers)
+	}
+
+	fmt.Printf("\nConcurrent runs match sequential runs passed \n")
+}
+
+// the offences of a run, leaving out why they were offences (misbehaving agents make up random IDs)
+func offencesOf(s server.IBaseBikerServer) []server.Offence {
+	offences := s.Offences()
+	for i := range offences {
+		offences[i].Reason = ""
+	}
+	return offences
+}
+
+func TestOffencesAreRecordedInOrderConcurrently(t *testing.T) {
+	sequential, sequentialStatistics, _ := runWithWorkers(t, 0, true, server.WithAgentInitFunctions(NewMisbehavingAgent))
+	concurrent, statistics, _ := runWithWorkers(t, 4, false, server.WithAgentInitFunctions(NewMisbehavingAgent))
+
+	// agents panicking on other goroutines are caught all the same, and their offences are recorded in the same order
+	assert.NotEmpty(t, concurrent.Offences())
+	assert.Equal(t, offencesOf(sequential), offencesOf(concurrent))
+	assert.Equal(t, sequentialStatistics, statistics)
+
+	fmt.Printf("\nOffences are recorded in order concurrently passed \n")
+}
@@ -98,7 +98,7 @@ func TestPositionUnchangedAfterPruning(t *testing.T) {
 		}
 		s.MovePhysicsObject(bike)
 		endPos := bike.GetPosition()
-		distTrav := physics.ComputeDistance(startPos, endPos)
+		distTrav := physics.ComputeDistance(startPos, endPos, s.GetConfig().Environment)
 		fmt.Println(distTrav)
 		if distTrav > 0.1 {
 			t.Error("Bike moved despite no voted direction")
@@ -133,7 +133,7 @@ func TestPositionChangedAfterPruning(t *testing.T) {
 		}
 		s.MovePhysicsObject(bike)
 		endPos := bike.GetPosition()
-		distTrav := physics.ComputeDistance(startPos, endPos)
+		distTrav := physics.ComputeDistance(startPos, endPos, s.GetConfig().Environment)
 		fmt.Println(distTrav)
 		if len(bike.GetAgents()) > 0 && distTrav <= 0.1 {
 			t.Error("Bike not moved despite no pruning")
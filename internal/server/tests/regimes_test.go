@@ -0,0 +1,119 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"bytes"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCouncilRunsTheBike(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := serverFoundedAs(t, config.GovernanceMix{Council: 1.0}, recorder)
+	councilSize := s.GetConfig().Governance.CouncilSize
+
+	bikes := ridden(s)
+	assert.NotEmpty(t, bikes)
+	dump := s.NewGameStateDump(0)
+	for id, bike := range bikes {
+		council := dump.Bikes[id].Council
+		assert.Len(t, council, min(councilSize, len(bike.GetAgents())))
+		// the council is made of riders, its chair ruling the bike
+		assert.Subset(t, riderIDs(bike), council)
+		assert.Equal(t, council[0], bike.GetRuler())
+	}
+	for _, election := range recorder.OfType(server.ElectionHeld) {
+		assert.Equal(t, utils.Council, election.Governance)
+		assert.Equal(t, election.AgentID, election.Council[0])
+	}
+
+	// only the votes of the council members count towards the direction
+	s.RunActionProcess()
+	directions := recorder.OfType(server.DirectionChosen)
+	assert.Len(t, directions, len(bikes))
+	for _, direction := range directions {
+		council := dump.Bikes[direction.BikeID].Council
+		for agentID, weight := range direction.Weights {
+			if slices.Contains(council, agentID) {
+				assert.Equal(t, 1.0, weight)
+			} else {
+				assert.Equal(t, 0.0, weight)
+			}
+		}
+	}
+	fmt.Printf("\nCouncil runs the bike passed \n")
+}
+
+func TestSortitionDrawsRulersEveryPeriod(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	cfg := smallRunConfig(t)
+	cfg.Governance.FoundingMix = config.GovernanceMix{Sortition: 1.0}
+	cfg.Governance.SortitionPeriod = 2
+	s := server.NewServer(server.WithConfig(cfg), server.WithEventSubscribers(recorder))
+	s.Initialize(cfg.Simulation.Iterations)
+	s.Simulate()
+
+	previous := make(map[uuid.UUID]int)
+	gameLoop := -1
+	draws := 0
+	for _, event := range recorder.OfType(server.ElectionHeld) {
+		if event.GameLoop != gameLoop {
+			gameLoop = event.GameLoop
+			clear(previous)
+		}
+		assert.Equal(t, utils.Sortition, event.Governance)
+		// nobody votes for a ruler drawn by lot
+		assert.Empty(t, event.Votes)
+		assert.Contains(t, event.Riders, event.AgentID)
+		if event.Cause == server.TermEnded {
+			draws++
+			last, ok := previous[event.BikeID]
+			assert.True(t, ok)
+			assert.Equal(t, cfg.Governance.SortitionPeriod, event.Iteration-last)
+		}
+		previous[event.BikeID] = event.Iteration
+	}
+	assert.Positive(t, draws)
+	fmt.Printf("\nSortition draws rulers every period passed \n")
+}
+
+func TestRotationPassesLeadershipInIDOrder(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	cfg := smallRunConfig(t)
+	cfg.Governance.FoundingMix = config.GovernanceMix{Rotation: 1.0}
+	cfg.Governance.RotationPeriod = 1
+	s := server.NewServer(server.WithConfig(cfg), server.WithEventSubscribers(recorder))
+	s.Initialize(cfg.Simulation.Iterations)
+	s.Simulate()
+
+	previous := make(map[uuid.UUID]uuid.UUID)
+	gameLoop := -1
+	turns := 0
+	for _, event := range recorder.OfType(server.ElectionHeld) {
+		if event.GameLoop != gameLoop {
+			gameLoop = event.GameLoop
+			clear(previous)
+		}
+		assert.Equal(t, utils.Rotation, event.Governance)
+		if event.Cause == server.TermEnded {
+			turns++
+			// the leader hands over to the rider that comes after it in ID order, the first one after the last
+			riders := slices.Clone(event.Riders)
+			slices.SortFunc(riders, func(a, b uuid.UUID) int {
+				return bytes.Compare(a[:], b[:])
+			})
+			last := slices.Index(riders, previous[event.BikeID])
+			assert.NotEqual(t, -1, last)
+			assert.Equal(t, riders[(last+1)%len(riders)], event.AgentID)
+		}
+		previous[event.BikeID] = event.AgentID
+	}
+	assert.Positive(t, turns)
+	fmt.Printf("\nRotation passes leadership in ID order passed \n")
+}
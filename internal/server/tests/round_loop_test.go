@@ -65,9 +65,8 @@ func TestHandleKickout(t *testing.T) {
 }
 
 func TestProcessJoiningRequests(t *testing.T) {
-	OnlySpawnBaseBikers(t)
 	iterations := 3
-	s := server.GenerateServer()
+	s := GenerateBaseBikerServer()
 	s.Initialize(iterations)
 
 	// 1: get two bike ids (choose the 2 most empty bikes)
@@ -160,7 +159,7 @@ func TestRunActionProcess(t *testing.T) {
 		s.RunActionProcess()
 		// check all agents have lost energy (proportionally to how much they have pedalled)
 		for _, agent := range s.GetAgentMap() {
-			lostEnergy := (utils.MovingDepletion * agent.GetForces().Pedal)
+			lostEnergy := (s.GetConfig().Resources.MovingDepletion * agent.GetForces().Pedal)
 
 			var agentBike objects.IMegaBike
 			for _, bike := range s.GetMegaBikes() {
@@ -172,9 +171,9 @@ func TestRunActionProcess(t *testing.T) {
 			governance := agentBike.GetGovernance()
 			switch governance {
 			case utils.Democracy:
-				lostEnergy += utils.DeliberativeDemocracyPenalty
+				lostEnergy += s.GetConfig().Resources.DeliberativeDemocracyPenalty
 			case utils.Leadership:
-				lostEnergy += utils.LeadershipDemocracyPenalty
+				lostEnergy += s.GetConfig().Resources.LeadershipDemocracyPenalty
 			}
 			// FP precision error
 			if (agent.GetEnergyLevel() - (1.0 - lostEnergy)) > utils.Epsilon {
@@ -205,7 +204,7 @@ func TestRunActionProcessDictator(t *testing.T) {
 
 	bike.SetGovernance(utils.Dictatorship)
 	agents := bike.GetAgents()
-	if len(agents) == utils.BikersOnBike {
+	if len(agents) == s.GetConfig().Environment.BikersOnBike {
 		removable := agents[0]
 		bike.RemoveAgent(removable.GetID())
 	}
@@ -255,7 +254,7 @@ func TestRunActionProcessLeader(t *testing.T) {
 	bike := s.GetMegaBikes()[bikeID]
 	bike.SetGovernance(utils.Leadership)
 	agents := bike.GetAgents()
-	if len(agents) == utils.BikersOnBike {
+	if len(agents) == s.GetConfig().Environment.BikersOnBike {
 		removable := agents[0]
 		bike.RemoveAgent(removable.GetID())
 	}
@@ -478,7 +477,7 @@ func TestLootboxShareDictator(t *testing.T) {
 	bike := s.GetMegaBikes()[bikeID]
 	bike.SetGovernance(utils.Dictatorship)
 	agents := bike.GetAgents()
-	if len(agents) == utils.BikersOnBike {
+	if len(agents) == s.GetConfig().Environment.BikersOnBike {
 		removable := agents[0]
 		bike.RemoveAgent(removable.GetID())
 	}
@@ -0,0 +1,133 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// an agent that never has confidence in the ruler of its bike
+type MutinousAgent struct {
+	*objects.BaseBiker
+}
+
+func NewMutinousAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &MutinousAgent{BaseBiker: baseBiker}
+}
+
+func (a *MutinousAgent) VoteNoConfidence() bool {
+	return true
+}
+
+func TestNoConfidenceRemovesTheRuler(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	cfg := smallRunConfig(t)
+	cfg.Governance.FoundingMix = config.GovernanceMix{Dictatorship: 1.0}
+	cfg.Governance.NoConfidenceMajority = 0.5
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(NewMutinousAgent), server.WithEventSubscribers(recorder)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	rulers := make(map[uuid.UUID]uuid.UUID)
+	for id, bike := range ridden(s) {
+		assert.Greater(t, len(bike.GetAgents()), 1)
+		rulers[id] = bike.GetRuler()
+	}
+	s.CheckRulerMandates()
+
+	removals := recorder.OfType(server.NoConfidencePassed)
+	assert.Len(t, removals, len(rulers))
+	assert.Empty(t, recorder.OfType(server.NoConfidenceRejected))
+	for _, removal := range removals {
+		assert.Equal(t, rulers[removal.BikeID], removal.AgentID)
+		// every rider but the ruler got a ballot
+		assert.Len(t, removal.Ballots, len(ridden(s)[removal.BikeID].GetAgents())-1)
+		assert.NotContains(t, removal.Ballots, removal.AgentID)
+	}
+	for id, bike := range ridden(s) {
+		// the ruler that lost the confidence of its riders can't be re-elected
+		assert.NotEqual(t, rulers[id], bike.GetRuler())
+		assert.Contains(t, riderIDs(bike), bike.GetRuler())
+	}
+	for _, election := range recorder.OfType(server.ElectionHeld)[len(rulers):] {
+		assert.Equal(t, server.LostConfidence, election.Cause)
+	}
+	fmt.Printf("\nNo confidence removes the ruler passed \n")
+}
+
+func TestScheduledElectionsWithTermLimits(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	cfg := smallRunConfig(t)
+	cfg.Governance.FoundingMix = config.GovernanceMix{Leadership: 1.0}
+	cfg.Governance.TermLength = 2
+	cfg.Governance.TermLimit = 1
+	s := server.NewServer(server.WithConfig(cfg), server.WithEventSubscribers(recorder))
+	s.Initialize(cfg.Simulation.Iterations)
+	s.Simulate()
+
+	// the previous election on each bike, in the current game loop
+	type election struct {
+		iteration int
+		ruler     uuid.UUID
+	}
+	previous := make(map[uuid.UUID]election)
+	gameLoop := -1
+	scheduled := 0
+	for _, event := range recorder.OfType(server.ElectionHeld) {
+		if event.GameLoop != gameLoop {
+			gameLoop = event.GameLoop
+			clear(previous)
+		}
+		if event.Cause == server.TermEnded {
+			scheduled++
+			last, ok := previous[event.BikeID]
+			assert.True(t, ok)
+			assert.Equal(t, cfg.Governance.TermLength, event.Iteration-last.iteration)
+			// a ruler only serves a single term in a row, unless nobody else can rule
+			if len(event.Riders) > 1 {
+				assert.NotEqual(t, last.ruler, event.AgentID)
+			}
+		}
+		previous[event.BikeID] = election{iteration: event.Iteration, ruler: event.AgentID}
+	}
+	assert.Positive(t, scheduled)
+	fmt.Printf("\nScheduled elections with term limits passed \n")
+}
+
+// an agent that leaves its bike as soon as it rules it
+type DeserterAgent struct {
+	*objects.BaseBiker
+}
+
+func NewDeserterAgent(baseBiker *objects.BaseBiker) objects.IBaseBiker {
+	return &DeserterAgent{BaseBiker: baseBiker}
+}
+
+func (a *DeserterAgent) DecideAction() objects.BikerAction {
+	if bike, ok := a.GetGameState().GetMegaBikes()[a.GetBike()]; ok && bike.GetRuler() == a.GetID() {
+		return objects.ChangeBike
+	}
+	return objects.Pedal
+}
+
+func TestRulersLeavingAreReplacedUnderTheSameGovernance(t *testing.T) {
+	recorder := &server.EventRecorder{}
+	s := serverFoundedAs(t, config.GovernanceMix{Dictatorship: 1.0}, recorder, NewDeserterAgent)
+	founding := len(recorder.OfType(server.ElectionHeld))
+
+	leaving := s.GetLeavingDecisions()
+	assert.NotEmpty(t, leaving)
+	elections := recorder.OfType(server.ElectionHeld)[founding:]
+	assert.NotEmpty(t, elections)
+	for _, election := range elections {
+		assert.Equal(t, utils.Dictatorship, election.Governance)
+		assert.Equal(t, utils.Dictatorship, s.GetMegaBikes()[election.BikeID].GetGovernance())
+	}
+	fmt.Printf("\nRulers leaving are replaced under the same governance passed \n")
+}
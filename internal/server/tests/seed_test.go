@@ -0,0 +1,81 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runs a small simulation with the given seed and returns its dump along with the server
+func runSeededSimulation(seed int64) (*server.Server, *server.SimplifiedGameStateDump) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.BikerAgentCount = 24
+	cfg.Simulation.Seed = config.Seed(seed)
+	s := server.GenerateServerWithConfig(cfg).(*server.Server)
+	s.Initialize(1)
+
+	dump := server.NewSimplifiedGameStateDump()
+	for i := 0; i < 2; i++ {
+		s.RunSimLoop(20, dump)
+	}
+	return s, dump
+}
+
+func TestSeededRunsAreReproducible(t *testing.T) {
+	s1, dump1 := runSeededSimulation(42)
+	s2, dump2 := runSeededSimulation(42)
+
+	// the same agents, bikes and lootboxes are created...
+	assert.Equal(t, utils.SortedIDs(s1.GetAgentMap()), utils.SortedIDs(s2.GetAgentMap()))
+	assert.Equal(t, utils.SortedIDs(s1.GetMegaBikes()), utils.SortedIDs(s2.GetMegaBikes()))
+	assert.Equal(t, utils.SortedIDs(s1.GetLootBoxes()), utils.SortedIDs(s2.GetLootBoxes()))
+	assert.Equal(t, s1.GetAwdi().GetPosition(), s2.GetAwdi().GetPosition())
+
+	// ...and they behave in exactly the same way
+	assert.Equal(t, dump1, dump2)
+	for id, agent := range s1.GetAgentMap() {
+		other := s2.GetAgentMap()[id]
+		assert.Equal(t, agent.GetEnergyLevel(), other.GetEnergyLevel())
+		assert.Equal(t, agent.GetPoints(), other.GetPoints())
+		assert.Equal(t, agent.GetBike(), other.GetBike())
+	}
+	for id, bike := range s1.GetMegaBikes() {
+		assert.Equal(t, bike.GetPhysicalState(), s2.GetMegaBikes()[id].GetPhysicalState())
+	}
+
+	fmt.Printf("\nSeeded runs are reproducible passed \n")
+}
+
+func TestSeedZeroIsReplayed(t *testing.T) {
+	s1, dump1 := runSeededSimulation(0)
+	s2, dump2 := runSeededSimulation(0)
+
+	assert.Equal(t, config.Seed(0), s1.GetConfig().Simulation.Seed)
+	assert.Equal(t, utils.SortedIDs(s1.GetAgentMap()), utils.SortedIDs(s2.GetAgentMap()))
+	assert.Equal(t, dump1, dump2)
+
+	fmt.Printf("\nSeed zero is replayed passed \n")
+}
+
+func TestDifferentSeedsDiverge(t *testing.T) {
+	s1, _ := runSeededSimulation(1)
+	s2, _ := runSeededSimulation(2)
+
+	assert.NotEqual(t, utils.SortedIDs(s1.GetAgentMap()), utils.SortedIDs(s2.GetAgentMap()))
+	assert.NotEqual(t, s1.GetAwdi().GetPosition(), s2.GetAwdi().GetPosition())
+
+	fmt.Printf("\nDifferent seeds diverge passed \n")
+}
+
+func TestUnseededRunRecordsItsSeed(t *testing.T) {
+	s := server.GenerateServer()
+	s.Initialize(1)
+
+	assert.NotNil(t, s.GetConfig().Simulation.Seed)
+
+	fmt.Printf("\nUnseeded run records its seed passed \n")
+}
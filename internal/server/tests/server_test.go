@@ -1,33 +1,38 @@
 package server_test
 
 import (
-	"SOMAS2023/internal/common/globals"
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/registry"
 	"SOMAS2023/internal/server"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestInitialize(t *testing.T) {
 
 	iterations := 3
-	s := server.GenerateServer()
+	cfg := config.DefaultConfig()
+	s := server.GenerateServerWithConfig(cfg)
 	s.Initialize(iterations)
 
-	if len(s.GetAgentMap()) != *globals.BikerAgentCount {
+	if len(s.GetAgentMap()) != cfg.Simulation.BikerAgentCount {
 		t.Error("agents not properly instantiated")
 	}
 
-	if len(s.GetMegaBikes()) != globals.MegaBikeCount {
+	if len(s.GetMegaBikes()) != cfg.MegaBikeCount() {
 		t.Error("megabikes not properly instantiated")
 	}
 
-	if len(s.GetLootBoxes()) != globals.LootBoxCount {
+	if len(s.GetLootBoxes()) != cfg.LootBoxCount() {
 		t.Error("loot boxes not properly instantiated")
 	}
 
-	if len(s.ViewGlobalRuleCache()) != *globals.GlobalRuleCount {
+	if len(s.ViewGlobalRuleCache()) != cfg.Simulation.GlobalRuleCount {
 		t.Error("ruleset not properly instantiated")
 	}
 
@@ -38,6 +43,88 @@ func TestInitialize(t *testing.T) {
 	fmt.Printf("\nInitialize passed \n")
 }
 
+func TestServerOptions(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.BikerAgentCount = 12
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(nil))
+	s.Initialize(1)
+
+	// the server works on its own copy of the scenario
+	assert.Nil(t, cfg.Simulation.Seed)
+	assert.NotNil(t, s.GetConfig().Simulation.Seed)
+
+	assert.Len(t, s.GetAgentMap(), 12)
+	for _, agent := range s.GetAgentMap() {
+		assert.IsType(t, &objects.BaseBiker{}, agent)
+	}
+
+	fmt.Printf("\nServer options passed \n")
+}
+
+func TestAgentMixIsSpawned(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.AgentMix = config.AgentMix{{Team: "sosa", Count: 5}, {Team: registry.BaseBiker, Count: 7}}
+	cfg.Simulation.BikerAgentCount = cfg.Simulation.AgentMix.Total()
+	// the mix takes precedence over the server's teams
+	s := server.NewServer(server.WithConfig(cfg), server.WithAgentInitFunctions(nil))
+	s.Initialize(1)
+
+	baseBikers := 0
+	for _, agent := range s.GetAgentMap() {
+		if _, ok := agent.(*objects.BaseBiker); ok {
+			baseBikers++
+		}
+	}
+	assert.Len(t, s.GetAgentMap(), 12)
+	assert.Equal(t, 7, baseBikers)
+
+	_, err := registry.Lookup("greedy")
+	assert.ErrorContains(t, err, "sosa")
+	assert.Error(t, registry.ValidateMix(config.AgentMix{{Team: "greedy", Count: 1}}))
+
+	fmt.Printf("\nAgent mix is spawned passed \n")
+}
+
+func TestConcurrentServersAreIndependent(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.Seed = config.Seed(5)
+	agentCounts := []int{8, 16, 24, 32}
+
+	// every server shares the same scenario, overriding only its agent count
+	servers := make([]*server.Server, len(agentCounts))
+	var wg sync.WaitGroup
+	for i, agentCount := range agentCounts {
+		wg.Add(1)
+		go func(i int, agentCount int) {
+			defer wg.Done()
+			serverCfg := *cfg
+			serverCfg.Simulation.BikerAgentCount = agentCount
+			s := server.NewServer(server.WithConfig(&serverCfg)).(*server.Server)
+			s.Initialize(1)
+			s.RunSimLoop(10, server.NewSimplifiedGameStateDump())
+			servers[i] = s
+		}(i, agentCount)
+	}
+	wg.Wait()
+
+	for i, s := range servers {
+		assert.Equal(t, agentCounts[i], s.GetConfig().Simulation.BikerAgentCount)
+		assert.Equal(t, agentCounts[i], len(s.GetAgentMap())+len(s.GetDeadAgents()))
+	}
+
+	// and a server run on its own behaves exactly as it did alongside the others
+	aloneCfg := servers[2].GetConfig()
+	alone := server.NewServer(server.WithConfig(&aloneCfg)).(*server.Server)
+	alone.Initialize(1)
+	alone.RunSimLoop(10, server.NewSimplifiedGameStateDump())
+	for id, agent := range servers[2].GetAgentMap() {
+		assert.Equal(t, agent.GetEnergyLevel(), alone.GetAgentMap()[id].GetEnergyLevel())
+		assert.Equal(t, agent.GetPoints(), alone.GetAgentMap()[id].GetPoints())
+	}
+
+	fmt.Printf("\nConcurrent servers are independent passed \n")
+}
+
 // func TestRunGame(t *testing.T) {
 // 	iterations := 2
 // 	s := server.GenerateServer()
@@ -1,6 +1,7 @@
 package server_test
 
 import (
+	"SOMAS2023/internal/common/config"
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/server"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
 type MockBiker struct {
@@ -27,7 +29,7 @@ type MegaBike struct {
 }
 
 func NewMockBiker(gameState objects.IGameState) *MockBiker {
-	baseBiker := objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), gameState)
+	baseBiker := objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), gameState, agentRng())
 
 	return &MockBiker{
 		BaseBiker: baseBiker,
@@ -73,9 +75,8 @@ func createMockBikers(s server.IBaseBikerServer, count int) []*MockBiker {
 } */
 
 func TestResetGameState(t *testing.T) {
-	OnlySpawnBaseBikers(t)
 	iterations := 2
-	s := server.GenerateServer()
+	s := GenerateBaseBikerServer()
 	s.Initialize(iterations)
 	s.FoundingInstitutions()
 
@@ -112,7 +113,7 @@ func TestResetGameState(t *testing.T) {
 		}
 	}
 
-	if utils.ResetPointsEveryRound {
+	if s.GetConfig().Environment.ResetPointsEveryRound {
 		for _, agent := range s.GetAgentMap() {
 			if agent.GetPoints() != 0 {
 				t.Errorf("Expected agent points to be 0, got %d", agent.GetPoints())
@@ -127,10 +128,8 @@ func TestResetGameState(t *testing.T) {
 }
 
 func TestFoundingInstitutions(t *testing.T) {
-	OnlySpawnBaseBikers(t)
-
 	iterations := 2
-	s := server.GenerateServer()
+	s := GenerateBaseBikerServer()
 	s.Initialize(iterations)
 
 	// remove 4 agents from the map
@@ -187,3 +186,38 @@ func TestFoundingInstitutions(t *testing.T) {
 		}
 	}
 }
+
+func TestFoundingMixDealsGovernances(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.BikerAgentCount = 24
+	cfg.Simulation.Seed = config.Seed(7)
+	cfg.Governance.FoundingMix = config.GovernanceMix{Democracy: 1, Dictatorship: 2}
+	s := server.GenerateServerWithConfig(cfg)
+	s.Initialize(1)
+
+	s.FoundingInstitutions()
+
+	ridersPerGovernance := make(map[utils.Governance]int)
+	for _, bike := range s.GetMegaBikes() {
+		ridersPerGovernance[bike.GetGovernance()] += len(bike.GetAgents())
+	}
+	assert.Equal(t, 8, ridersPerGovernance[utils.Democracy])
+	assert.Equal(t, 0, ridersPerGovernance[utils.Leadership])
+	assert.Equal(t, 16, ridersPerGovernance[utils.Dictatorship])
+}
+
+func TestSimulateReturnsStatistics(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Simulation.BikerAgentCount = 16
+	cfg.Simulation.Iterations = 2
+	cfg.Simulation.RoundIterations = 10
+	cfg.Simulation.Seed = config.Seed(3)
+	s := server.GenerateServerWithConfig(cfg)
+	s.Initialize(cfg.Simulation.Iterations)
+
+	statistics := s.Simulate()
+
+	assert.Len(t, statistics.PerRound, 2)
+	assert.NotEmpty(t, statistics.AgentIDToGroupID)
+	assert.Positive(t, statistics.Summary().AgentLifetime)
+}
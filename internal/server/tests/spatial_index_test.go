@@ -0,0 +1,103 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/spatial"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// the IDs of the objects at most radius away from a position, found by visiting all of them
+func bruteForceInRadius[T objects.IPhysicsObject](objectMap map[uuid.UUID]T, position utils.Coordinates, radius float64) []uuid.UUID {
+	found := make([]uuid.UUID, 0)
+	for _, id := range utils.SortedIDs(objectMap) {
+		if spatial.Distance(position, objectMap[id].GetPosition()) <= radius {
+			found = append(found, id)
+		}
+	}
+	return found
+}
+
+// the ID of the object nearest to a position, found by visiting all of them
+func bruteForceNearestTo[T objects.IPhysicsObject](objectMap map[uuid.UUID]T, position utils.Coordinates) uuid.UUID {
+	nearest, nearestDistance := uuid.Nil, math.Inf(1)
+	for _, id := range utils.SortedIDs(objectMap) {
+		if distance := spatial.Distance(position, objectMap[id].GetPosition()); distance < nearestDistance {
+			nearest, nearestDistance = id, distance
+		}
+	}
+	return nearest
+}
+
+// checks the spatial queries of a game state against visiting every lootbox and bike
+func assertSpatialQueriesMatch(t *testing.T, gameState objects.IGameState, rng *rand.Rand) {
+	for i := 0; i < 20; i++ {
+		position := utils.GenerateRandomCoordinates(rng, 250.0, 250.0)
+		radius := 40.0 * rng.Float64()
+		assert.Equal(t, bruteForceInRadius(gameState.GetLootBoxes(), position, radius), utils.SortedIDs(gameState.GetLootBoxesInRadius(position, radius)))
+		assert.Equal(t, bruteForceInRadius(gameState.GetMegaBikes(), position, radius), utils.SortedIDs(gameState.GetMegaBikesInRadius(position, radius)))
+		assert.Equal(t, bruteForceNearestTo(gameState.GetLootBoxes(), position), gameState.GetNearestLootBox(position, nil).GetID())
+		assert.Equal(t, bruteForceNearestTo(gameState.GetMegaBikes(), position), gameState.GetNearestMegaBike(position, nil).GetID())
+	}
+}
+
+func TestSpatialIndexFollowsTheWorld(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	rng := rand.New(rand.NewSource(1))
+	assertSpatialQueriesMatch(t, s, rng)
+
+	// bikes move, lootboxes are looted and replenished, bikes may be removed by the Awdi...
+	dump := server.NewSimplifiedGameStateDump()
+	s.RunSimLoop(cfg.Simulation.RoundIterations, dump)
+	assertSpatialQueriesMatch(t, s, rng)
+
+	// ...and agents query the world as their view shows it, being given dumps
+	agent := s.GetAgentMap()[utils.SortedIDs(s.GetAgentMap())[0]]
+	s.RunActionProcess()
+	gameState := agentGameState(t, agent)
+	assertSpatialQueriesMatch(t, gameState, rng)
+	for _, bike := range gameState.GetMegaBikesInRadius(utils.Coordinates{X: 125.0, Y: 125.0}, 500.0) {
+		assert.IsType(t, server.BikeDump{}, bike)
+	}
+	nearest := gameState.GetNearestLootBox(utils.Coordinates{}, func(lootbox objects.ILootBox) bool {
+		return lootbox.GetColour() == agent.GetColour()
+	})
+	if nearest != nil {
+		assert.IsType(t, server.LootBoxDump{}, nearest)
+		assert.Equal(t, agent.GetColour(), nearest.GetColour())
+	}
+
+	fmt.Printf("\nSpatial index follows the world passed \n")
+}
+
+func TestAwdiTargetsNearestStationaryBike(t *testing.T) {
+	cfg := smallRunConfig(t)
+	cfg.Awdi.OnlyTargetsStationaryMegaBike = true
+	cfg.Awdi.TargetsEmptyMegaBike = true
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+
+	// every bike but two is moving
+	bikeIDs := utils.SortedIDs(s.GetMegaBikes())
+	for _, id := range bikeIDs[2:] {
+		bike := s.GetMegaBikes()[id]
+		state := bike.GetPhysicalState()
+		state.Velocity = 1.0
+		bike.SetPhysicalState(state)
+	}
+	stationary := map[uuid.UUID]objects.IMegaBike{bikeIDs[0]: s.GetMegaBikes()[bikeIDs[0]], bikeIDs[1]: s.GetMegaBikes()[bikeIDs[1]]}
+
+	s.GetAwdi().UpdateForce()
+	assert.Equal(t, bruteForceNearestTo(stationary, s.GetAwdi().GetPosition()), s.GetAwdi().GetTargetID())
+
+	fmt.Printf("\nAwdi targets nearest stationary bike passed \n")
+}
@@ -0,0 +1,111 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// sends two bikes with riders through the same lootbox in one move: the first passes straight through it, going
+// much faster than the collision threshold, and gets to it first; the second drives into it later in the step
+func raceToLootBox(t *testing.T, firstArrivalTakesLoot bool) (*server.EventRecorder, uuid.UUID, objects.IMegaBike, objects.IMegaBike) {
+	cfg := smallRunConfig(t)
+	cfg.Environment.FirstArrivalTakesLoot = firstArrivalTakesLoot
+	recorder := &server.EventRecorder{}
+	s := server.NewServer(server.WithConfig(cfg), server.WithEventSubscribers(recorder)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	lootboxID := utils.SortedIDs(s.GetLootBoxes())[0]
+	target := s.GetLootBoxes()[lootboxID].GetPosition()
+	racers := make([]objects.IMegaBike, 0, 2)
+	for _, id := range utils.SortedIDs(s.GetMegaBikes()) {
+		if bike := s.GetMegaBikes()[id]; len(bike.GetAgents()) > 0 && len(racers) < 2 {
+			racers = append(racers, bike)
+		}
+	}
+	if len(racers) < 2 {
+		t.Fatal("fewer than two bikes have riders")
+	}
+	move := func(bike objects.IMegaBike, from, to utils.Coordinates) {
+		bike.SetPhysicalState(utils.PhysicalState{Position: from, Mass: 1.0})
+		bike.MoveTo(utils.PhysicalState{Position: to, Mass: 1.0})
+	}
+	move(racers[0], utils.Coordinates{X: target.X - 20.0, Y: target.Y}, utils.Coordinates{X: target.X + 20.0, Y: target.Y})
+	move(racers[1], utils.Coordinates{X: target.X, Y: target.Y - 10.0}, utils.Coordinates{X: target.X, Y: target.Y - 4.0})
+
+	s.LootboxCheckAndDistributions()
+	return recorder, lootboxID, racers[0], racers[1]
+}
+
+// the share of the lootbox each bike got
+func lootShares(recorder *server.EventRecorder, lootboxID uuid.UUID) map[uuid.UUID]server.Event {
+	shares := make(map[uuid.UUID]server.Event)
+	for _, allocation := range recorder.OfType(server.LootAllocated) {
+		if allocation.LootBoxID == lootboxID {
+			shares[allocation.BikeID] = allocation
+		}
+	}
+	return shares
+}
+
+func TestFastBikesCollectLootBoxesOnTheirWay(t *testing.T) {
+	recorder, lootboxID, first, second := raceToLootBox(t, false)
+	shares := lootShares(recorder, lootboxID)
+
+	// both bikes reached the lootbox, and share it
+	assert.Contains(t, shares, first.GetID())
+	assert.Contains(t, shares, second.GetID())
+	assert.Equal(t, shares[first.GetID()].Resources, shares[second.GetID()].Resources)
+	// the one passing through it got there first
+	assert.InDelta(t, 13.0/40.0, shares[first.GetID()].ImpactTime, 1e-9)
+	assert.InDelta(t, 0.5, shares[second.GetID()].ImpactTime, 1e-9)
+
+	fmt.Printf("\nFast bikes collect lootboxes on their way passed \n")
+}
+
+func TestFirstArrivalTakesLootBox(t *testing.T) {
+	recorder, lootboxID, first, second := raceToLootBox(t, true)
+	shares := lootShares(recorder, lootboxID)
+
+	assert.Contains(t, shares, first.GetID())
+	assert.NotContains(t, shares, second.GetID())
+	// the first to get there doesn't share it
+	splitRecorder, _, _, _ := raceToLootBox(t, false)
+	split := lootShares(splitRecorder, lootboxID)
+	assert.InDelta(t, 2*split[first.GetID()].Resources, shares[first.GetID()].Resources, 1e-9)
+
+	fmt.Printf("\nFirst arrival takes lootbox passed \n")
+}
+
+func TestFastAwdiHitsBikesOnItsWay(t *testing.T) {
+	cfg := smallRunConfig(t)
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	var bike objects.IMegaBike
+	for _, id := range utils.SortedIDs(s.GetMegaBikes()) {
+		if bike = s.GetMegaBikes()[id]; len(bike.GetAgents()) > 0 {
+			break
+		}
+	}
+	riders := bike.GetAgents()
+	// the awdi ends up 20 units past the bike, having gone straight through it
+	position := bike.GetPosition()
+	s.GetAwdi().SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: position.X - 20.0, Y: position.Y}, Mass: 1.0})
+	s.GetAwdi().MoveTo(utils.PhysicalState{Position: utils.Coordinates{X: position.X + 20.0, Y: position.Y}, Mass: 1.0})
+	assert.False(t, s.GetAwdi().CheckForCollision(bike))
+
+	s.AwdiCollisionCheck()
+	for _, rider := range riders {
+		assert.NotContains(t, s.GetAgentMap(), rider.GetID())
+	}
+
+	fmt.Printf("\nFast awdi hits bikes on its way passed \n")
+}
@@ -6,17 +6,22 @@ import (
 	"SOMAS2023/internal/common/voting"
 	"SOMAS2023/internal/server"
 	"math"
-	"testing"
+	"math/rand"
 
 	"github.com/google/uuid"
 )
 
-func OnlySpawnBaseBikers(t *testing.T) {
-	oldInitFunctions := server.AgentInitFunctions
-	server.AgentInitFunctions = []server.AgentInitFunction{nil}
-	t.Cleanup(func() {
-		server.AgentInitFunctions = oldInitFunctions
-	})
+// random source for the agents and objects built by the tests themselves
+var testRng = rand.New(rand.NewSource(0))
+
+// a random source of its own for an agent built by the tests (agents may make their decisions concurrently)
+func agentRng() *rand.Rand {
+	return rand.New(rand.NewSource(testRng.Int63()))
+}
+
+// generates a server with the default parameters that only spawns base bikers
+func GenerateBaseBikerServer() server.IBaseBikerServer {
+	return server.NewServer(server.WithAgentInitFunctions(nil))
 }
 
 type NegativeAgent struct {
@@ -34,7 +39,7 @@ type INegativeAgent interface {
 
 func NewNegativeAgent(gameState objects.IGameState) *NegativeAgent {
 	return &NegativeAgent{
-		BaseBiker: objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New(), gameState),
+		BaseBiker: objects.GetBaseBiker(utils.GenerateRandomColour(testRng), uuid.New(), gameState, agentRng()),
 	}
 }
 
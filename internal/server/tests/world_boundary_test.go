@@ -0,0 +1,93 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// a server whose first bike (with riders) is about to cross the right edge of the grid
+func serverWithBikeAtEdge(t *testing.T, boundary config.BoundaryMode) (*server.Server, objects.IMegaBike) {
+	cfg := smallRunConfig(t)
+	cfg.Environment.Boundary = boundary
+	s := server.NewServer(server.WithConfig(cfg)).(*server.Server)
+	s.Initialize(cfg.Simulation.Iterations)
+	s.FoundingInstitutions()
+
+	for _, id := range utils.SortedIDs(s.GetMegaBikes()) {
+		bike := s.GetMegaBikes()[id]
+		if len(bike.GetAgents()) == 0 {
+			continue
+		}
+		for _, agent := range bike.GetAgents() {
+			agent.SetForces(utils.Forces{})
+		}
+		state := bike.GetPhysicalState()
+		state.Position = utils.Coordinates{X: cfg.Environment.GridWidth - 0.1, Y: 100.0}
+		state.Velocity = 1.0
+		bike.SetPhysicalState(state)
+		bike.SetOrientation(0.0)
+		return s, bike
+	}
+	t.Fatal("no bike has riders")
+	return nil, nil
+}
+
+func TestBikesStayWithinWalls(t *testing.T) {
+	for _, boundary := range []config.BoundaryMode{config.WrapBoundary, config.ReflectBoundary, config.AbsorbBoundary} {
+		s, bike := serverWithBikeAtEdge(t, boundary)
+		s.MovePhysicsObject(bike)
+		position := bike.GetPosition()
+		assert.True(t, position.X >= 0 && position.X <= 250.0, boundary.String())
+
+		switch boundary {
+		case config.WrapBoundary:
+			assert.Less(t, position.X, 1.0)
+			assert.Greater(t, bike.GetVelocity(), 0.0)
+		case config.ReflectBoundary:
+			assert.Less(t, position.X, 250.0)
+			assert.Equal(t, 1.0, bike.GetOrientation())
+		case config.AbsorbBoundary:
+			assert.Equal(t, 250.0, position.X)
+			assert.Equal(t, 0.0, bike.GetVelocity())
+		}
+		// the index follows the bike to wherever the boundary put it
+		assert.Contains(t, s.GetMegaBikesInRadius(position, 0.0), bike.GetID())
+
+		// and a whole run keeps every bike within the grid
+		s.RunSimLoop(5, server.NewSimplifiedGameStateDump())
+		for _, bike := range s.GetMegaBikes() {
+			position := bike.GetPosition()
+			assert.True(t, position.X >= 0 && position.X <= 250.0 && position.Y >= 0 && position.Y <= 250.0, boundary.String())
+		}
+	}
+
+	fmt.Printf("\nBikes stay within walls passed \n")
+}
+
+func TestAbsorbingWallCostsRidersEnergy(t *testing.T) {
+	s, bike := serverWithBikeAtEdge(t, config.AbsorbBoundary)
+	energy := make(map[uuid.UUID]float64)
+	for _, agent := range bike.GetAgents() {
+		energy[agent.GetID()] = agent.GetEnergyLevel()
+	}
+
+	s.MovePhysicsObject(bike)
+	for _, agent := range bike.GetAgents() {
+		assert.InDelta(t, energy[agent.GetID()]-s.GetConfig().Resources.WallEnergyPenalty, agent.GetEnergyLevel(), 1e-9)
+	}
+
+	// a bike stopped at the wall doesn't pay again for standing there
+	s.MovePhysicsObject(bike)
+	for _, agent := range bike.GetAgents() {
+		assert.InDelta(t, energy[agent.GetID()]-s.GetConfig().Resources.WallEnergyPenalty, agent.GetEnergyLevel(), 1e-9)
+	}
+
+	fmt.Printf("\nAbsorbing wall costs riders energy passed \n")
+}
@@ -0,0 +1,41 @@
+package sweep
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Confidence is the level of the confidence intervals reported by a sweep
+const Confidence = 0.95
+
+// Estimate summarises a quantity measured over the repetitions of a configuration
+type Estimate struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	CILow  float64 `json:"ci_low"`
+	CIHigh float64 `json:"ci_high"`
+}
+
+// NewEstimate computes the mean of the samples along with its Student's t confidence interval.
+// With fewer than two samples there's no spread to go on, so the interval is just the mean.
+func NewEstimate(samples []float64) Estimate {
+	if len(samples) == 0 {
+		return Estimate{}
+	}
+	mean := stat.Mean(samples, nil)
+	if len(samples) < 2 {
+		return Estimate{Mean: mean, CILow: mean, CIHigh: mean}
+	}
+
+	stdDev := stat.StdDev(samples, nil)
+	t := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(len(samples) - 1)}.Quantile(1 - (1-Confidence)/2)
+	halfWidth := t * stdDev / math.Sqrt(float64(len(samples)))
+	return Estimate{
+		Mean:   mean,
+		StdDev: stdDev,
+		CILow:  mean - halfWidth,
+		CIHigh: mean + halfWidth,
+	}
+}
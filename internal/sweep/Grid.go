@@ -0,0 +1,83 @@
+package sweep
+
+import (
+	"SOMAS2023/internal/common/config"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Grid lists the values each swept parameter takes. A parameter with no values keeps
+// the value of the base scenario, so an empty grid is a single configuration.
+type Grid struct {
+	Agents          []int
+	LootRatios      []float64
+	RuleCounts      []int
+	StratifyRules   []bool
+	GovernanceMixes []config.GovernanceMix
+}
+
+// Configurations returns a copy of the base scenario for every combination of the grid's values
+func (g Grid) Configurations(base *config.Config) []*config.Config {
+	configurations := []*config.Config{base.Clone()}
+
+	// expands every configuration so far by the values of one parameter
+	expand := func(count int, apply func(cfg *config.Config, i int)) {
+		if count == 0 {
+			return
+		}
+		expanded := make([]*config.Config, 0, len(configurations)*count)
+		for _, cfg := range configurations {
+			for i := 0; i < count; i++ {
+				next := cfg.Clone()
+				apply(next, i)
+				expanded = append(expanded, next)
+			}
+		}
+		configurations = expanded
+	}
+
+	expand(len(g.Agents), func(cfg *config.Config, i int) { cfg.Simulation.BikerAgentCount = g.Agents[i] })
+	expand(len(g.LootRatios), func(cfg *config.Config, i int) { cfg.Simulation.LootBoxRatio = g.LootRatios[i] })
+	expand(len(g.RuleCounts), func(cfg *config.Config, i int) { cfg.Simulation.GlobalRuleCount = g.RuleCounts[i] })
+	expand(len(g.StratifyRules), func(cfg *config.Config, i int) { cfg.Simulation.StratifyRules = g.StratifyRules[i] })
+	expand(len(g.GovernanceMixes), func(cfg *config.Config, i int) { cfg.Governance.FoundingMix = g.GovernanceMixes[i] })
+
+	return configurations
+}
+
+// ParseInts reads a comma separated list of integers (an empty string is an empty list)
+func ParseInts(text string) ([]int, error) {
+	return parseList(text, ",", strconv.Atoi)
+}
+
+// ParseFloats reads a comma separated list of floats (an empty string is an empty list)
+func ParseFloats(text string) ([]float64, error) {
+	return parseList(text, ",", func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+}
+
+// ParseBools reads a comma separated list of booleans (an empty string is an empty list)
+func ParseBools(text string) ([]bool, error) {
+	return parseList(text, ",", strconv.ParseBool)
+}
+
+// ParseGovernanceMixes reads a semicolon separated list of governance mixes, each written
+// as accepted by config.ParseGovernanceMix (e.g. "agents;democracy:1;democracy:1,dictatorship:1")
+func ParseGovernanceMixes(text string) ([]config.GovernanceMix, error) {
+	return parseList(text, ";", config.ParseGovernanceMix)
+}
+
+func parseList[T any](text string, separator string, parse func(string) (T, error)) ([]T, error) {
+	values := make([]T, 0)
+	if strings.TrimSpace(text) == "" {
+		return values, nil
+	}
+	for _, item := range strings.Split(text, separator) {
+		value, err := parse(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %q: %w", item, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
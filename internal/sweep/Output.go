@@ -0,0 +1,73 @@
+package sweep
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// WriteJSON writes the results as an indented JSON array
+func WriteJSON(w io.Writer, results []Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(results)
+}
+
+// WriteCSV writes one row per configuration, with the mean, standard deviation and
+// confidence interval of every statistic in their own columns
+func WriteCSV(w io.Writer, results []Result) error {
+	statistics := []struct {
+		name     string
+		estimate func(r *Result) Estimate
+	}{
+		{"agent_lifetime", func(r *Result) Estimate { return r.AgentLifetime }},
+		{"agent_energy_average", func(r *Result) Estimate { return r.EnergyAverage }},
+		{"agent_energy_variance", func(r *Result) Estimate { return r.EnergyVariance }},
+		{"agent_points_average", func(r *Result) Estimate { return r.PointsAverage }},
+		{"agent_points_variance", func(r *Result) Estimate { return r.PointsVariance }},
+		{"runtime_seconds", func(r *Result) Estimate { return r.RuntimeSeconds }},
+	}
+
+	header := []string{"agents", "loot_ratio", "global_rule_count", "stratify_rules", "governance_mix", "repetitions", "first_seed"}
+	for _, statistic := range statistics {
+		header = append(header,
+			statistic.name+"_mean",
+			statistic.name+"_std_dev",
+			statistic.name+"_ci_low",
+			statistic.name+"_ci_high",
+		)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+	for i := range results {
+		result := &results[i]
+		row := []string{
+			strconv.Itoa(result.Agents),
+			formatFloat(result.LootRatio),
+			strconv.Itoa(result.RuleCount),
+			strconv.FormatBool(result.StratifyRules),
+			result.GovernanceMix,
+			strconv.Itoa(result.Repetitions),
+			strconv.FormatInt(result.FirstSeed, 10),
+		}
+		for _, statistic := range statistics {
+			estimate := statistic.estimate(result)
+			row = append(row,
+				formatFloat(estimate.Mean),
+				formatFloat(estimate.StdDev),
+				formatFloat(estimate.CILow),
+				formatFloat(estimate.CIHigh),
+			)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
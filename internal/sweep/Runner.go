@@ -0,0 +1,147 @@
+package sweep
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/common/registry"
+	"SOMAS2023/internal/server"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Result aggregates the statistics of every repetition of one configuration of a sweep
+type Result struct {
+	Agents         int      `json:"agents"`
+	LootRatio      float64  `json:"loot_ratio"`
+	RuleCount      int      `json:"global_rule_count"`
+	StratifyRules  bool     `json:"stratify_rules"`
+	GovernanceMix  string   `json:"governance_mix"`
+	Repetitions    int      `json:"repetitions"`
+	FirstSeed      int64    `json:"first_seed"` // repetition i ran with seed FirstSeed + i
+	AgentLifetime  Estimate `json:"agent_lifetime"`
+	EnergyAverage  Estimate `json:"agent_energy_average"`
+	EnergyVariance Estimate `json:"agent_energy_variance"`
+	PointsAverage  Estimate `json:"agent_points_average"`
+	PointsVariance Estimate `json:"agent_points_variance"`
+	RuntimeSeconds Estimate `json:"runtime_seconds"`
+}
+
+// a single simulation run of the sweep
+type job struct {
+	configuration int
+	repetition    int
+	cfg           *config.Config
+}
+
+type outcome struct {
+	job
+	summary server.StatisticsSummary
+	runtime time.Duration
+}
+
+// Run simulates every configuration the given number of times, running up to workers servers at once,
+// and returns one result per configuration (in the order they were given). Repetition i of every
+// configuration is seeded with its Seed + i, so that configurations are compared on the same draws
+// (unseeded configurations all sharing a first seed picked from the clock).
+func Run(configurations []*config.Config, repetitions int, workers int) ([]Result, error) {
+	if repetitions < 1 {
+		return nil, fmt.Errorf("a sweep needs at least one repetition (got %d)", repetitions)
+	}
+	if workers < 1 {
+		return nil, fmt.Errorf("a sweep needs at least one worker (got %d)", workers)
+	}
+	unseeded := time.Now().UnixNano()
+	firstSeeds := make([]int64, len(configurations))
+	for i, cfg := range configurations {
+		firstSeeds[i] = unseeded
+		if cfg.Simulation.Seed != nil {
+			firstSeeds[i] = *cfg.Simulation.Seed
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("configuration %d: %w", i, err)
+		}
+		if err := registry.ValidateMix(cfg.Simulation.AgentMix); err != nil {
+			return nil, fmt.Errorf("configuration %d: %w", i, err)
+		}
+	}
+
+	jobs := make(chan job)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				outcomes <- simulate(j)
+			}
+		}()
+	}
+	go func() {
+		for c, cfg := range configurations {
+			for r := 0; r < repetitions; r++ {
+				runCfg := cfg.Clone()
+				runCfg.Simulation.Seed = config.Seed(firstSeeds[c] + int64(r))
+				jobs <- job{configuration: c, repetition: r, cfg: runCfg}
+			}
+		}
+		close(jobs)
+	}()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	// outcomes arrive in whatever order the runs finish, so they're slotted in by repetition
+	summaries := make([][]outcome, len(configurations))
+	for c := range summaries {
+		summaries[c] = make([]outcome, repetitions)
+	}
+	for o := range outcomes {
+		summaries[o.configuration][o.repetition] = o
+	}
+
+	results := make([]Result, 0, len(configurations))
+	for c, cfg := range configurations {
+		results = append(results, aggregate(cfg, firstSeeds[c], summaries[c]))
+	}
+	return results, nil
+}
+
+func simulate(j job) outcome {
+	start := time.Now()
+	s := server.NewServer(server.WithConfig(j.cfg))
+	s.Initialize(j.cfg.Simulation.Iterations)
+	statistics := s.Simulate()
+	return outcome{
+		job:     j,
+		summary: statistics.Summary(),
+		runtime: time.Since(start),
+	}
+}
+
+func aggregate(cfg *config.Config, firstSeed int64, outcomes []outcome) Result {
+	estimate := func(sample func(o *outcome) float64) Estimate {
+		samples := make([]float64, 0, len(outcomes))
+		for i := range outcomes {
+			samples = append(samples, sample(&outcomes[i]))
+		}
+		return NewEstimate(samples)
+	}
+
+	return Result{
+		Agents:         cfg.Simulation.BikerAgentCount,
+		LootRatio:      cfg.Simulation.LootBoxRatio,
+		RuleCount:      cfg.Simulation.GlobalRuleCount,
+		StratifyRules:  cfg.Simulation.StratifyRules,
+		GovernanceMix:  cfg.Governance.FoundingMix.String(),
+		Repetitions:    len(outcomes),
+		FirstSeed:      firstSeed,
+		AgentLifetime:  estimate(func(o *outcome) float64 { return o.summary.AgentLifetime }),
+		EnergyAverage:  estimate(func(o *outcome) float64 { return o.summary.AgentEnergyAverage }),
+		EnergyVariance: estimate(func(o *outcome) float64 { return o.summary.AgentEnergyVariance }),
+		PointsAverage:  estimate(func(o *outcome) float64 { return o.summary.AgentPointsAverage }),
+		PointsVariance: estimate(func(o *outcome) float64 { return o.summary.AgentPointsVariance }),
+		RuntimeSeconds: estimate(func(o *outcome) float64 { return o.runtime.Seconds() }),
+	}
+}
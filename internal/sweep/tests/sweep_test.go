@@ -0,0 +1,135 @@
+package sweep_test
+
+import (
+	"SOMAS2023/internal/common/config"
+	"SOMAS2023/internal/sweep"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGridConfigurations(t *testing.T) {
+	base := config.DefaultConfig()
+	grid := sweep.Grid{
+		Agents:          []int{16, 24, 32},
+		LootRatios:      []float64{1.5, 2.5},
+		GovernanceMixes: []config.GovernanceMix{{}, {Democracy: 1}},
+	}
+
+	configurations := grid.Configurations(base)
+
+	assert.Len(t, configurations, 12)
+	for _, cfg := range configurations {
+		// parameters that aren't swept keep the base value
+		assert.Equal(t, base.Simulation.GlobalRuleCount, cfg.Simulation.GlobalRuleCount)
+		assert.NotSame(t, base, cfg)
+	}
+	assert.Equal(t, 16, configurations[0].Simulation.BikerAgentCount)
+	assert.Equal(t, 1.5, configurations[0].Simulation.LootBoxRatio)
+	assert.False(t, configurations[0].Governance.FoundingMix.IsSet())
+	assert.Equal(t, 32, configurations[11].Simulation.BikerAgentCount)
+	assert.Equal(t, 2.5, configurations[11].Simulation.LootBoxRatio)
+	assert.True(t, configurations[11].Governance.FoundingMix.IsSet())
+	// the base scenario is left untouched
+	assert.Equal(t, config.DefaultConfig(), base)
+
+	assert.Len(t, sweep.Grid{}.Configurations(base), 1)
+
+	fmt.Printf("\nGrid configurations passed \n")
+}
+
+func TestGridConfigurationsAreIndependent(t *testing.T) {
+	base := config.DefaultConfig()
+	base.Simulation.AgentMix = config.AgentMix{{Team: "sosa", Count: 8}, {Team: "base", Count: 8}}
+	configurations := sweep.Grid{Agents: []int{16, 24}}.Configurations(base)
+
+	// changing the mix of one configuration leaves the others (and the base scenario) as they were
+	configurations[0].Simulation.AgentMix[0].Count = 4
+	assert.Equal(t, 8, configurations[1].Simulation.AgentMix[0].Count)
+	assert.Equal(t, 8, base.Simulation.AgentMix[0].Count)
+
+	fmt.Printf("\nGrid configurations are independent passed \n")
+}
+
+func TestParseLists(t *testing.T) {
+	ints, err := sweep.ParseInts("8, 16,24")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{8, 16, 24}, ints)
+
+	empty, err := sweep.ParseFloats("")
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+
+	mixes, err := sweep.ParseGovernanceMixes("agents;democracy:1,dictatorship:1")
+	assert.NoError(t, err)
+	assert.Equal(t, []config.GovernanceMix{{}, {Democracy: 1, Dictatorship: 1}}, mixes)
+
+	_, err = sweep.ParseBools("true,maybe")
+	assert.Error(t, err)
+
+	fmt.Printf("\nParse lists passed \n")
+}
+
+func TestEstimate(t *testing.T) {
+	estimate := sweep.NewEstimate([]float64{1, 2, 3, 4, 5})
+
+	assert.InDelta(t, 3.0, estimate.Mean, 1e-9)
+	assert.InDelta(t, 1.5811388, estimate.StdDev, 1e-6)
+	// t(0.975, 4) = 2.776445
+	assert.InDelta(t, 3.0-1.9632432, estimate.CILow, 1e-6)
+	assert.InDelta(t, 3.0+1.9632432, estimate.CIHigh, 1e-6)
+
+	single := sweep.NewEstimate([]float64{2})
+	assert.Equal(t, sweep.Estimate{Mean: 2, CILow: 2, CIHigh: 2}, single)
+
+	fmt.Printf("\nEstimate passed \n")
+}
+
+func TestRunSweep(t *testing.T) {
+	base := config.DefaultConfig()
+	base.Simulation.Iterations = 2
+	base.Simulation.RoundIterations = 10
+	base.Simulation.Seed = config.Seed(11)
+	grid := sweep.Grid{Agents: []int{8, 16}}
+
+	results, err := sweep.Run(grid.Configurations(base), 3, 4)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.Equal(t, 3, result.Repetitions)
+		assert.Equal(t, int64(11), result.FirstSeed)
+		assert.LessOrEqual(t, result.AgentLifetime.CILow, result.AgentLifetime.Mean)
+		assert.GreaterOrEqual(t, result.AgentLifetime.CIHigh, result.AgentLifetime.Mean)
+	}
+	assert.Equal(t, 8, results[0].Agents)
+	assert.Equal(t, 16, results[1].Agents)
+
+	// the same seeds give the same statistics, however the runs are scheduled
+	again, err := sweep.Run(grid.Configurations(base), 3, 1)
+	assert.NoError(t, err)
+	for i := range results {
+		assert.Equal(t, results[i].AgentLifetime, again[i].AgentLifetime)
+		assert.Equal(t, results[i].PointsAverage, again[i].PointsAverage)
+	}
+
+	csvOutput := new(bytes.Buffer)
+	assert.NoError(t, sweep.WriteCSV(csvOutput, results))
+	rows, err := csv.NewReader(csvOutput).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	jsonOutput := new(bytes.Buffer)
+	assert.NoError(t, sweep.WriteJSON(jsonOutput, results))
+	decoded := make([]sweep.Result, 0)
+	assert.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &decoded))
+	assert.Equal(t, results, decoded)
+
+	_, err = sweep.Run(grid.Configurations(base), 0, 1)
+	assert.Error(t, err)
+
+	fmt.Printf("\nRun sweep passed \n")
+}
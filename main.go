@@ -4,28 +4,32 @@ import (
 	"SOMAS2023/internal/common/globals"
 	"SOMAS2023/internal/server"
 	"flag"
-	"math"
+	"fmt"
+	"os"
 )
 
-func initialiseFlagConstants() {
-	globals.LootBoxCount = int(float64(*globals.BikerAgentCount) * *globals.LootBoxRatio)
-	bikesNeeded := math.Ceil(float64(*globals.BikerAgentCount) / 8)
-	globals.MegaBikeCount = int(bikesNeeded)
-}
-
 func main() {
+	flags := globals.RegisterFlags(flag.CommandLine)
 	flag.Parse()
-	initialiseFlagConstants()
-	s := server.GenerateServer()
-	s.Initialize(100)
-	s.Start()
+	cfg, err := flags.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	s := server.NewServer(server.WithConfig(cfg))
+	s.Initialize(cfg.Simulation.Iterations)
+	if err := s.RunWithOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "error running simulation: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 // func main() {
+// 	flags := globals.RegisterFlags(flag.CommandLine)
 // 	flag.Parse()
-// 	initialiseFlagConstants()
+// 	cfg, _ := flags.LoadConfig()
 
-// 	s := &server.Server{}
+// 	s := server.GenerateServerWithConfig(cfg)
 // 	s.Initialize(1)
 // 	s.FoundingInstitutions()
 // 	iters := 10000